@@ -0,0 +1,159 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker keeps the calls away from the failing dependency
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// Closed lets every call through, counting the failures in a row
+	Closed State = iota
+	// Open fails every call fast until the cooldown is over
+	Open
+	// HalfOpen lets a single trial call through, its outcome closes or opens the breaker again
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// The settings used unless New is given others
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 10 * time.Second
+)
+
+// Breaker stops calling a dependency that keeps failing. After Threshold failures in a row it opens and
+// the calls fail fast for Cooldown, then a trial call tells whether the dependency is back. Each call
+// allowed by Allow must report its outcome with Done.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	Now       func() time.Time
+	// OnChange is called on every change of state, e.g. to log it. It must not call the breaker.
+	OnChange func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = DefaultThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, Now: time.Now}
+}
+
+// Allow returns ErrOpen when the call must fail fast, nil when it can go through
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.Now().Sub(b.openedAt) < b.Cooldown {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.trial = true
+		return nil
+	case HalfOpen:
+		// The trial call is still running
+		if b.trial {
+			return ErrOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Done records the outcome of a call allowed by Allow, failed tells whether it counts against the dependency
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		if b.state == HalfOpen {
+			b.trial = false
+			b.setState(Closed)
+		}
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.state == HalfOpen:
+		b.open()
+	case b.state == Closed && b.failures >= b.Threshold:
+		b.open()
+	}
+}
+
+// State returns the state of the breaker, an open breaker past its cooldown is still reported open
+// until a call tries the dependency
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// RetryAfter is how long until the breaker lets a trial call through, zero when it isn't open
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if wait := b.Cooldown - b.Now().Sub(b.openedAt); wait > 0 {
+			return wait
+		}
+		return 0
+	case HalfOpen:
+		// Another call is trying the dependency, it should know shortly
+		return b.Cooldown
+	default:
+		return 0
+	}
+}
+
+func (b *Breaker) open() {
+	b.trial = false
+	b.openedAt = b.Now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	if state == b.state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.OnChange != nil {
+		b.OnChange(from, state)
+	}
+}
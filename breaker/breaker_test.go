@@ -0,0 +1,80 @@
+package breaker_test
+
+import (
+	"coffee/coffee-server/breaker"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Breaker", Label("unit"), func() {
+	var (
+		b       *breaker.Breaker
+		now     time.Time
+		changes []string
+	)
+
+	fail := func(times int) {
+		for i := 0; i < times; i++ {
+			Expect(b.Allow()).To(Succeed())
+			b.Done(true)
+		}
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2024, 11, 4, 9, 0, 0, 0, time.UTC)
+		changes = nil
+		b = breaker.New(3, 10*time.Second)
+		b.Now = func() time.Time { return now }
+		b.OnChange = func(from, to breaker.State) {
+			changes = append(changes, from.String()+" -> "+to.String())
+		}
+	})
+
+	It("should open after the threshold of failures in a row", func() {
+		fail(2)
+		Expect(b.Allow()).To(Succeed())
+		b.Done(false)
+		fail(2)
+		Expect(b.State()).To(Equal(breaker.Closed))
+
+		fail(1)
+		Expect(b.State()).To(Equal(breaker.Open))
+		Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+		Expect(b.RetryAfter()).To(Equal(10 * time.Second))
+
+		now = now.Add(4 * time.Second)
+		Expect(b.RetryAfter()).To(Equal(6 * time.Second))
+	})
+
+	It("should let a single trial call through after the cooldown", func() {
+		fail(3)
+		now = now.Add(10 * time.Second)
+
+		Expect(b.Allow()).To(Succeed())
+		Expect(b.State()).To(Equal(breaker.HalfOpen))
+		Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+
+		b.Done(false)
+		Expect(b.State()).To(Equal(breaker.Closed))
+		Expect(b.Allow()).To(Succeed())
+		Expect(changes).To(Equal([]string{"closed -> open", "open -> half-open", "half-open -> closed"}))
+	})
+
+	It("should open again when the trial call fails", func() {
+		fail(3)
+		now = now.Add(15 * time.Second)
+
+		fail(1)
+		Expect(b.State()).To(Equal(breaker.Open))
+		Expect(b.RetryAfter()).To(Equal(10 * time.Second))
+		Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+	})
+
+	It("should use the defaults for the settings left out", func() {
+		b := breaker.New(0, 0)
+		Expect(b.Threshold).To(Equal(breaker.DefaultThreshold))
+		Expect(b.Cooldown).To(Equal(breaker.DefaultCooldown))
+	})
+})
@@ -0,0 +1,110 @@
+package cache_test
+
+import (
+	"coffee/coffee-server/cache"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", Label("unit"), func() {
+	Describe("LRU", func() {
+		var (
+			lru *cache.LRU
+			now time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2024, 11, 4, 9, 0, 0, 0, time.UTC)
+			lru = cache.NewLRU(2)
+			lru.Now = func() time.Time { return now }
+		})
+
+		It("should evict the least recently used entry once full", func() {
+			lru.Set("a", []byte("1"), 0)
+			lru.Set("b", []byte("2"), 0)
+			_, ok := lru.Get("a")
+			Expect(ok).To(BeTrue())
+
+			lru.Set("c", []byte("3"), 0)
+
+			_, ok = lru.Get("b")
+			Expect(ok).To(BeFalse())
+			value, ok := lru.Get("a")
+			Expect(ok).To(BeTrue())
+			Expect(string(value)).To(Equal("1"))
+			Expect(lru.Len()).To(Equal(2))
+		})
+
+		It("should expire the entries after their ttl", func() {
+			lru.Set("a", []byte("1"), time.Minute)
+
+			now = now.Add(59 * time.Second)
+			_, ok := lru.Get("a")
+			Expect(ok).To(BeTrue())
+
+			now = now.Add(time.Second)
+			_, ok = lru.Get("a")
+			Expect(ok).To(BeFalse())
+			Expect(lru.Len()).To(BeZero())
+		})
+
+		It("should replace the value of an existing key", func() {
+			lru.Set("a", []byte("1"), 0)
+			lru.Set("a", []byte("2"), 0)
+
+			value, _ := lru.Get("a")
+			Expect(string(value)).To(Equal("2"))
+			Expect(lru.Len()).To(Equal(1))
+		})
+	})
+
+	Describe("Group", func() {
+		It("should share a single load between the concurrent callers of a key", func() {
+			var group cache.Group
+			var loads atomic.Int32
+			release := make(chan struct{})
+
+			var wg sync.WaitGroup
+			results := make([]string, 5)
+			for i := range results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					value, err, _ := group.Do("key", func() ([]byte, error) {
+						loads.Add(1)
+						<-release
+						return []byte("value"), nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					results[i] = string(value)
+				}()
+			}
+
+			Eventually(loads.Load).Should(Equal(int32(1)))
+			// Leave the other callers time to join the running load
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Expect(loads.Load()).To(Equal(int32(1)))
+			Expect(results).To(HaveEach("value"))
+		})
+
+		It("should load again once the previous call is done", func() {
+			var group cache.Group
+			loads := 0
+			load := func() ([]byte, error) {
+				loads++
+				return nil, nil
+			}
+
+			group.Do("key", load)
+			group.Do("key", load)
+			Expect(loads).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,738 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: catalog.proto
+
+package catalogpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	EventType_EVENT_TYPE_CREATED     EventType = 1
+	EventType_EVENT_TYPE_UPDATED     EventType = 2
+	EventType_EVENT_TYPE_DELETED     EventType = 3
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_CREATED",
+		2: "EVENT_TYPE_UPDATED",
+		3: "EVENT_TYPE_DELETED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"EVENT_TYPE_CREATED":     1,
+		"EVENT_TYPE_UPDATED":     2,
+		"EVENT_TYPE_DELETED":     3,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_catalog_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_catalog_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{0}
+}
+
+type Coffee struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Roast         string                 `protobuf:"bytes,3,opt,name=roast,proto3" json:"roast,omitempty"`
+	Image         string                 `protobuf:"bytes,4,opt,name=image,proto3" json:"image,omitempty"`
+	Region        string                 `protobuf:"bytes,5,opt,name=region,proto3" json:"region,omitempty"`
+	Price         float32                `protobuf:"fixed32,6,opt,name=price,proto3" json:"price,omitempty"`
+	GrindUnit     int32                  `protobuf:"varint,7,opt,name=grind_unit,json=grindUnit,proto3" json:"grind_unit,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Coffee) Reset() {
+	*x = Coffee{}
+	mi := &file_catalog_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Coffee) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coffee) ProtoMessage() {}
+
+func (x *Coffee) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coffee.ProtoReflect.Descriptor instead.
+func (*Coffee) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Coffee) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Coffee) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Coffee) GetRoast() string {
+	if x != nil {
+		return x.Roast
+	}
+	return ""
+}
+
+func (x *Coffee) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Coffee) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Coffee) GetPrice() float32 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Coffee) GetGrindUnit() int32 {
+	if x != nil {
+		return x.GrindUnit
+	}
+	return 0
+}
+
+func (x *Coffee) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Coffee) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListCoffeesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCoffeesRequest) Reset() {
+	*x = ListCoffeesRequest{}
+	mi := &file_catalog_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCoffeesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoffeesRequest) ProtoMessage() {}
+
+func (x *ListCoffeesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoffeesRequest.ProtoReflect.Descriptor instead.
+func (*ListCoffeesRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{1}
+}
+
+type ListCoffeesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Coffees       []*Coffee              `protobuf:"bytes,1,rep,name=coffees,proto3" json:"coffees,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCoffeesResponse) Reset() {
+	*x = ListCoffeesResponse{}
+	mi := &file_catalog_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCoffeesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoffeesResponse) ProtoMessage() {}
+
+func (x *ListCoffeesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoffeesResponse.ProtoReflect.Descriptor instead.
+func (*ListCoffeesResponse) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCoffeesResponse) GetCoffees() []*Coffee {
+	if x != nil {
+		return x.Coffees
+	}
+	return nil
+}
+
+type GetCoffeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCoffeeRequest) Reset() {
+	*x = GetCoffeeRequest{}
+	mi := &file_catalog_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCoffeeRequest) ProtoMessage() {}
+
+func (x *GetCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*GetCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCoffeeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateCoffeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Coffee        *Coffee                `protobuf:"bytes,1,opt,name=coffee,proto3" json:"coffee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCoffeeRequest) Reset() {
+	*x = CreateCoffeeRequest{}
+	mi := &file_catalog_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCoffeeRequest) ProtoMessage() {}
+
+func (x *CreateCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*CreateCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateCoffeeRequest) GetCoffee() *Coffee {
+	if x != nil {
+		return x.Coffee
+	}
+	return nil
+}
+
+type UpdateCoffeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Coffee        *Coffee                `protobuf:"bytes,2,opt,name=coffee,proto3" json:"coffee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCoffeeRequest) Reset() {
+	*x = UpdateCoffeeRequest{}
+	mi := &file_catalog_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCoffeeRequest) ProtoMessage() {}
+
+func (x *UpdateCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateCoffeeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateCoffeeRequest) GetCoffee() *Coffee {
+	if x != nil {
+		return x.Coffee
+	}
+	return nil
+}
+
+type DeleteCoffeeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCoffeeRequest) Reset() {
+	*x = DeleteCoffeeRequest{}
+	mi := &file_catalog_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCoffeeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCoffeeRequest) ProtoMessage() {}
+
+func (x *DeleteCoffeeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCoffeeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCoffeeRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteCoffeeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteCoffeeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCoffeeResponse) Reset() {
+	*x = DeleteCoffeeResponse{}
+	mi := &file_catalog_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCoffeeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCoffeeResponse) ProtoMessage() {}
+
+func (x *DeleteCoffeeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCoffeeResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCoffeeResponse) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{7}
+}
+
+type WatchCoffeesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AfterEventId  int64                  `protobuf:"varint,1,opt,name=after_event_id,json=afterEventId,proto3" json:"after_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCoffeesRequest) Reset() {
+	*x = WatchCoffeesRequest{}
+	mi := &file_catalog_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCoffeesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCoffeesRequest) ProtoMessage() {}
+
+func (x *WatchCoffeesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCoffeesRequest.ProtoReflect.Descriptor instead.
+func (*WatchCoffeesRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchCoffeesRequest) GetAfterEventId() int64 {
+	if x != nil {
+		return x.AfterEventId
+	}
+	return 0
+}
+
+type CoffeeEvent struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type     EventType              `protobuf:"varint,2,opt,name=type,proto3,enum=coffee.catalog.v1.EventType" json:"type,omitempty"`
+	CoffeeId string                 `protobuf:"bytes,3,opt,name=coffee_id,json=coffeeId,proto3" json:"coffee_id,omitempty"`
+	// Not set for deletions
+	Coffee        *Coffee                `protobuf:"bytes,4,opt,name=coffee,proto3" json:"coffee,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoffeeEvent) Reset() {
+	*x = CoffeeEvent{}
+	mi := &file_catalog_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoffeeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoffeeEvent) ProtoMessage() {}
+
+func (x *CoffeeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoffeeEvent.ProtoReflect.Descriptor instead.
+func (*CoffeeEvent) Descriptor() ([]byte, []int) {
+	return file_catalog_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CoffeeEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CoffeeEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *CoffeeEvent) GetCoffeeId() string {
+	if x != nil {
+		return x.CoffeeId
+	}
+	return ""
+}
+
+func (x *CoffeeEvent) GetCoffee() *Coffee {
+	if x != nil {
+		return x.Coffee
+	}
+	return nil
+}
+
+func (x *CoffeeEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_catalog_proto protoreflect.FileDescriptor
+
+const file_catalog_proto_rawDesc = "" +
+	"\n" +
+	"\rcatalog.proto\x12\x11coffee.catalog.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x02\n" +
+	"\x06Coffee\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05roast\x18\x03 \x01(\tR\x05roast\x12\x14\n" +
+	"\x05image\x18\x04 \x01(\tR\x05image\x12\x16\n" +
+	"\x06region\x18\x05 \x01(\tR\x06region\x12\x14\n" +
+	"\x05price\x18\x06 \x01(\x02R\x05price\x12\x1d\n" +
+	"\n" +
+	"grind_unit\x18\a \x01(\x05R\tgrindUnit\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x14\n" +
+	"\x12ListCoffeesRequest\"J\n" +
+	"\x13ListCoffeesResponse\x123\n" +
+	"\acoffees\x18\x01 \x03(\v2\x19.coffee.catalog.v1.CoffeeR\acoffees\"\"\n" +
+	"\x10GetCoffeeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"H\n" +
+	"\x13CreateCoffeeRequest\x121\n" +
+	"\x06coffee\x18\x01 \x01(\v2\x19.coffee.catalog.v1.CoffeeR\x06coffee\"X\n" +
+	"\x13UpdateCoffeeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x121\n" +
+	"\x06coffee\x18\x02 \x01(\v2\x19.coffee.catalog.v1.CoffeeR\x06coffee\"%\n" +
+	"\x13DeleteCoffeeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x16\n" +
+	"\x14DeleteCoffeeResponse\";\n" +
+	"\x13WatchCoffeesRequest\x12$\n" +
+	"\x0eafter_event_id\x18\x01 \x01(\x03R\fafterEventId\"\xdc\x01\n" +
+	"\vCoffeeEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x120\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1c.coffee.catalog.v1.EventTypeR\x04type\x12\x1b\n" +
+	"\tcoffee_id\x18\x03 \x01(\tR\bcoffeeId\x121\n" +
+	"\x06coffee\x18\x04 \x01(\v2\x19.coffee.catalog.v1.CoffeeR\x06coffee\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt*o\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12EVENT_TYPE_CREATED\x10\x01\x12\x16\n" +
+	"\x12EVENT_TYPE_UPDATED\x10\x02\x12\x16\n" +
+	"\x12EVENT_TYPE_DELETED\x10\x032\x9b\x04\n" +
+	"\rCoffeeCatalog\x12\\\n" +
+	"\vListCoffees\x12%.coffee.catalog.v1.ListCoffeesRequest\x1a&.coffee.catalog.v1.ListCoffeesResponse\x12K\n" +
+	"\tGetCoffee\x12#.coffee.catalog.v1.GetCoffeeRequest\x1a\x19.coffee.catalog.v1.Coffee\x12Q\n" +
+	"\fCreateCoffee\x12&.coffee.catalog.v1.CreateCoffeeRequest\x1a\x19.coffee.catalog.v1.Coffee\x12Q\n" +
+	"\fUpdateCoffee\x12&.coffee.catalog.v1.UpdateCoffeeRequest\x1a\x19.coffee.catalog.v1.Coffee\x12_\n" +
+	"\fDeleteCoffee\x12&.coffee.catalog.v1.DeleteCoffeeRequest\x1a'.coffee.catalog.v1.DeleteCoffeeResponse\x12X\n" +
+	"\fWatchCoffees\x12&.coffee.catalog.v1.WatchCoffeesRequest\x1a\x1e.coffee.catalog.v1.CoffeeEvent0\x01B*Z(coffee/coffee-server/catalogpb;catalogpbb\x06proto3"
+
+var (
+	file_catalog_proto_rawDescOnce sync.Once
+	file_catalog_proto_rawDescData []byte
+)
+
+func file_catalog_proto_rawDescGZIP() []byte {
+	file_catalog_proto_rawDescOnce.Do(func() {
+		file_catalog_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_catalog_proto_rawDesc), len(file_catalog_proto_rawDesc)))
+	})
+	return file_catalog_proto_rawDescData
+}
+
+var file_catalog_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_catalog_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_catalog_proto_goTypes = []any{
+	(EventType)(0),                // 0: coffee.catalog.v1.EventType
+	(*Coffee)(nil),                // 1: coffee.catalog.v1.Coffee
+	(*ListCoffeesRequest)(nil),    // 2: coffee.catalog.v1.ListCoffeesRequest
+	(*ListCoffeesResponse)(nil),   // 3: coffee.catalog.v1.ListCoffeesResponse
+	(*GetCoffeeRequest)(nil),      // 4: coffee.catalog.v1.GetCoffeeRequest
+	(*CreateCoffeeRequest)(nil),   // 5: coffee.catalog.v1.CreateCoffeeRequest
+	(*UpdateCoffeeRequest)(nil),   // 6: coffee.catalog.v1.UpdateCoffeeRequest
+	(*DeleteCoffeeRequest)(nil),   // 7: coffee.catalog.v1.DeleteCoffeeRequest
+	(*DeleteCoffeeResponse)(nil),  // 8: coffee.catalog.v1.DeleteCoffeeResponse
+	(*WatchCoffeesRequest)(nil),   // 9: coffee.catalog.v1.WatchCoffeesRequest
+	(*CoffeeEvent)(nil),           // 10: coffee.catalog.v1.CoffeeEvent
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_catalog_proto_depIdxs = []int32{
+	11, // 0: coffee.catalog.v1.Coffee.created_at:type_name -> google.protobuf.Timestamp
+	11, // 1: coffee.catalog.v1.Coffee.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: coffee.catalog.v1.ListCoffeesResponse.coffees:type_name -> coffee.catalog.v1.Coffee
+	1,  // 3: coffee.catalog.v1.CreateCoffeeRequest.coffee:type_name -> coffee.catalog.v1.Coffee
+	1,  // 4: coffee.catalog.v1.UpdateCoffeeRequest.coffee:type_name -> coffee.catalog.v1.Coffee
+	0,  // 5: coffee.catalog.v1.CoffeeEvent.type:type_name -> coffee.catalog.v1.EventType
+	1,  // 6: coffee.catalog.v1.CoffeeEvent.coffee:type_name -> coffee.catalog.v1.Coffee
+	11, // 7: coffee.catalog.v1.CoffeeEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	2,  // 8: coffee.catalog.v1.CoffeeCatalog.ListCoffees:input_type -> coffee.catalog.v1.ListCoffeesRequest
+	4,  // 9: coffee.catalog.v1.CoffeeCatalog.GetCoffee:input_type -> coffee.catalog.v1.GetCoffeeRequest
+	5,  // 10: coffee.catalog.v1.CoffeeCatalog.CreateCoffee:input_type -> coffee.catalog.v1.CreateCoffeeRequest
+	6,  // 11: coffee.catalog.v1.CoffeeCatalog.UpdateCoffee:input_type -> coffee.catalog.v1.UpdateCoffeeRequest
+	7,  // 12: coffee.catalog.v1.CoffeeCatalog.DeleteCoffee:input_type -> coffee.catalog.v1.DeleteCoffeeRequest
+	9,  // 13: coffee.catalog.v1.CoffeeCatalog.WatchCoffees:input_type -> coffee.catalog.v1.WatchCoffeesRequest
+	3,  // 14: coffee.catalog.v1.CoffeeCatalog.ListCoffees:output_type -> coffee.catalog.v1.ListCoffeesResponse
+	1,  // 15: coffee.catalog.v1.CoffeeCatalog.GetCoffee:output_type -> coffee.catalog.v1.Coffee
+	1,  // 16: coffee.catalog.v1.CoffeeCatalog.CreateCoffee:output_type -> coffee.catalog.v1.Coffee
+	1,  // 17: coffee.catalog.v1.CoffeeCatalog.UpdateCoffee:output_type -> coffee.catalog.v1.Coffee
+	8,  // 18: coffee.catalog.v1.CoffeeCatalog.DeleteCoffee:output_type -> coffee.catalog.v1.DeleteCoffeeResponse
+	10, // 19: coffee.catalog.v1.CoffeeCatalog.WatchCoffees:output_type -> coffee.catalog.v1.CoffeeEvent
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_catalog_proto_init() }
+func file_catalog_proto_init() {
+	if File_catalog_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_catalog_proto_rawDesc), len(file_catalog_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_catalog_proto_goTypes,
+		DependencyIndexes: file_catalog_proto_depIdxs,
+		EnumInfos:         file_catalog_proto_enumTypes,
+		MessageInfos:      file_catalog_proto_msgTypes,
+	}.Build()
+	File_catalog_proto = out.File
+	file_catalog_proto_goTypes = nil
+	file_catalog_proto_depIdxs = nil
+}
@@ -0,0 +1,321 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: catalog.proto
+
+package catalogpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CoffeeCatalog_ListCoffees_FullMethodName  = "/coffee.catalog.v1.CoffeeCatalog/ListCoffees"
+	CoffeeCatalog_GetCoffee_FullMethodName    = "/coffee.catalog.v1.CoffeeCatalog/GetCoffee"
+	CoffeeCatalog_CreateCoffee_FullMethodName = "/coffee.catalog.v1.CoffeeCatalog/CreateCoffee"
+	CoffeeCatalog_UpdateCoffee_FullMethodName = "/coffee.catalog.v1.CoffeeCatalog/UpdateCoffee"
+	CoffeeCatalog_DeleteCoffee_FullMethodName = "/coffee.catalog.v1.CoffeeCatalog/DeleteCoffee"
+	CoffeeCatalog_WatchCoffees_FullMethodName = "/coffee.catalog.v1.CoffeeCatalog/WatchCoffees"
+)
+
+// CoffeeCatalogClient is the client API for CoffeeCatalog service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CoffeeCatalog exposes the coffee catalog to internal services
+type CoffeeCatalogClient interface {
+	ListCoffees(ctx context.Context, in *ListCoffeesRequest, opts ...grpc.CallOption) (*ListCoffeesResponse, error)
+	GetCoffee(ctx context.Context, in *GetCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	CreateCoffee(ctx context.Context, in *CreateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	UpdateCoffee(ctx context.Context, in *UpdateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error)
+	DeleteCoffee(ctx context.Context, in *DeleteCoffeeRequest, opts ...grpc.CallOption) (*DeleteCoffeeResponse, error)
+	// WatchCoffees streams the catalog changes, starting after after_event_id when it is set
+	WatchCoffees(ctx context.Context, in *WatchCoffeesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CoffeeEvent], error)
+}
+
+type coffeeCatalogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoffeeCatalogClient(cc grpc.ClientConnInterface) CoffeeCatalogClient {
+	return &coffeeCatalogClient{cc}
+}
+
+func (c *coffeeCatalogClient) ListCoffees(ctx context.Context, in *ListCoffeesRequest, opts ...grpc.CallOption) (*ListCoffeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCoffeesResponse)
+	err := c.cc.Invoke(ctx, CoffeeCatalog_ListCoffees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeCatalogClient) GetCoffee(ctx context.Context, in *GetCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeCatalog_GetCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeCatalogClient) CreateCoffee(ctx context.Context, in *CreateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeCatalog_CreateCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeCatalogClient) UpdateCoffee(ctx context.Context, in *UpdateCoffeeRequest, opts ...grpc.CallOption) (*Coffee, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Coffee)
+	err := c.cc.Invoke(ctx, CoffeeCatalog_UpdateCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeCatalogClient) DeleteCoffee(ctx context.Context, in *DeleteCoffeeRequest, opts ...grpc.CallOption) (*DeleteCoffeeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCoffeeResponse)
+	err := c.cc.Invoke(ctx, CoffeeCatalog_DeleteCoffee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coffeeCatalogClient) WatchCoffees(ctx context.Context, in *WatchCoffeesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CoffeeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CoffeeCatalog_ServiceDesc.Streams[0], CoffeeCatalog_WatchCoffees_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCoffeesRequest, CoffeeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CoffeeCatalog_WatchCoffeesClient = grpc.ServerStreamingClient[CoffeeEvent]
+
+// CoffeeCatalogServer is the server API for CoffeeCatalog service.
+// All implementations must embed UnimplementedCoffeeCatalogServer
+// for forward compatibility.
+//
+// CoffeeCatalog exposes the coffee catalog to internal services
+type CoffeeCatalogServer interface {
+	ListCoffees(context.Context, *ListCoffeesRequest) (*ListCoffeesResponse, error)
+	GetCoffee(context.Context, *GetCoffeeRequest) (*Coffee, error)
+	CreateCoffee(context.Context, *CreateCoffeeRequest) (*Coffee, error)
+	UpdateCoffee(context.Context, *UpdateCoffeeRequest) (*Coffee, error)
+	DeleteCoffee(context.Context, *DeleteCoffeeRequest) (*DeleteCoffeeResponse, error)
+	// WatchCoffees streams the catalog changes, starting after after_event_id when it is set
+	WatchCoffees(*WatchCoffeesRequest, grpc.ServerStreamingServer[CoffeeEvent]) error
+	mustEmbedUnimplementedCoffeeCatalogServer()
+}
+
+// UnimplementedCoffeeCatalogServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCoffeeCatalogServer struct{}
+
+func (UnimplementedCoffeeCatalogServer) ListCoffees(context.Context, *ListCoffeesRequest) (*ListCoffeesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListCoffees not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) GetCoffee(context.Context, *GetCoffeeRequest) (*Coffee, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCoffee not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) CreateCoffee(context.Context, *CreateCoffeeRequest) (*Coffee, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateCoffee not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) UpdateCoffee(context.Context, *UpdateCoffeeRequest) (*Coffee, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateCoffee not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) DeleteCoffee(context.Context, *DeleteCoffeeRequest) (*DeleteCoffeeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCoffee not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) WatchCoffees(*WatchCoffeesRequest, grpc.ServerStreamingServer[CoffeeEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchCoffees not implemented")
+}
+func (UnimplementedCoffeeCatalogServer) mustEmbedUnimplementedCoffeeCatalogServer() {}
+func (UnimplementedCoffeeCatalogServer) testEmbeddedByValue()                       {}
+
+// UnsafeCoffeeCatalogServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoffeeCatalogServer will
+// result in compilation errors.
+type UnsafeCoffeeCatalogServer interface {
+	mustEmbedUnimplementedCoffeeCatalogServer()
+}
+
+func RegisterCoffeeCatalogServer(s grpc.ServiceRegistrar, srv CoffeeCatalogServer) {
+	// If the following call panics, it indicates UnimplementedCoffeeCatalogServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CoffeeCatalog_ServiceDesc, srv)
+}
+
+func _CoffeeCatalog_ListCoffees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCoffeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeCatalogServer).ListCoffees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeCatalog_ListCoffees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeCatalogServer).ListCoffees(ctx, req.(*ListCoffeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeCatalog_GetCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeCatalogServer).GetCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeCatalog_GetCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeCatalogServer).GetCoffee(ctx, req.(*GetCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeCatalog_CreateCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeCatalogServer).CreateCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeCatalog_CreateCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeCatalogServer).CreateCoffee(ctx, req.(*CreateCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeCatalog_UpdateCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeCatalogServer).UpdateCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeCatalog_UpdateCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeCatalogServer).UpdateCoffee(ctx, req.(*UpdateCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeCatalog_DeleteCoffee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCoffeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoffeeCatalogServer).DeleteCoffee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoffeeCatalog_DeleteCoffee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoffeeCatalogServer).DeleteCoffee(ctx, req.(*DeleteCoffeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoffeeCatalog_WatchCoffees_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCoffeesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoffeeCatalogServer).WatchCoffees(m, &grpc.GenericServerStream[WatchCoffeesRequest, CoffeeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CoffeeCatalog_WatchCoffeesServer = grpc.ServerStreamingServer[CoffeeEvent]
+
+// CoffeeCatalog_ServiceDesc is the grpc.ServiceDesc for CoffeeCatalog service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CoffeeCatalog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "coffee.catalog.v1.CoffeeCatalog",
+	HandlerType: (*CoffeeCatalogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCoffees",
+			Handler:    _CoffeeCatalog_ListCoffees_Handler,
+		},
+		{
+			MethodName: "GetCoffee",
+			Handler:    _CoffeeCatalog_GetCoffee_Handler,
+		},
+		{
+			MethodName: "CreateCoffee",
+			Handler:    _CoffeeCatalog_CreateCoffee_Handler,
+		},
+		{
+			MethodName: "UpdateCoffee",
+			Handler:    _CoffeeCatalog_UpdateCoffee_Handler,
+		},
+		{
+			MethodName: "DeleteCoffee",
+			Handler:    _CoffeeCatalog_DeleteCoffee_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCoffees",
+			Handler:       _CoffeeCatalog_WatchCoffees_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "catalog.proto",
+}
@@ -0,0 +1,261 @@
+package client
+
+import (
+	"bytes"
+	"coffee/coffee-server/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultRetryWait = 200 * time.Millisecond
+	maxRetryWait     = 5 * time.Second
+)
+
+// AuthFunc is called on every request before it is sent, e.g. to set an Authorization header
+type AuthFunc func(req *http.Request) error
+
+// Client calls the coffee API over HTTP
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Retries is how many times a request is tried again after a network error or a 429, 502, 503 or 504.
+	// Only the idempotent calls are retried, a create is sent once.
+	Retries int
+	// RetryWait is the wait before the first retry, it doubles after every attempt
+	RetryWait time.Duration
+	Auth      AuthFunc
+	UserAgent string
+	// Tenant is the slug of the shop whose catalog is served, the server picks its default when empty
+	Tenant string
+}
+
+// Option changes how the client is set up
+type Option func(*Client)
+
+// New returns a client of the API served at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+		RetryWait:  defaultRetryWait,
+		UserAgent:  "coffee-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithTimeout bounds every attempt of a request, the context bounds the call as a whole. The timeout is
+// set on a copy, an *http.Client given with WithHTTPClient is left as it is.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		httpClient := *c.HTTPClient
+		httpClient.Timeout = timeout
+		c.HTTPClient = &httpClient
+	}
+}
+
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.Retries = retries
+		c.RetryWait = wait
+	}
+}
+
+func WithAuth(auth AuthFunc) Option {
+	return func(c *Client) {
+		c.Auth = auth
+	}
+}
+
+// WithTenant sends the requests to the catalog of the tenant
+func WithTenant(slug string) Option {
+	return func(c *Client) {
+		c.Tenant = slug
+	}
+}
+
+// WithBearerToken sends the token in the Authorization header of every request
+func WithBearerToken(token string) Option {
+	return WithAuth(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// Error is an error response of the API, decoded from its services.JsonResponse body
+type Error struct {
+	StatusCode int
+	Message    string
+	Data       interface{}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("coffee api: %d %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) GetAllCoffees(ctx context.Context) ([]*services.Coffee, error) {
+	var envelope struct {
+		Coffees []*services.Coffee `json:"coffees"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/coffees", nil, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Coffees, nil
+}
+
+func (c *Client) GetCoffeesById(ctx context.Context, id string) (*services.Coffee, error) {
+	var envelope struct {
+		Coffee *services.Coffee `json:"coffee"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/coffees/coffee/"+url.PathEscape(id), nil, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Coffee, nil
+}
+
+func (c *Client) CreateCoffee(ctx context.Context, coffee services.Coffee) (*services.Coffee, error) {
+	var envelope struct {
+		Coffee *services.Coffee `json:"coffees"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/coffees/coffee", coffee, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Coffee, nil
+}
+
+func (c *Client) UpdateCoffee(ctx context.Context, id string, coffee services.Coffee) (*services.Coffee, error) {
+	var envelope struct {
+		Coffee *services.Coffee `json:"coffees"`
+	}
+	if err := c.do(ctx, http.MethodPut, "/api/v1/coffees/coffee/"+url.PathEscape(id), coffee, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Coffee, nil
+}
+
+func (c *Client) DeleteCoffee(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/coffees/coffee/"+url.PathEscape(id), nil, nil)
+}
+
+// do sends the request, retrying it when allowed, and decodes the response body into out
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	attempts := 1
+	if method != http.MethodPost {
+		attempts += c.Retries
+	}
+
+	wait := c.RetryWait
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, method, path, body, out)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxRetryWait)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if c.Tenant != "" {
+		req.Header.Set("X-Tenant", c.Tenant)
+	}
+	if c.Auth != nil {
+		if err := c.Auth(req); err != nil {
+			return err
+		}
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return decodeError(res)
+	}
+
+	if out == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// decodeError reads the services.JsonResponse of a failed call, falling back to the status text
+// when the body is something else, e.g. the page of a proxy in front of the API
+func decodeError(res *http.Response) error {
+	apiErr := &Error{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+
+	var payload services.JsonResponse
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err := json.Unmarshal(data, &payload); err == nil && payload.Message != "" {
+		apiErr.Message = payload.Message
+		apiErr.Data = payload.Data
+	}
+	return apiErr
+}
+
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// Anything else failed before a response came back, e.g. a refused connection or an attempt timing out,
+	// but not a body that couldn't be decoded
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
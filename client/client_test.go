@@ -0,0 +1,215 @@
+package client_test
+
+import (
+	"coffee/coffee-server/client"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Client", Label("unit"), func() {
+	var (
+		mockedCoffee *mocks.CoffeeService
+		api          http.Handler
+		server       *httptest.Server
+		ctx          context.Context
+	)
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+		api = router.Routes(services.Models{
+			Coffee:       mockedCoffee,
+			CoffeeStream: new(mocks.CoffeeStream),
+			Order:        new(mocks.OrderService),
+			Cart:         new(mocks.CartService),
+			Payment:      new(mocks.PaymentService),
+			Promotion:    new(mocks.PromotionService),
+			Webhook:      new(mocks.WebhookService),
+		}, router.WithValidation())
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	serve := func(handler http.Handler) {
+		server = httptest.NewServer(handler)
+	}
+
+	Describe("coffee operations", func() {
+		var c *client.Client
+
+		BeforeEach(func() {
+			serve(api)
+			c = client.New(server.URL)
+		})
+
+		It("should list the coffees", func() {
+			mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1", Name: "Espresso"}, {ID: "c2", Name: "Mocha"}}, nil)
+
+			coffees, err := c.GetAllCoffees(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coffees).To(HaveLen(2))
+			Expect(coffees[1].Name).To(Equal("Mocha"))
+		})
+
+		It("should get a coffee by id", func() {
+			mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Espresso", Price: 10}, nil)
+
+			coffee, err := c.GetCoffeesById(ctx, "c1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coffee.Name).To(Equal("Espresso"))
+			Expect(coffee.Price).To(Equal(float32(10)))
+		})
+
+		It("should create, update and delete a coffee", func() {
+			mockedCoffee.On("CreateCoffee", mock.MatchedBy(func(c services.Coffee) bool { return c.Name == "Mocha" })).
+				Return(&services.Coffee{ID: "c3", Name: "Mocha"}, nil)
+			mockedCoffee.On("UpdateCoffee", "c3", mock.MatchedBy(func(c services.Coffee) bool { return c.Name == "Mocha Java" })).
+				Return(&services.Coffee{Name: "Mocha Java"}, nil)
+			mockedCoffee.On("DeleteCoffee", "c3").Return(nil)
+
+			created, err := c.CreateCoffee(ctx, services.Coffee{Name: "Mocha"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.ID).To(Equal("c3"))
+
+			updated, err := c.UpdateCoffee(ctx, "c3", services.Coffee{Name: "Mocha Java"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Name).To(Equal("Mocha Java"))
+
+			Expect(c.DeleteCoffee(ctx, "c3")).To(Succeed())
+			mockedCoffee.AssertExpectations(GinkgoT())
+		})
+
+		It("should decode the error body of the API", func() {
+			mockedCoffee.On("GetCoffeesById", "missing").Return(nil, errors.New("sql: no rows in result set"))
+
+			_, err := c.GetCoffeesById(ctx, "missing")
+
+			var apiErr *client.Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(apiErr.Message).To(Equal("sql: no rows in result set"))
+		})
+
+		It("should satisfy services.CoffeeService through the adapter", func() {
+			mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1"}}, nil)
+
+			var service services.CoffeeService = client.NewCoffeeService(c, time.Second)
+			coffees, err := service.GetAllCoffees()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coffees).To(HaveLen(1))
+		})
+	})
+
+	Describe("retries", func() {
+		var calls atomic.Int32
+
+		BeforeEach(func() {
+			calls.Store(0)
+			mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{}, nil)
+			mockedCoffee.On("CreateCoffee", mock.Anything).Return(&services.Coffee{ID: "c1"}, nil)
+
+			// The first two calls fail like a restarting server behind a proxy
+			serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				api.ServeHTTP(w, r)
+			}))
+		})
+
+		It("should retry an idempotent call until it succeeds", func() {
+			c := client.New(server.URL, client.WithRetries(2, time.Millisecond))
+
+			_, err := c.GetAllCoffees(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls.Load()).To(Equal(int32(3)))
+		})
+
+		It("should give up once the retries are used up", func() {
+			c := client.New(server.URL, client.WithRetries(1, time.Millisecond))
+
+			_, err := c.GetAllCoffees(ctx)
+
+			var apiErr *client.Error
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(apiErr.Message).To(Equal("Service Unavailable"))
+			Expect(calls.Load()).To(Equal(int32(2)))
+		})
+
+		It("should never resend a create", func() {
+			c := client.New(server.URL, client.WithRetries(2, time.Millisecond))
+
+			_, err := c.CreateCoffee(ctx, services.Coffee{Name: "Mocha"})
+			Expect(err).To(HaveOccurred())
+			Expect(calls.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	It("should stop waiting when an attempt times out", func() {
+		serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		c := client.New(server.URL, client.WithTimeout(20*time.Millisecond))
+
+		start := time.Now()
+		_, err := c.GetAllCoffees(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+	})
+
+	It("should leave the timeout of a shared http.Client alone", func() {
+		shared := &http.Client{Timeout: time.Minute}
+		c := client.New("http://localhost:8080", client.WithHTTPClient(shared), client.WithTimeout(20*time.Millisecond))
+
+		Expect(shared.Timeout).To(Equal(time.Minute))
+		Expect(c.HTTPClient.Timeout).To(Equal(20 * time.Millisecond))
+	})
+
+	It("should run the auth hook on every request", func() {
+		mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{}, nil)
+		var authorization string
+		serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			api.ServeHTTP(w, r)
+		}))
+		c := client.New(server.URL, client.WithBearerToken("s3cret"))
+
+		_, err := c.GetAllCoffees(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorization).To(Equal("Bearer s3cret"))
+	})
+
+	It("should name the tenant of every request", func() {
+		mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{}, nil)
+		var tenant string
+		serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant = r.Header.Get("X-Tenant")
+			api.ServeHTTP(w, r)
+		}))
+		c := client.New(server.URL, client.WithTenant("roastery"))
+
+		_, err := c.GetAllCoffees(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tenant).To(Equal("roastery"))
+	})
+})
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"coffee/coffee-server/services"
+	"context"
+	"time"
+)
+
+// CoffeeService adapts the client to services.CoffeeService, so code written against the service
+// can run against a remote API. Every call is bounded by Timeout, retries included.
+type CoffeeService struct {
+	Client  *Client
+	Timeout time.Duration
+}
+
+var _ services.CoffeeService = (*CoffeeService)(nil)
+
+func NewCoffeeService(client *Client, timeout time.Duration) *CoffeeService {
+	return &CoffeeService{Client: client, Timeout: timeout}
+}
+
+func (s *CoffeeService) GetAllCoffees() ([]*services.Coffee, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.GetAllCoffees(ctx)
+}
+
+func (s *CoffeeService) CreateCoffee(coffee services.Coffee) (*services.Coffee, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.CreateCoffee(ctx, coffee)
+}
+
+func (s *CoffeeService) GetCoffeesById(id string) (*services.Coffee, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.GetCoffeesById(ctx, id)
+}
+
+func (s *CoffeeService) UpdateCoffee(id string, coffee services.Coffee) (*services.Coffee, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.UpdateCoffee(ctx, id, coffee)
+}
+
+func (s *CoffeeService) DeleteCoffee(id string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.Client.DeleteCoffee(ctx, id)
+}
+
+func (s *CoffeeService) context() (context.Context, context.CancelFunc) {
+	if s.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.Timeout)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/migrations"
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+)
+
+type migrationRow struct {
+	Version     int64  `json:"version"`
+	Description string `json:"description"`
+	Applied     bool   `json:"applied"`
+}
+
+type migrationRows []migrationRow
+
+func (m migrationRows) table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(m))
+	for _, migration := range m {
+		status := "pending"
+		if migration.Applied {
+			status = "applied"
+		}
+		rows = append(rows, []string{strconv.FormatInt(migration.Version, 10), migration.Description, status})
+	}
+	return []string{"VERSION", "DESCRIPTION", "STATUS"}, rows
+}
+
+func toRows(all []*db.Migration) migrationRows {
+	rows := make(migrationRows, 0, len(all))
+	for _, migration := range all {
+		rows = append(rows, migrationRow{Version: migration.Version, Description: migration.Description, Applied: migration.Applied})
+	}
+	return rows
+}
+
+// migrate runs the migrations embedded in the binary, or the ones of -dir
+func (c *ctl) migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: migrate up|down|status", errUsage)
+	}
+	direction := args[0]
+
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := flags.String("dir", "", "directory holding the migrations, the embedded ones when left out")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	var files fs.FS = migrations.Files
+	if *dir != "" {
+		files = os.DirFS(*dir)
+	}
+	all, err := db.LoadMigrations(files)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.database()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch direction {
+	case "up":
+		applied, err := db.MigrateUp(ctx, conn, all)
+		for _, migration := range applied {
+			fmt.Fprintf(c.stdout, "Applied %d %s\n", migration.Version, migration.Description)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(c.stdout, "Nothing to migrate")
+		}
+		return err
+	case "down":
+		reverted, err := db.MigrateDown(ctx, conn, all)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintln(c.stdout, "Nothing to revert")
+			return nil
+		}
+		fmt.Fprintf(c.stdout, "Reverted %d %s\n", reverted.Version, reverted.Description)
+		return nil
+	case "status":
+		if err := db.MigrationStatus(ctx, conn, all); err != nil {
+			return err
+		}
+		return render(c.stdout, c.output, toRows(all))
+	default:
+		return fmt.Errorf("%w: unknown migrate command %q", errUsage, direction)
+	}
+}
+
+type healthReport struct {
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (h healthReport) table() ([]string, [][]string) {
+	return []string{"TARGET", "STATUS", "LATENCY", "ERROR"}, [][]string{
+		{h.Target, h.Status, strconv.FormatInt(h.LatencyMs, 10) + "ms", h.Error},
+	}
+}
+
+// health pings the database with -dsn and otherwise makes a catalog request to the API
+func (c *ctl) health() error {
+	report := healthReport{Target: c.api, Status: "ok"}
+	start := time.Now()
+
+	var err error
+	if c.dsn != "" {
+		report.Target = "postgres"
+		if _, err = c.database(); err == nil {
+			err = c.conn.Ping()
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		_, err = c.apiClient().GetAllCoffees(ctx)
+	}
+
+	report.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		report.Status = "down"
+		report.Error = err.Error()
+	}
+	if renderErr := render(c.stdout, c.output, report); renderErr != nil {
+		return renderErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s is down", report.Target)
+	}
+	return nil
+}
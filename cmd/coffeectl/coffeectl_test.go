@@ -0,0 +1,142 @@
+package main
+
+// The tests sit in package main as a command can't be imported
+
+import (
+	"bytes"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("coffeectl", Label("unit"), func() {
+	var (
+		mockedCoffee   *mocks.CoffeeService
+		server         *httptest.Server
+		stdout, stderr *bytes.Buffer
+	)
+
+	coffees := []*services.Coffee{
+		{ID: "c1", Name: "Espresso", Roast: "Dark", Region: "Brazil", Price: 10, GrindUnit: 1},
+		{ID: "c2", Name: "Mocha", Roast: "Medium", Region: "Yemen", Price: 18.5, GrindUnit: 2},
+	}
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+		server = httptest.NewServer(router.Routes(services.Models{
+			Coffee:       mockedCoffee,
+			CoffeeStream: new(mocks.CoffeeStream),
+			Order:        new(mocks.OrderService),
+			Cart:         new(mocks.CartService),
+			Payment:      new(mocks.PaymentService),
+			Promotion:    new(mocks.PromotionService),
+			Webhook:      new(mocks.WebhookService),
+		}))
+		stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	run := func(args ...string) int {
+		c := &ctl{stdout: stdout, stderr: stderr}
+		return c.run(append([]string{"-api", server.URL, "-dsn", ""}, args...))
+	}
+
+	It("should list the coffees as a table", func() {
+		mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+
+		Expect(run("list")).To(Equal(0))
+		Expect(stdout.String()).To(MatchRegexp(`ID\s+NAME\s+ROAST\s+REGION\s+PRICE`))
+		Expect(stdout.String()).To(MatchRegexp(`c2\s+Mocha\s+Medium\s+Yemen\s+18.50\s+2`))
+	})
+
+	It("should show a coffee as JSON or YAML", func() {
+		mockedCoffee.On("GetCoffeesById", "c1").Return(coffees[0], nil)
+
+		Expect(run("-o", "json", "get", "c1")).To(Equal(0))
+		var decoded []services.Coffee
+		Expect(json.Unmarshal(stdout.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded[0].Name).To(Equal("Espresso"))
+
+		stdout.Reset()
+		Expect(run("-o", "yaml", "get", "c1")).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("- id: c1\n  name: Espresso\n"))
+		Expect(stdout.String()).To(ContainSubstring("grind_unit: 1"))
+	})
+
+	It("should create a coffee from the field flags", func() {
+		mockedCoffee.On("CreateCoffee", services.Coffee{Name: "Kona", Roast: "Light", Region: "Hawaii", Price: 30, GrindUnit: 1}).
+			Return(&services.Coffee{ID: "c3", Name: "Kona"}, nil)
+
+		Expect(run("create", "-name", "Kona", "-roast", "Light", "-region", "Hawaii", "-price", "30", "-grind", "1")).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("c3"))
+	})
+
+	It("should only change the fields given to update", func() {
+		mockedCoffee.On("GetCoffeesById", "c1").Return(coffees[0], nil)
+		mockedCoffee.On("UpdateCoffee", "c1", mock.MatchedBy(func(c services.Coffee) bool {
+			return c.Name == "Espresso" && c.Region == "Brazil" && c.Price == 12
+		})).Return(&services.Coffee{Name: "Espresso", Price: 12}, nil)
+
+		Expect(run("update", "c1", "-price", "12")).To(Equal(0))
+		mockedCoffee.AssertExpectations(GinkgoT())
+	})
+
+	It("should export the catalog and import it back", func() {
+		mockedCoffee.On("GetAllCoffees").Return(coffees[:1], nil)
+		mockedCoffee.On("UpdateCoffee", "c1", mock.Anything).Return(&services.Coffee{}, nil)
+		mockedCoffee.On("CreateCoffee", mock.MatchedBy(func(c services.Coffee) bool { return c.ID == "" && c.Name == "Mocha" })).
+			Return(&services.Coffee{ID: "c9"}, nil)
+
+		file := filepath.Join(GinkgoT().TempDir(), "catalog.yaml")
+		Expect(run("export", "-f", file)).To(Equal(0))
+
+		exported, err := os.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(exported)).To(ContainSubstring("name: Espresso"))
+
+		// Add a coffee to the export, the existing one gets updated and the new one created
+		Expect(os.WriteFile(file, append(exported, []byte("- id: c2\n  name: Mocha\n  roast: Medium\n  region: Yemen\n")...), 0o600)).To(Succeed())
+
+		Expect(run("import", "-upsert", "-f", file)).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("Imported 2 coffees: 1 created, 1 updated"))
+		mockedCoffee.AssertExpectations(GinkgoT())
+	})
+
+	It("should report the API as healthy", func() {
+		mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+
+		Expect(run("-o", "json", "health")).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring(`"status": "ok"`))
+	})
+
+	It("should fail when the API can't be reached", func() {
+		server.Close()
+
+		Expect(run("-timeout", "100ms", "health")).To(Equal(1))
+		Expect(stdout.String()).To(ContainSubstring("down"))
+	})
+
+	It("should report the API errors", func() {
+		mockedCoffee.On("DeleteCoffee", "c1").Return(services.ErrCoffeeNotFound)
+
+		Expect(run("delete", "c1")).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring(services.ErrCoffeeNotFound.Error()))
+	})
+
+	It("should reject an unknown command or format", func() {
+		Expect(run("brew")).To(Equal(2))
+		Expect(run("-o", "xml", "list")).To(Equal(2))
+		Expect(run("migrate", "up")).To(Equal(2))
+	})
+})
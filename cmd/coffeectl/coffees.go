@@ -0,0 +1,234 @@
+package main
+
+import (
+	"coffee/coffee-server/services"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func (c *ctl) list() error {
+	coffees, err := c.coffees.GetAllCoffees()
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, c.output, coffees)
+}
+
+func (c *ctl) get(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: get <id>", errUsage)
+	}
+
+	coffee, err := c.coffees.GetCoffeesById(args[0])
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, c.output, []*services.Coffee{coffee})
+}
+
+func (c *ctl) create(args []string) error {
+	flags, fields := coffeeFlags("create")
+	file := flags.String("f", "", "JSON or YAML file holding the coffee")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	var coffee services.Coffee
+	if *file != "" {
+		coffees, err := readCoffees(*file)
+		if err != nil {
+			return err
+		}
+		if len(coffees) != 1 {
+			return fmt.Errorf("%s holds %d coffees, use import for more than one", *file, len(coffees))
+		}
+		coffee = coffees[0]
+	}
+	fields.apply(flags, &coffee)
+
+	if coffee.Name == "" || coffee.Roast == "" || coffee.Region == "" {
+		return fmt.Errorf("%w: name, roast and region are required", errUsage)
+	}
+
+	created, err := c.coffees.CreateCoffee(coffee)
+	if err != nil {
+		return err
+	}
+	return render(c.stdout, c.output, []*services.Coffee{created})
+}
+
+// update only changes the fields given as flags, the others keep their current value
+func (c *ctl) update(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: update <id> [fields]", errUsage)
+	}
+	id := args[0]
+
+	flags, fields := coffeeFlags("update")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	current, err := c.coffees.GetCoffeesById(id)
+	if err != nil {
+		return err
+	}
+	coffee := *current
+	fields.apply(flags, &coffee)
+
+	updated, err := c.coffees.UpdateCoffee(id, coffee)
+	if err != nil {
+		return err
+	}
+	updated.ID = id
+	return render(c.stdout, c.output, []*services.Coffee{updated})
+}
+
+func (c *ctl) delete(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: delete <id>", errUsage)
+	}
+
+	if err := c.coffees.DeleteCoffee(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Deleted coffee %s\n", args[0])
+	return nil
+}
+
+// export writes the catalog to a file, in YAML for a .yaml or .yml file and in JSON otherwise
+func (c *ctl) export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := flags.String("f", "", "file to write, stdout when left out")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	coffees, err := c.coffees.GetAllCoffees()
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		format := c.output
+		if format == formatTable {
+			format = formatJson
+		}
+		return render(c.stdout, format, coffees)
+	}
+
+	out, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := render(out, fileFormat(*file), coffees); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "Exported %d coffees to %s\n", len(coffees), *file)
+	return out.Close()
+}
+
+// importFile creates the coffees of the file. With -upsert the ones whose id is already in the
+// catalog are updated instead, e.g. to restore an export.
+func (c *ctl) importFile(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("f", "", "JSON or YAML file holding a list of coffees")
+	upsert := flags.Bool("upsert", false, "update the coffees whose id already exists")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if *file == "" {
+		return fmt.Errorf("%w: import -f <file>", errUsage)
+	}
+
+	coffees, err := readCoffees(*file)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	if *upsert {
+		all, err := c.coffees.GetAllCoffees()
+		if err != nil {
+			return err
+		}
+		for _, coffee := range all {
+			existing[coffee.ID] = true
+		}
+	}
+
+	var creates []services.Coffee
+	var positions []int
+	updated := 0
+	for i, coffee := range coffees {
+		if coffee.ID != "" && existing[coffee.ID] {
+			if _, err := c.coffees.UpdateCoffee(coffee.ID, coffee); err != nil {
+				return fmt.Errorf("coffee %d (%s): %w", i+1, coffee.Name, err)
+			}
+			updated++
+			continue
+		}
+		coffee.ID = ""
+		creates = append(creates, coffee)
+		positions = append(positions, i)
+	}
+
+	// Straight on the database the new coffees are copied in bulk
+	if importer, ok := c.coffees.(services.CoffeeImporter); ok && len(creates) > 0 {
+		if _, err := importer.ImportCoffees(creates); err != nil {
+			return fmt.Errorf("importing %d coffees: %w", len(creates), err)
+		}
+	} else {
+		for i, coffee := range creates {
+			if _, err := c.coffees.CreateCoffee(coffee); err != nil {
+				return fmt.Errorf("coffee %d (%s): %w", positions[i]+1, coffee.Name, err)
+			}
+		}
+	}
+	created := len(creates)
+
+	fmt.Fprintf(c.stdout, "Imported %d coffees: %d created, %d updated\n", len(coffees), created, updated)
+	return nil
+}
+
+type coffeeFields struct {
+	name, roast, image, region *string
+	price                      *float64
+	grind                      *int
+}
+
+func coffeeFlags(command string) (*flag.FlagSet, *coffeeFields) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	fields := &coffeeFields{
+		name:   flags.String("name", "", "name"),
+		roast:  flags.String("roast", "", "roast"),
+		image:  flags.String("image", "", "image URL"),
+		region: flags.String("region", "", "region"),
+		price:  flags.Float64("price", 0, "price"),
+		grind:  flags.Int("grind", 0, "grind unit"),
+	}
+	return flags, fields
+}
+
+// apply copies the flags that were given onto the coffee
+func (f *coffeeFields) apply(flags *flag.FlagSet, coffee *services.Coffee) {
+	flags.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			coffee.Name = *f.name
+		case "roast":
+			coffee.Roast = *f.roast
+		case "image":
+			coffee.Image = *f.image
+		case "region":
+			coffee.Region = *f.region
+		case "price":
+			coffee.Price = float32(*f.price)
+		case "grind":
+			coffee.GrindUnit = int16(*f.grind)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+// Command coffeectl manages the coffee catalog, through the HTTP API or straight from Postgres.
+//
+//	coffeectl [flags] list
+//	coffeectl [flags] get <id>
+//	coffeectl [flags] create -name Espresso -roast Dark -region Brazil -price 10 -grind 1
+//	coffeectl [flags] update <id> -price 12
+//	coffeectl [flags] delete <id>
+//	coffeectl [flags] export [-f coffees.yaml]
+//	coffeectl [flags] import [-upsert] -f coffees.yaml
+//	coffeectl -dsn <dsn> migrate up|down|status [-dir migrations]
+//	coffeectl [flags] health
+package main
+
+import (
+	"coffee/coffee-server/client"
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/services"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const usage = `Usage: coffeectl [flags] <command> [args]
+
+Commands:
+  list                  list the coffees
+  get <id>              show a coffee
+  create [fields]       create a coffee from the field flags or from -f file
+  update <id> [fields]  change the given fields of a coffee
+  delete <id>           delete a coffee
+  export [-f file]      write the catalog to a JSON or YAML file, stdout by default
+  import -f file        create the coffees of a JSON or YAML file, -upsert updates the existing ones
+  migrate up|down|status  run the database migrations, needs -dsn
+  health                check the API, or the database with -dsn
+
+Flags:
+`
+
+type ctl struct {
+	api     string
+	dsn     string
+	token   string
+	tenant  string
+	output  string
+	timeout time.Duration
+
+	stdout io.Writer
+	stderr io.Writer
+
+	// coffees is set up by connect from the flags, or by the tests
+	coffees services.CoffeeService
+	conn    db.DBInterface
+}
+
+func main() {
+	c := &ctl{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+func (c *ctl) run(args []string) int {
+	flags := flag.NewFlagSet("coffeectl", flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.StringVar(&c.api, "api", envOr("COFFEE_API", "http://localhost:8080"), "base URL of the API")
+	flags.StringVar(&c.dsn, "dsn", os.Getenv("DSN"), "Postgres DSN, talks to the database instead of the API when set")
+	flags.StringVar(&c.token, "token", os.Getenv("COFFEE_TOKEN"), "bearer token sent to the API")
+	flags.StringVar(&c.tenant, "tenant", os.Getenv("COFFEE_TENANT"), "slug of the shop whose catalog is managed, the default one when empty")
+	flags.StringVar(&c.output, "o", "table", "output format: table, json or yaml")
+	flags.DurationVar(&c.timeout, "timeout", 10*time.Second, "timeout of every call")
+	flags.Usage = func() {
+		fmt.Fprint(c.stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if !validFormat(c.output) {
+		fmt.Fprintf(c.stderr, "unknown output format %q\n", c.output)
+		return 2
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	if err := c.dispatch(command, rest); err != nil {
+		fmt.Fprintln(c.stderr, "Error:", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+var errUsage = errors.New("invalid usage")
+
+func (c *ctl) dispatch(command string, args []string) error {
+	if command == "migrate" {
+		return c.migrate(args)
+	}
+	if command == "health" {
+		return c.health()
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return c.list()
+	case "get":
+		return c.get(args)
+	case "create":
+		return c.create(args)
+	case "update":
+		return c.update(args)
+	case "delete":
+		return c.delete(args)
+	case "export":
+		return c.export(args)
+	case "import":
+		return c.importFile(args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+// connect picks the backend of the coffee commands, Postgres when a DSN is set and the API otherwise
+func (c *ctl) connect() error {
+	if c.coffees != nil {
+		return nil
+	}
+
+	if c.dsn == "" {
+		c.coffees = client.NewCoffeeService(c.apiClient(), c.timeout)
+		return nil
+	}
+
+	conn, err := c.database()
+	if err != nil {
+		return err
+	}
+	catalog := &services.CoffeeServiceImpl{DB: conn}
+	if c.tenant == "" {
+		c.coffees = catalog
+		return nil
+	}
+	tenant, err := (&services.TenantServiceImpl{DB: conn}).GetTenantBySlug(c.tenant)
+	if err != nil {
+		return fmt.Errorf("tenant %q: %w", c.tenant, err)
+	}
+	c.coffees = catalog.ForTenant(tenant.ID)
+	return nil
+}
+
+func (c *ctl) apiClient() *client.Client {
+	opts := []client.Option{client.WithTimeout(c.timeout), client.WithRetries(2, 200*time.Millisecond)}
+	if c.token != "" {
+		opts = append(opts, client.WithBearerToken(c.token))
+	}
+	if c.tenant != "" {
+		opts = append(opts, client.WithTenant(c.tenant))
+	}
+	return client.New(c.api, opts...)
+}
+
+func (c *ctl) database() (db.DBInterface, error) {
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	if c.dsn == "" {
+		return nil, fmt.Errorf("%w: -dsn or DSN is required", errUsage)
+	}
+
+	dbConn, err := db.ConnectPostgres(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = dbConn.DB
+	return c.conn, nil
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"coffee/coffee-server/services"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJson  = "json"
+	formatYaml  = "yaml"
+)
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJson || format == formatYaml
+}
+
+// tabular is implemented by the results other than coffees that can be shown as a table
+type tabular interface {
+	table() ([]string, [][]string)
+}
+
+func render(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case formatJson:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYaml:
+		return writeYaml(w, v)
+	default:
+		return writeTable(w, v)
+	}
+}
+
+// writeYaml goes through JSON so the YAML keys are the JSON names of the fields, in the same order
+func writeYaml(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	// The nodes keep the JSON styles, {"a": 1}, the encoder picks the plain YAML ones once they are cleared
+	clearStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+func writeTable(w io.Writer, v interface{}) error {
+	var header []string
+	var rows [][]string
+
+	switch v := v.(type) {
+	case []*services.Coffee:
+		header = []string{"ID", "NAME", "ROAST", "REGION", "PRICE", "GRIND", "UPDATED"}
+		for _, coffee := range v {
+			rows = append(rows, []string{
+				coffee.ID,
+				coffee.Name,
+				coffee.Roast,
+				coffee.Region,
+				strconv.FormatFloat(float64(coffee.Price), 'f', 2, 32),
+				strconv.Itoa(int(coffee.GrindUnit)),
+				formatTime(coffee.UpdatedAt),
+			})
+		}
+	case tabular:
+		header, rows = v.table()
+	default:
+		return fmt.Errorf("%T can't be shown as a table", v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// fileFormat picks the format of a file by its extension, JSON unless it is .yaml or .yml
+func fileFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return formatYaml
+	default:
+		return formatJson
+	}
+}
+
+// readCoffees reads a list of coffees, or a single one, from a JSON or YAML file
+func readCoffees(name string) ([]services.Coffee, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, the document goes back to JSON to reuse the JSON names of the fields
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if envelope, ok := document.(map[string]interface{}); ok {
+		// An export saved from the API, {"coffees": [...]}
+		if list, ok := envelope["coffees"]; ok {
+			document = list
+		}
+	}
+	if _, ok := document.(map[string]interface{}); ok {
+		document = []interface{}{document}
+	}
+
+	data, err = json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var coffees []services.Coffee
+	if err := json.Unmarshal(data, &coffees); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return coffees, nil
+}
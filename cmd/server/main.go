@@ -1,20 +1,65 @@
 package main
 
 import (
+	"coffee/coffee-server/breaker"
+	"coffee/coffee-server/cache"
 	"coffee/coffee-server/db"
+	"coffee/coffee-server/payments"
 	"coffee/coffee-server/router"
+	"coffee/coffee-server/rpc"
 	"coffee/coffee-server/services"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/lpernett/godotenv"
 )
 
+const (
+	catalogCacheSize = 1000
+
+	storePostgres = "postgres"
+	storeMemory   = "memory"
+)
+
 type Config struct {
-	Port string
+	Port     string
+	GRPCPort string
+	Env      string
+	// CacheTTL is how long the catalog reads are cached, zero turns the cache off
+	CacheTTL time.Duration
+	// Store is where the catalog is kept, "postgres" or "memory" to run without a database
+	Store string
+	// TxIsolation is the isolation level of the units of work spanning several services
+	TxIsolation sql.IsolationLevel
+	// SlowQuery is the duration from which the queries are logged, zero turns the log off
+	SlowQuery time.Duration
+	// Replicas are the DSNs of the read replicas serving the catalog reads
+	Replicas []string
+	// MaxReplicaLag is how far behind a replica can be and still serve reads
+	MaxReplicaLag time.Duration
+	// ReadYourWrites is how long the catalog reads of a client stay on the primary after it changed something, zero turns it off
+	ReadYourWrites time.Duration
+	// ConnectTimeout is how long the start waits for Postgres to answer
+	ConnectTimeout time.Duration
+	// BreakerCooldown is how long the catalog fails fast once the database is found down
+	BreakerCooldown time.Duration
+	// TenantDomain is the domain whose subdomains name the tenants, e.g. roastery.coffee.example.com
+	TenantDomain string
+	// TenantTokenSecret verifies the bearer tokens naming the tenant, they aren't read when it is empty
+	TenantTokenSecret string
+	// Languages are the languages the catalog is translated in, services.DefaultLanguages when empty
+	Languages []string
+	// V1Deprecated is when the v1 routes were deprecated, the release of the v2 routes when zero
+	V1Deprecated time.Time
+	// V1Sunset is when the v1 routes are removed, required in production. When zero no date is announced.
+	V1Sunset time.Time
 }
 
 type Application struct {
@@ -32,12 +77,142 @@ func (app *Application) Serve() error {
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", port),
-		Handler: router.Routes(app.Models.Coffee),
+		Handler: router.Routes(app.Models, app.routeOptions()...),
 	}
 
 	return srv.ListenAndServe()
 }
 
+// ServeGRPC serves the CoffeeCatalog gRPC service on its own port
+func (app *Application) ServeGRPC() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", app.Config.GRPCPort))
+	if err != nil {
+		return err
+	}
+	log.Printf("gRPC is listening on %s", app.Config.GRPCPort)
+
+	return rpc.NewServer(app.Models).Serve(lis)
+}
+
+func (app *Application) routeOptions() []router.Option {
+	var options []router.Option
+	if app.Config.Env == "development" {
+		options = append(options, router.WithGraphiQL())
+	}
+	if app.Config.Env != "production" {
+		options = append(options, router.WithValidation())
+	}
+	if app.Config.TenantDomain != "" {
+		options = append(options, router.WithTenantDomain(app.Config.TenantDomain))
+	}
+	if app.Config.TenantTokenSecret != "" {
+		options = append(options, router.WithTenantTokens([]byte(app.Config.TenantTokenSecret)))
+	}
+	if !app.Config.V1Deprecated.IsZero() {
+		options = append(options, router.WithV1Deprecation(app.Config.V1Deprecated))
+	}
+	if !app.Config.V1Sunset.IsZero() {
+		options = append(options, router.WithV1Sunset(app.Config.V1Sunset))
+	}
+	if app.Config.ReadYourWrites > 0 {
+		options = append(options, router.WithReadYourWrites(app.Config.ReadYourWrites))
+	}
+	return options
+}
+
+// PurgeExpiredCarts periodically removes carts that expired or were merged into another cart
+func (app *Application) PurgeExpiredCarts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := app.Models.Cart.DeleteExpiredCarts()
+		if err != nil {
+			log.Println("Error purging expired carts:", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Purged %d expired carts", deleted)
+		}
+	}
+}
+
+// DispatchWebhooks periodically sends the webhook deliveries that are due
+func (app *Application) DispatchWebhooks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		delivered, err := app.Models.Webhook.DispatchDue(100)
+		if err != nil {
+			log.Println("Error dispatching webhooks:", err)
+			continue
+		}
+		if delivered > 0 {
+			log.Printf("Delivered %d webhooks", delivered)
+		}
+	}
+}
+
+// GuardCatalog fails the catalog calls fast while the database is down, the cache still serves what it holds
+func (app *Application) GuardCatalog(cooldown time.Duration) {
+	b := breaker.New(breaker.DefaultThreshold, cooldown)
+	b.OnChange = func(from, to breaker.State) {
+		log.Printf("Catalog circuit breaker %s -> %s", from, to)
+	}
+	app.Models.Coffee = services.NewBreakerCoffeeService(app.Models.Coffee, b)
+}
+
+// CacheCatalog puts the catalog reads behind an in-memory cache, kept fresh by the catalog changes followed
+// from the outbox
+func (app *Application) CacheCatalog(ttl time.Duration) {
+	cached := services.NewCachedCoffeeService(app.Models.Coffee, cache.NewLRU(catalogCacheSize), ttl)
+	app.Models.Coffee = cached
+	go cached.Follow(context.Background(), app.Models.CoffeeStream)
+}
+
+// ExpirePaymentClaims periodically fails the checkouts that stopped before recording the outcome of the
+// provider, so their orders can be paid again
+func (app *Application) ExpirePaymentClaims(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := app.Models.Payment.ExpireStaleClaims(services.StaleClaimAge)
+		if err != nil {
+			log.Println("Error expiring payment claims:", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d stale payment claims", expired)
+		}
+	}
+}
+
+// RelayOutbox periodically publishes the events written to the outbox, on one instance at a time
+func (app *Application) RelayOutbox(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := app.Models.Outbox.PublishPending(100); err != nil {
+			log.Println("Error relaying outbox:", err)
+		}
+	}
+}
+
+// FollowOutbox periodically hands the events relayed by any instance to the live streams of this one
+func (app *Application) FollowOutbox(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := app.Models.Feed.Poll(500); err != nil {
+			log.Println("Error following outbox:", err)
+		}
+	}
+}
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -45,31 +220,135 @@ func main() {
 	}
 
 	cfg := Config{
-		Port: os.Getenv("PORT"),
+		Port:     os.Getenv("PORT"),
+		GRPCPort: os.Getenv("GRPC_PORT"),
+		Env:      os.Getenv("APP_ENV"),
+		CacheTTL: 5 * time.Minute,
+		Store:    os.Getenv("COFFEE_STORE"),
+
+		TenantDomain:      os.Getenv("TENANT_DOMAIN"),
+		TenantTokenSecret: os.Getenv("TENANT_TOKEN_SECRET"),
+	}
+	cfg.CacheTTL = durationEnv("COFFEE_CACHE_TTL", cfg.CacheTTL)
+	cfg.SlowQuery = durationEnv("DB_SLOW_QUERY", 0)
+	cfg.MaxReplicaLag = durationEnv("DB_REPLICA_MAX_LAG", 10*time.Second)
+	cfg.ReadYourWrites = durationEnv("DB_READ_YOUR_WRITES", 0)
+	cfg.ConnectTimeout = durationEnv("DB_CONNECT_TIMEOUT", db.DefaultRetry.Timeout)
+	cfg.BreakerCooldown = durationEnv("DB_BREAKER_COOLDOWN", breaker.DefaultCooldown)
+	for _, replica := range strings.Split(os.Getenv("DSN_REPLICAS"), ",") {
+		if replica = strings.TrimSpace(replica); replica != "" {
+			cfg.Replicas = append(cfg.Replicas, replica)
+		}
+	}
+	for _, lang := range strings.Split(os.Getenv("COFFEE_LANGUAGES"), ",") {
+		if lang = services.NormalizeLanguage(lang); lang != "" {
+			cfg.Languages = append(cfg.Languages, lang)
+		}
+	}
+	if deprecated := os.Getenv("API_V1_DEPRECATED"); deprecated != "" {
+		cfg.V1Deprecated, err = time.Parse(time.DateOnly, deprecated)
+		if err != nil {
+			log.Fatal("Error parsing API_V1_DEPRECATED: ", err)
+		}
+	}
+	if sunset := os.Getenv("API_V1_SUNSET"); sunset != "" {
+		cfg.V1Sunset, err = time.Parse(time.DateOnly, sunset)
+		if err != nil {
+			log.Fatal("Error parsing API_V1_SUNSET: ", err)
+		}
+		if !cfg.V1Sunset.After(time.Now()) {
+			log.Fatalf("API_V1_SUNSET %s is in the past, remove the v1 routes or set a later date", sunset)
+		}
+	} else if cfg.Env == "production" {
+		log.Fatal("API_V1_SUNSET must be set in production, the date the v1 routes are removed on")
+	}
+	cfg.TxIsolation, err = db.ParseIsolation(os.Getenv("COFFEE_TX_ISOLATION"))
+	if err != nil {
+		log.Fatal("Error parsing COFFEE_TX_ISOLATION: ", err)
+	}
+
+	switch cfg.Store {
+	case "", storePostgres:
+	case storeMemory:
+		app := &Application{Config: cfg, Models: services.NewMemory()}
+		log.Println("Keeping the catalog in memory, the endpoints other than the catalog need Postgres")
+		app.Run()
+		return
+	default:
+		log.Fatalf("Unknown COFFEE_STORE %q, use %q or %q", cfg.Store, storePostgres, storeMemory)
+	}
+
+	dbOptions := []db.Option{
+		db.WithReplicas(cfg.Replicas...),
+		db.WithMaxReplicaLag(cfg.MaxReplicaLag),
+	}
+	if cfg.SlowQuery > 0 {
+		dbOptions = append(dbOptions, db.WithTracer(db.SlowQueryLog(log.Default(), cfg.SlowQuery)))
 	}
 
 	dsn := os.Getenv("DSN")
-	dbConn, err := db.ConnectPostgres(dsn)
+	retry := db.DefaultRetry
+	retry.Timeout = cfg.ConnectTimeout
+	dbConn, err := db.ConnectWithRetry(context.Background(), dsn, retry, dbOptions...)
 	if err != nil {
-		log.Fatal("Error connection DB")
+		log.Fatal("Error connecting to the database: ", err)
 	}
 
 	defer dbConn.DB.Close()
 
-	// Assert dbConn.DB to *sql.DB
-	sqlDB, ok := dbConn.DB.(*sql.DB)
-	if !ok {
-		fmt.Println("Error: dbConn.DB is not a *sql.DB")
-		return
+	provider, err := payments.NewProvider(os.Getenv("PAYMENT_PROVIDER"), os.Getenv("PAYMENT_WEBHOOK_SECRET"), cfg.Env == "production")
+	if err != nil {
+		log.Fatal("Error configuring the payments: ", err)
 	}
 
 	app := &Application{
 		Config: cfg,
-		Models: services.New(sqlDB),
+		Models: services.New(dbConn.DB).WithPaymentProvider(dbConn.DB, provider).WithCatalogReads(dbConn.DB, dbConn.Reads),
 	}
+	app.Models.Tx.Isolation = cfg.TxIsolation
+	app.Models = app.Models.WithOrdersInTx()
+	if len(cfg.Languages) > 0 {
+		app.Models = app.Models.WithLanguages(dbConn.DB, cfg.Languages...)
+	}
+	app.GuardCatalog(cfg.BreakerCooldown)
+
+	if cfg.CacheTTL > 0 {
+		app.CacheCatalog(cfg.CacheTTL)
+	}
+
+	go app.PurgeExpiredCarts(time.Hour)
+	go app.ExpirePaymentClaims(time.Minute)
+	go app.RelayOutbox(time.Second)
+	go app.FollowOutbox(time.Second)
+	go app.DispatchWebhooks(5 * time.Second)
 
-	err = app.Serve()
+	app.Run()
+}
+
+// durationEnv parses the duration in the environment variable, the fallback is used when it isn't set
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
 	if err != nil {
+		log.Fatalf("Error parsing %s: %v", key, err)
+	}
+	return d
+}
+
+// Run serves the gRPC API in the background when a port is set, and the HTTP API
+func (app *Application) Run() {
+	if app.Config.GRPCPort != "" {
+		go func() {
+			if err := app.ServeGRPC(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	if err := app.Serve(); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"coffee/coffee-server/db"
 	"coffee/coffee-server/services"
-	"database/sql"
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -25,8 +25,8 @@ type Application struct {
 }
 
 var (
-	dbConn *db.DB  // To hold the test database connection
-	sqlDB  *sql.DB // The raw SQL database object
+	dbConn *db.DB         // To hold the test database connection
+	pool   db.DBInterface // The pool of the connection
 )
 
 var _ = BeforeSuite(func() {
@@ -39,24 +39,22 @@ var _ = BeforeSuite(func() {
 	dbConn, err = db.ConnectPostgres(dsn)
 	Expect(err).NotTo(HaveOccurred())
 
-	var ok bool
-	sqlDB, ok = dbConn.DB.(*sql.DB)
-	Expect(ok).To(BeTrue(), "dbConn.DB is not a *sql.DB")
+	pool = dbConn.DB
 })
 
 var _ = AfterSuite(func() {
-	sqlDB.Close()
+	pool.Close()
 })
 
 var _ = Describe("Main", Label("E2E"), func() {
 	BeforeEach(func() {
 		// Clean up database or reset state before each test
-		_, err := sqlDB.Exec("DELETE FROM coffees")
+		_, err := pool.ExecContext(context.Background(), "DELETE FROM coffees")
 		Expect(err).To(BeNil())
 	})
 	It("should return all coffees with status 200", func() {
 		// Insert a coffee into the database
-		_, err := sqlDB.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000','Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		_, err := pool.ExecContext(context.Background(), "INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000','Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
 		Expect(err).To(BeNil())
 
 		// Make a GET request to the API to fetch the coffees
@@ -85,7 +83,7 @@ var _ = Describe("Main", Label("E2E"), func() {
 	})
 	It("should return coffee by id with status 200", func() {
 		// Insert a coffee into the database
-		_, err := sqlDB.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000','Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		_, err := pool.ExecContext(context.Background(), "INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000','Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
 		Expect(err).To(BeNil())
 
 		// Make a GET request to the API to fetch the coffees
@@ -134,7 +132,7 @@ var _ = Describe("Main", Label("E2E"), func() {
 		// Ensure that the response status code is 200 OK
 		Expect(res.StatusCode).To(Equal(http.StatusOK))
 
-		rows, err := sqlDB.Query(`SELECT * FROM coffees`)
+		rows, err := pool.QueryContext(context.Background(), `SELECT * FROM coffees`)
 		Expect(err).NotTo(HaveOccurred())
 		defer rows.Close()
 
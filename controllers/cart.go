@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// cartErrorStatus maps the cart service errors to the status code returned to the client
+func cartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCartNotFound), errors.Is(err, services.ErrCartItemNotFound), errors.Is(err, services.ErrCoffeeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCartExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrCartNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidQuantity), errors.Is(err, services.ErrInvalidGrind), errors.Is(err, services.ErrUserRequired):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type cartOwner struct {
+	UserID string `json:"user_id"`
+}
+
+// POST /carts
+
+func CreateCart(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	// The body is optional, an empty one creates an anonymous cart
+	var owner cartOwner
+	if r.ContentLength != 0 {
+		err := helpers.ReadJson(w, r, &owner)
+		if err != nil {
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.ErrorJson(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	created, err := cart.CreateCart(owner.UserID)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"cart": created})
+}
+
+// GET /carts/{id}
+
+func GetCartById(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	id := chi.URLParam(r, "id")
+
+	found, err := cart.GetCartById(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"cart": found})
+}
+
+// POST /carts/{id}/items
+
+func AddCartItem(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	var item services.CartItem
+	err := helpers.ReadJson(w, r, &item)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	updated, err := cart.AddCartItem(id, item)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"cart": updated})
+}
+
+// PUT /carts/{id}/items/{itemId}
+
+func UpdateCartItem(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	var item services.CartItem
+	err := helpers.ReadJson(w, r, &item)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	itemId := chi.URLParam(r, "itemId")
+
+	updated, err := cart.UpdateCartItem(id, itemId, item)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"cart": updated})
+}
+
+// DELETE /carts/{id}/items/{itemId}
+
+func RemoveCartItem(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	id := chi.URLParam(r, "id")
+	itemId := chi.URLParam(r, "itemId")
+
+	updated, err := cart.RemoveCartItem(id, itemId)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"cart": updated})
+}
+
+// POST /carts/{id}/merge
+
+func MergeCarts(w http.ResponseWriter, r *http.Request, cart services.CartService) {
+	var owner cartOwner
+	err := helpers.ReadJson(w, r, &owner)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	merged, err := cart.MergeCarts(id, owner.UserID)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, cartErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"cart": merged})
+}
@@ -0,0 +1,147 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedCart *mocks.CartService
+
+var _ = Describe("Cart controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedCart = new(mocks.CartService)
+	})
+
+	Describe("CreateCart", func() {
+		It("should create an anonymous cart without a body", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts", nil)
+			mockedCart.On("CreateCart", "").Return(&services.Cart{ID: "c1", Status: services.CartStatusActive}, nil)
+
+			controllers.CreateCart(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			mockedCart.AssertCalled(GinkgoT(), "CreateCart", "")
+		})
+		It("should bind the cart to the given user", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts", bytes.NewBuffer([]byte(`{"user_id": "u1"}`)))
+			mockedCart.On("CreateCart", "u1").Return(&services.Cart{ID: "c1", UserID: "u1"}, nil)
+
+			controllers.CreateCart(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			mockedCart.AssertCalled(GinkgoT(), "CreateCart", "u1")
+		})
+	})
+
+	Describe("GetCartById", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/carts/c1", nil)
+			request = withURLParam(request, "id", "c1")
+		})
+		It("should return the cart", func() {
+			mockedCart.On("GetCartById", "c1").Return(&services.Cart{ID: "c1", Total: 20}, nil)
+
+			controllers.GetCartById(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response map[string]services.Cart
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response["cart"].Total).To(Equal(float32(20)))
+		})
+		It("should return 410 for an expired cart", func() {
+			mockedCart.On("GetCartById", "c1").Return(nil, services.ErrCartExpired)
+
+			controllers.GetCartById(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusGone))
+		})
+	})
+
+	Describe("AddCartItem", func() {
+		It("should add the item to the cart", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts/c1/items", bytes.NewBuffer([]byte(`{"coffee_id": "abc", "grind": "espresso", "quantity": 2}`)))
+			request = withURLParam(request, "id", "c1")
+			matcher := mock.MatchedBy(func(i services.CartItem) bool {
+				return i.CoffeeID == "abc" && i.Grind == "espresso" && i.Quantity == 2
+			})
+			mockedCart.On("AddCartItem", "c1", matcher).Return(&services.Cart{ID: "c1"}, nil)
+
+			controllers.AddCartItem(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mockedCart.AssertCalled(GinkgoT(), "AddCartItem", "c1", matcher)
+		})
+		It("should return 400 for an unknown grind", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts/c1/items", bytes.NewBuffer([]byte(`{"coffee_id": "abc", "grind": "dust", "quantity": 2}`)))
+			request = withURLParam(request, "id", "c1")
+			mockedCart.On("AddCartItem", "c1", mock.Anything).Return(nil, services.ErrInvalidGrind)
+
+			controllers.AddCartItem(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("UpdateCartItem", func() {
+		It("should update the item", func() {
+			request, _ = http.NewRequest(http.MethodPut, "/api/v1/carts/c1/items/i1", bytes.NewBuffer([]byte(`{"grind": "filter", "quantity": 3}`)))
+			request = withURLParam(withURLParam(request, "id", "c1"), "itemId", "i1")
+			matcher := mock.MatchedBy(func(i services.CartItem) bool {
+				return i.Grind == "filter" && i.Quantity == 3
+			})
+			mockedCart.On("UpdateCartItem", "c1", "i1", matcher).Return(&services.Cart{ID: "c1"}, nil)
+
+			controllers.UpdateCartItem(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("RemoveCartItem", func() {
+		It("should return 404 for an unknown item", func() {
+			request, _ = http.NewRequest(http.MethodDelete, "/api/v1/carts/c1/items/i1", nil)
+			request = withURLParam(withURLParam(request, "id", "c1"), "itemId", "i1")
+			mockedCart.On("RemoveCartItem", "c1", "i1").Return(nil, services.ErrCartItemNotFound)
+
+			controllers.RemoveCartItem(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("MergeCarts", func() {
+		It("should merge the cart into the user's cart", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts/c1/merge", bytes.NewBuffer([]byte(`{"user_id": "u1"}`)))
+			request = withURLParam(request, "id", "c1")
+			mockedCart.On("MergeCarts", "c1", "u1").Return(&services.Cart{ID: "c2", UserID: "u1"}, nil)
+
+			controllers.MergeCarts(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"c2"`))
+		})
+		It("should return 400 without a user", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/carts/c1/merge", bytes.NewBuffer([]byte(`{}`)))
+			request = withURLParam(request, "id", "c1")
+			mockedCart.On("MergeCarts", "c1", "").Return(nil, services.ErrUserRequired)
+
+			controllers.MergeCarts(recorder, request, mockedCart)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
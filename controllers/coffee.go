@@ -4,39 +4,67 @@ import (
 	"coffee/coffee-server/helpers"
 	"coffee/coffee-server/services"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
 )
 
+// coffeeErrorStatus maps the coffee service errors to the status code returned to the client
+func coffeeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDatabaseUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// coffeeError writes the error of the coffee service, telling the client when to try again while the
+// database is down
+func coffeeError(w http.ResponseWriter, err error) {
+	helpers.MessageLogs.ErrorLog.Println(err)
+	var unavailable *services.UnavailableError
+	if errors.As(err, &unavailable) && unavailable.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(unavailable.RetryAfter.Seconds()))))
+	}
+	helpers.ErrorJson(w, err, coffeeErrorStatus(err))
+}
+
 // GET /coffees
 
-func GetAllCoffees(w http.ResponseWriter, r *http.Request, coffee services.CoffeeService) {
+func GetAllCoffees(w http.ResponseWriter, r *http.Request, coffee services.CoffeeService, translations services.TranslationService) {
 	all, err := coffee.GetAllCoffees()
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		coffeeError(w, err)
 		return
 	}
+	localize(w, r, translations, all...)
 
-	helpers.WriteJson(w, http.StatusOK, helpers.Envelop{"coffees": all})
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffees": all})
 }
 
 // GET /coffees/{id}
 
-func GetCoffeesById(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+// GetCoffeesById serves the coffee named by its id or its slug, a slug it had before redirects to the current one
+func GetCoffeesById(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService, translations services.TranslationService) {
 	id := chi.URLParam(r, "id")
 
 	// Get the coffee by ID - this returns a *Coffee (pointer)
 	coffeePointer, err := coffeeService.GetCoffeesById(id)
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		coffeeError(w, err)
+		return
+	}
+	if movedSlug(w, r, id, coffeePointer) {
 		return
 	}
+	localize(w, r, translations, coffeePointer)
 
 	// Since coffeePointer is *Coffee, we can pass it directly to the response
-	helpers.WriteJson(w, http.StatusOK, helpers.Envelop{"coffee": coffeePointer})
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffee": coffeePointer})
 }
 
 // POST /coffees
@@ -51,11 +79,10 @@ func CreateCoffee(w http.ResponseWriter, r *http.Request, coffee services.Coffee
 	}
 	coffeeCreated, err := coffee.CreateCoffee(coffeeData)
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		coffeeError(w, err)
 		return
 	}
-	helpers.WriteJson(w, http.StatusOK, helpers.Envelop{"coffees": coffeeCreated})
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffees": coffeeCreated})
 }
 
 func UpdateCoffeeById(w http.ResponseWriter, r *http.Request, coffee services.CoffeeService) {
@@ -73,12 +100,11 @@ func UpdateCoffeeById(w http.ResponseWriter, r *http.Request, coffee services.Co
 	coffeeUpdated, err := coffee.UpdateCoffee(id, coffeeData)
 
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		coffeeError(w, err)
 		return
 	}
 
-	helpers.WriteJson(w, http.StatusOK, helpers.Envelop{"coffees": coffeeUpdated})
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffees": coffeeUpdated})
 }
 
 func DeleteCoffee(w http.ResponseWriter, r *http.Request, coffee services.CoffeeService) {
@@ -87,8 +113,7 @@ func DeleteCoffee(w http.ResponseWriter, r *http.Request, coffee services.Coffee
 	err := coffee.DeleteCoffee(id)
 
 	if err != nil {
-		helpers.MessageLogs.ErrorLog.Println(err)
-		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		coffeeError(w, err)
 		return
 	}
 }
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// coffeeSlugErrorStatus maps the errors of a slug change to the status code returned to the client
+func coffeeSlugErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCoffeeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidSlug):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSlugTaken):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrSlugsUnsupported):
+		return http.StatusNotImplemented
+	default:
+		return coffeeErrorStatus(err)
+	}
+}
+
+func coffeeSlugError(w http.ResponseWriter, err error) {
+	if status := coffeeSlugErrorStatus(err); status != coffeeErrorStatus(err) {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, status)
+		return
+	}
+	coffeeError(w, err)
+}
+
+// movedSlug redirects with a 301 a request naming the coffee by a slug it had before to its current
+// slug, it reports whether it did
+func movedSlug(w http.ResponseWriter, r *http.Request, requested string, coffee *services.Coffee) bool {
+	if coffee.Slug == "" || requested == coffee.ID || requested == coffee.Slug {
+		return false
+	}
+	location := *r.URL
+	location.Path = strings.TrimSuffix(r.URL.Path, requested) + coffee.Slug
+	location.RawPath = ""
+	http.Redirect(w, r, location.String(), http.StatusMovedPermanently)
+	return true
+}
+
+// PUT /coffees/coffee/{id}/slug
+
+// SetCoffeeSlug overrides the slug generated for the coffee, it is kept through the renames
+func SetCoffeeSlug(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+	slugs, ok := coffeeService.(services.CoffeeSlugs)
+	if !ok {
+		coffeeSlugError(w, services.ErrSlugsUnsupported)
+		return
+	}
+
+	var input struct {
+		Slug string `json:"slug"`
+	}
+	err := helpers.ReadJson(w, r, &input)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	coffee, err := slugs.SetCoffeeSlug(chi.URLParam(r, "id"), input.Slug)
+	if err != nil {
+		coffeeSlugError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffee": coffee})
+}
+
+// DELETE /coffees/coffee/{id}/slug
+
+// ResetCoffeeSlug drops the slug set for the coffee, it is generated from the name and region again
+func ResetCoffeeSlug(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+	slugs, ok := coffeeService.(services.CoffeeSlugs)
+	if !ok {
+		coffeeSlugError(w, services.ErrSlugsUnsupported)
+		return
+	}
+
+	coffee, err := slugs.ResetCoffeeSlug(chi.URLParam(r, "id"))
+	if err != nil {
+		coffeeSlugError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffee": coffee})
+}
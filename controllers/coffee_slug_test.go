@@ -0,0 +1,115 @@
+package controllers_test
+
+import (
+	"bytes"
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// sluggedCatalog is a mocked catalog letting the slugs be set
+type sluggedCatalog struct {
+	*mocks.CoffeeService
+	*mocks.CoffeeSlugs
+}
+
+var _ = Describe("Coffee slugs", Label("unit"), func() {
+	var (
+		coffees *mocks.CoffeeService
+		slugs   *mocks.CoffeeSlugs
+	)
+
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+		coffees = new(mocks.CoffeeService)
+		slugs = new(mocks.CoffeeSlugs)
+	})
+
+	Describe("GetCoffeesById", func() {
+		moved := &services.Coffee{ID: "550e8400-e29b-41d4-a716-446655440000", Slug: "mocha-java-ethiopia", Name: "Mocha Java"}
+
+		It("should redirect a former slug to the current one for good", func() {
+			coffees.On("GetCoffeesById", "mocha-ethiopia").Return(moved, nil)
+			request := withURLParam(httptest.NewRequest(http.MethodGet, "/api/v1/coffees/coffee/mocha-ethiopia?lang=nl", nil), "id", "mocha-ethiopia")
+
+			controllers.GetCoffeesById(recorder, request, coffees, nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusMovedPermanently))
+			Expect(recorder.Header().Get("Location")).To(Equal("/api/v1/coffees/coffee/mocha-java-ethiopia?lang=nl"))
+		})
+
+		It("should serve the coffee by its id or its current slug", func() {
+			for _, key := range []string{moved.ID, moved.Slug} {
+				recorder = httptest.NewRecorder()
+				coffees.On("GetCoffeesById", key).Return(moved, nil)
+				request := withURLParam(httptest.NewRequest(http.MethodGet, "/api/v2/coffees/"+key, nil), "id", key)
+
+				controllers.GetCoffeeV2(recorder, request, coffees, nil)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"slug": "mocha-java-ethiopia"`))
+			}
+		})
+	})
+
+	Describe("SetCoffeeSlug", func() {
+		put := func(catalog services.CoffeeService, body string) {
+			request := withURLParam(httptest.NewRequest(http.MethodPut, "/api/v1/coffees/coffee/c1/slug", bytes.NewBufferString(body)), "id", "c1")
+			controllers.SetCoffeeSlug(recorder, request, catalog)
+		}
+
+		It("should answer the coffee with its new slug", func() {
+			slugs.On("SetCoffeeSlug", "c1", "house-blend").Return(&services.Coffee{ID: "c1", Slug: "house-blend"}, nil)
+
+			put(sluggedCatalog{coffees, slugs}, `{"slug": "house-blend"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var response map[string]services.Coffee
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response["coffee"].Slug).To(Equal("house-blend"))
+		})
+
+		DescribeTable("should map the errors to their status",
+			func(err error, status int) {
+				slugs.On("SetCoffeeSlug", "c1", "house-blend").Return(nil, err)
+
+				put(sluggedCatalog{coffees, slugs}, `{"slug": "house-blend"}`)
+
+				Expect(recorder.Code).To(Equal(status))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"error": true`))
+			},
+			Entry("an invalid slug", fmt.Errorf("%w: slug can't be a UUID", services.ErrInvalidSlug), http.StatusBadRequest),
+			Entry("a slug of another coffee", services.ErrSlugTaken, http.StatusConflict),
+			Entry("a missing coffee", services.ErrCoffeeNotFound, http.StatusNotFound),
+			Entry("a database down", &services.UnavailableError{}, http.StatusServiceUnavailable),
+		)
+
+		It("should refuse a body it can't read and a catalog without slugs", func() {
+			put(sluggedCatalog{coffees, slugs}, `{"slug": `)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+
+			recorder = httptest.NewRecorder()
+			put(coffees, `{"slug": "house-blend"}`)
+			Expect(recorder.Code).To(Equal(http.StatusNotImplemented))
+		})
+	})
+
+	Describe("ResetCoffeeSlug", func() {
+		It("should answer the coffee with its generated slug", func() {
+			slugs.On("ResetCoffeeSlug", "c1").Return(&services.Coffee{ID: "c1", Slug: "mocha-ethiopia"}, nil)
+			request := withURLParam(httptest.NewRequest(http.MethodDelete, "/api/v1/coffees/coffee/c1/slug", nil), "id", "c1")
+
+			controllers.ResetCoffeeSlug(recorder, request, sluggedCatalog{coffees, slugs})
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"slug": "mocha-ethiopia"`))
+		})
+	})
+})
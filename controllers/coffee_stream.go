@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// StreamHeartbeat is how often an idle stream sends a comment to keep proxies from closing it
+	StreamHeartbeat = 15 * time.Second
+	// StreamWriteTimeout is how long a single write to a client may take before the client is dropped
+	StreamWriteTimeout = 10 * time.Second
+)
+
+// streamRetry is the reconnection delay suggested to EventSource clients, in milliseconds
+const streamRetry = 3000
+
+// GET /coffees/stream
+
+// StreamCoffees sends catalog changes as Server-Sent Events. A client reconnecting with Last-Event-ID
+// (or ?last_event_id= when the header can't be set) first receives the events it missed.
+// A client that can't keep up is disconnected and catches up the same way when it reconnects.
+func StreamCoffees(w http.ResponseWriter, r *http.Request, stream services.CoffeeStream) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := errors.New("streaming is not supported")
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("last_event_id")
+	}
+	var afterId int64
+	if lastEventId != "" {
+		var err error
+		afterId, err = strconv.ParseInt(lastEventId, 10, 64)
+		if err != nil || afterId < 0 {
+			err := errors.New("Last-Event-ID must be an event id")
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.ErrorJson(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Subscribe before reading the missed events so nothing published in between is lost
+	live, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+
+	var missed []events.Event
+	if lastEventId != "" {
+		var err error
+		missed, err = stream.Replay(afterId)
+		if err != nil {
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.ErrorJson(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...interface{}) error {
+		// Not every writer supports deadlines, in which case the bus still drops the client once its buffer is full
+		_ = rc.SetWriteDeadline(time.Now().Add(StreamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := write("retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+
+	sent := map[int64]bool{}
+	for _, event := range missed {
+		if err := writeEvent(write, event); err != nil {
+			return
+		}
+		sent[event.ID] = true
+	}
+
+	heartbeat := time.NewTicker(StreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				// Dropped for falling behind, the client reconnects with its Last-Event-ID
+				return
+			}
+			if sent[event.ID] {
+				continue
+			}
+			if err := writeEvent(write, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(write func(format string, args ...interface{}) error, event events.Event) error {
+	return write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Payload)
+}
@@ -0,0 +1,118 @@
+package controllers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/mocks"
+)
+
+var _ = Describe("Coffee stream controller", Label("unit"), func() {
+	var (
+		mockedStream *mocks.CoffeeStream
+		live         chan events.Event
+		server       *httptest.Server
+	)
+
+	event := func(id int64, eventType string) events.Event {
+		return events.Event{ID: id, AggregateType: "coffee", AggregateID: "c1", Type: eventType, Payload: json.RawMessage(`{"id":"c1"}`)}
+	}
+
+	// readUntil collects the stream lines up to and including the first one matching the prefix
+	readUntil := func(reader *bufio.Reader, prefix string) []string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			line = strings.TrimRight(line, "\n")
+			lines = append(lines, line)
+			if strings.HasPrefix(line, prefix) {
+				return lines
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedStream = new(mocks.CoffeeStream)
+		live = make(chan events.Event, 4)
+		mockedStream.On("Subscribe").Return((<-chan events.Event)(live), func() {})
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			controllers.StreamCoffees(w, r, mockedStream)
+		}))
+	})
+
+	AfterEach(func() {
+		server.CloseClientConnections()
+		server.Close()
+	})
+
+	It("should replay the missed events before the live ones", func() {
+		mockedStream.On("Replay", int64(4)).Return([]events.Event{event(5, "coffee.created"), event(6, "coffee.updated")}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Last-Event-ID", "4")
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		reader := bufio.NewReader(res.Body)
+		Expect(readUntil(reader, "retry:")).To(ContainElement("retry: 3000"))
+		Expect(readUntil(reader, "data:")).To(Equal([]string{"", "id: 5", "event: coffee.created", `data: {"id":"c1"}`}))
+		Expect(readUntil(reader, "id:")).To(Equal([]string{"", "id: 6"}))
+
+		// Already replayed, so it is not sent twice
+		live <- event(6, "coffee.updated")
+		live <- event(7, "coffee.deleted")
+		Expect(readUntil(reader, "event:")).To(ContainElement("event: coffee.updated"))
+		Expect(readUntil(reader, "event:")).To(ContainElement("event: coffee.deleted"))
+	})
+
+	It("should send heartbeats while idle", func() {
+		heartbeat := controllers.StreamHeartbeat
+		controllers.StreamHeartbeat = 10 * time.Millisecond
+		DeferCleanup(func() { controllers.StreamHeartbeat = heartbeat })
+
+		res, err := http.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		Expect(readUntil(bufio.NewReader(res.Body), ":")).To(ContainElement(": heartbeat"))
+		mockedStream.AssertNotCalled(GinkgoT(), "Replay")
+	})
+
+	It("should end the stream when the client is dropped for falling behind", func() {
+		res, err := http.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		close(live)
+
+		reader := bufio.NewReader(res.Body)
+		readUntil(reader, "retry:")
+		Eventually(func() error {
+			_, err := reader.ReadString('\n')
+			return err
+		}).Should(HaveOccurred())
+	})
+
+	It("should return 400 for an invalid Last-Event-ID", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/coffees/stream?last_event_id=abc", nil)
+
+		controllers.StreamCoffees(recorder, request, mockedStream)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
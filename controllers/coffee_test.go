@@ -42,7 +42,7 @@ var _ = Describe("Coffee controller", Label("unit"), func() {
 			}
 			mockedCoffee.On("GetAllCoffees").Return(mockCoffees, nil)
 
-			controllers.GetAllCoffees(recorder, request, mockedCoffee)
+			controllers.GetAllCoffees(recorder, request, mockedCoffee, nil)
 
 			Expect(recorder.Code).To(Equal(http.StatusOK))
 
@@ -57,7 +57,7 @@ var _ = Describe("Coffee controller", Label("unit"), func() {
 
 		It("should log the error and not write a response", func() {
 			mockedCoffee.On("GetAllCoffees").Return(nil, errors.New("New database error"))
-			controllers.GetAllCoffees(recorder, request, mockedCoffee)
+			controllers.GetAllCoffees(recorder, request, mockedCoffee, nil)
 
 			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
 
@@ -73,6 +73,15 @@ var _ = Describe("Coffee controller", Label("unit"), func() {
 			Expect(response["error"]).To(Equal(true))
 			Expect(response["message"]).To(Equal("New database error"))
 		})
+
+		It("should return 503 and when to retry while the database is down", func() {
+			mockedCoffee.On("GetAllCoffees").Return(nil, &services.UnavailableError{RetryAfter: 1500 * time.Millisecond})
+			controllers.GetAllCoffees(recorder, request, mockedCoffee, nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(recorder.Header().Get("Retry-After")).To(Equal("2"))
+			Expect(recorder.Body.String()).To(ContainSubstring("the database is unavailable"))
+		})
 	})
 	Describe("GetAllCoffeeById", func() {
 		BeforeEach(func() {
@@ -90,7 +99,7 @@ var _ = Describe("Coffee controller", Label("unit"), func() {
 			}
 			mockedCoffee.On("GetCoffeesById", "").Return(mockCoffee, nil)
 
-			controllers.GetCoffeesById(recorder, request, mockedCoffee)
+			controllers.GetCoffeesById(recorder, request, mockedCoffee, nil)
 
 			Expect(recorder.Code).To(Equal(http.StatusOK))
 
@@ -105,7 +114,7 @@ var _ = Describe("Coffee controller", Label("unit"), func() {
 		It("Return error if not coffee not found", func() {
 			mockedCoffee.On("GetCoffeesById", "").Return(nil, errors.New("The coffee is not found"))
 
-			controllers.GetCoffeesById(recorder, request, mockedCoffee)
+			controllers.GetCoffeesById(recorder, request, mockedCoffee, nil)
 
 			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
 
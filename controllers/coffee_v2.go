@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// The v2 coffee routes answer a single coffee under "coffee", a created one with 201, a body that can't
+// be read with 400 and a coffee that doesn't exist with 404. The list is served by GetAllCoffees.
+
+// coffeeV2Error writes the error of the coffee service, a coffee that doesn't exist is a 404
+func coffeeV2Error(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, services.ErrCoffeeNotFound) {
+		helpers.ErrorJson(w, services.ErrCoffeeNotFound, http.StatusNotFound)
+		return
+	}
+	coffeeError(w, err)
+}
+
+// GET /v2/coffees/{id}
+
+func GetCoffeeV2(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService, translations services.TranslationService) {
+	id := chi.URLParam(r, "id")
+	coffee, err := coffeeService.GetCoffeesById(id)
+	if err != nil {
+		coffeeV2Error(w, err)
+		return
+	}
+	if movedSlug(w, r, id, coffee) {
+		return
+	}
+	localize(w, r, translations, coffee)
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffee": coffee})
+}
+
+// POST /v2/coffees
+
+func CreateCoffeeV2(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+	var coffeeData services.Coffee
+	err := helpers.ReadJson(w, r, &coffeeData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := coffeeService.CreateCoffee(coffeeData)
+	if err != nil {
+		coffeeV2Error(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v2/coffees/"+created.ID)
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"coffee": created})
+}
+
+// PUT /v2/coffees/{id}
+
+// UpdateCoffeeV2 answers the coffee as stored after the update, the update itself doesn't tell whether
+// the coffee exists
+func UpdateCoffeeV2(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+	var coffeeData services.Coffee
+	err := helpers.ReadJson(w, r, &coffeeData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := coffeeService.UpdateCoffee(id, coffeeData); err != nil {
+		coffeeV2Error(w, err)
+		return
+	}
+	updated, err := coffeeService.GetCoffeesById(id)
+	if err != nil {
+		coffeeV2Error(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"coffee": updated})
+}
+
+// DELETE /v2/coffees/{id}
+
+// DeleteCoffeeV2 looks the coffee up before deleting it, deleting a coffee that doesn't exist succeeds
+// in the service. A slug doesn't name the coffee to delete, only its id does.
+func DeleteCoffeeV2(w http.ResponseWriter, r *http.Request, coffeeService services.CoffeeService) {
+	id := chi.URLParam(r, "id")
+	coffee, err := coffeeService.GetCoffeesById(id)
+	if err == nil && coffee.ID != id {
+		err = services.ErrCoffeeNotFound
+	}
+	if err != nil {
+		coffeeV2Error(w, err)
+		return
+	}
+
+	if err := coffeeService.DeleteCoffee(id); err != nil {
+		coffeeV2Error(w, err)
+	}
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/openapi"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// GET /openapi.json
+
+// OpenAPI serves the OpenAPI document of the API
+func OpenAPI(w http.ResponseWriter, r *http.Request, doc *openapi3.T) {
+	err := helpers.WriteJson(w, http.StatusOK, doc)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusInternalServerError)
+	}
+}
+
+// GET /docs
+
+// SwaggerUI serves the interactive documentation of the API
+func SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(openapi.SwaggerUIPage))
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"coffee/coffee-server/gql"
+	"coffee/coffee-server/helpers"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// GET, POST /graphql
+
+// GraphQL runs a query sent as JSON in a POST body, or as query parameters of a GET request.
+// Mutations are only run from POST requests.
+func GraphQL(w http.ResponseWriter, r *http.Request, schema graphql.Schema, limits gql.Limits) {
+	var req gql.Request
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+				helpers.ErrorJson(w, errors.New("variables must be a JSON object"), http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		err := helpers.ReadJson(w, r, &req)
+		if err != nil {
+			helpers.MessageLogs.ErrorLog.Println(err)
+			helpers.ErrorJson(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Query == "" {
+		helpers.ErrorJson(w, errors.New("query is required"), http.StatusBadRequest)
+		return
+	}
+
+	result := gql.Execute(r.Context(), schema, limits, req, r.Method == http.MethodPost)
+	helpers.WriteJson(w, http.StatusOK, result)
+}
+
+// GraphiQL serves the GraphQL playground
+func GraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(gql.GraphiQLPage))
+}
+
+// WantsGraphiQL tells a browser opening the endpoint apart from a client sending a query
+func WantsGraphiQL(r *http.Request) bool {
+	return r.Method == http.MethodGet && r.URL.Query().Get("query") == "" && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
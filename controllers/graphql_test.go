@@ -0,0 +1,77 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/graphql-go/graphql"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/gql"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var _ = Describe("GraphQL controller", Label("unit"), func() {
+	var schema graphql.Schema
+
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedCoffee := new(mocks.CoffeeService)
+		mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Espresso"}, nil)
+		mockedCoffee.On("DeleteCoffee", "c1").Return(nil)
+
+		var err error
+		schema, err = gql.NewSchema(mockedCoffee)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should run a query posted as JSON", func() {
+		request, _ = http.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer([]byte(`{"query": "query($id: ID!) { coffee(id: $id) { name } }", "variables": {"id": "c1"}}`)))
+
+		controllers.GraphQL(recorder, request, schema, gql.DefaultLimits)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"name": "Espresso"`))
+	})
+
+	It("should run a query from the query parameters", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`{ coffee(id: "c1") { name } }`), nil)
+
+		controllers.GraphQL(recorder, request, schema, gql.DefaultLimits)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"name": "Espresso"`))
+	})
+
+	It("should not run mutations from a GET request", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteCoffee(id: "c1") }`), nil)
+
+		controllers.GraphQL(recorder, request, schema, gql.DefaultLimits)
+
+		Expect(recorder.Body.String()).To(ContainSubstring("mutations are only allowed in POST requests"))
+	})
+
+	It("should return 400 without a query", func() {
+		request, _ = http.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer([]byte(`{}`)))
+
+		controllers.GraphQL(recorder, request, schema, gql.DefaultLimits)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should tell a browser apart from a client", func() {
+		browser, _ := http.NewRequest(http.MethodGet, "/graphql", nil)
+		browser.Header.Set("Accept", "text/html,application/xhtml+xml")
+		client, _ := http.NewRequest(http.MethodGet, "/graphql?query=%7B__typename%7D", nil)
+		client.Header.Set("Accept", "text/html")
+
+		Expect(controllers.WantsGraphiQL(browser)).To(BeTrue())
+		Expect(controllers.WantsGraphiQL(client)).To(BeFalse())
+	})
+})
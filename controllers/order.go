@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// orderErrorStatus maps the order service errors to the status code returned to the client
+func orderErrorStatus(err error) int {
+	var transitionErr *services.TransitionError
+	switch {
+	case errors.As(err, &transitionErr):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrOrderNotFound), errors.Is(err, services.ErrCoffeeNotFound), errors.Is(err, services.ErrCartNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOrderEmpty), errors.Is(err, services.ErrInvalidQuantity), errors.Is(err, services.ErrInvalidOrderStatus):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GET /orders
+
+func GetAllOrders(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	all, err := order.GetAllOrders()
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"orders": all})
+}
+
+// GET /orders/{id}
+
+func GetOrderById(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	id := chi.URLParam(r, "id")
+
+	found, err := order.GetOrderById(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"order": found})
+}
+
+// POST /orders
+
+func CreateOrder(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	var orderData services.Order
+	err := json.NewDecoder(r.Body).Decode(&orderData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := order.CreateOrder(orderData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"order": created})
+}
+
+// POST /orders/{id}/cancel
+
+func CancelOrder(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	id := chi.URLParam(r, "id")
+
+	cancelled, err := order.CancelOrder(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"order": cancelled})
+}
+
+// POST /orders/{id}/transitions
+
+func TransitionOrder(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	var transition struct {
+		Status string `json:"status"`
+	}
+	err := helpers.ReadJson(w, r, &transition)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	updated, err := order.TransitionOrder(id, transition.Status)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"order": updated})
+}
+
+// GET /orders/{id}/history
+
+func GetOrderHistory(w http.ResponseWriter, r *http.Request, order services.OrderService) {
+	id := chi.URLParam(r, "id")
+
+	history, err := order.GetOrderHistory(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, orderErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"history": history})
+}
@@ -0,0 +1,237 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedOrder *mocks.OrderService
+
+// withURLParam attaches a chi route parameter to the request like the router would
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx, ok := r.Context().Value(chi.RouteCtxKey).(*chi.Context)
+	if !ok {
+		rctx = chi.NewRouteContext()
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+	rctx.URLParams.Add(key, value)
+	return r
+}
+
+var _ = Describe("Order controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedOrder = new(mocks.OrderService)
+	})
+
+	Describe("GetAllOrders", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		})
+		It("should return all orders with status 200", func() {
+			mockOrders := []*services.Order{
+				{ID: "1", CustomerName: "Jan", Status: services.OrderStatusPending, Total: 24.0},
+				{ID: "2", CustomerName: "Piet", Status: services.OrderStatusCancelled, Total: 10.0},
+			}
+			mockedOrder.On("GetAllOrders").Return(mockOrders, nil)
+
+			controllers.GetAllOrders(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response map[string][]services.Order
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response["orders"]).To(HaveLen(2))
+			Expect(response["orders"][0].CustomerName).To(Equal("Jan"))
+		})
+		It("should return 500 on a database error", func() {
+			mockedOrder.On("GetAllOrders").Return(nil, errors.New("Database error"))
+
+			controllers.GetAllOrders(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).To(ContainSubstring("Database error"))
+		})
+	})
+
+	Describe("GetOrderById", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/orders/12345", nil)
+			request = withURLParam(request, "id", "12345")
+		})
+		It("should return the order", func() {
+			mockedOrder.On("GetOrderById", "12345").Return(&services.Order{ID: "12345", Total: 12.0}, nil)
+
+			controllers.GetOrderById(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response map[string]services.Order
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response["order"].ID).To(Equal("12345"))
+		})
+		It("should return 404 when the order does not exist", func() {
+			mockedOrder.On("GetOrderById", "12345").Return(nil, services.ErrOrderNotFound)
+
+			controllers.GetOrderById(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			Expect(recorder.Body.String()).To(ContainSubstring(services.ErrOrderNotFound.Error()))
+		})
+	})
+
+	Describe("CreateOrder", func() {
+		var orderJson []byte
+
+		BeforeEach(func() {
+			orderJson, _ = json.Marshal(services.Order{
+				CustomerName:  "Jan",
+				CustomerEmail: "jan@example.com",
+				Items:         []services.OrderItem{{CoffeeID: "abc", Quantity: 2}},
+			})
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders", bytes.NewBuffer(orderJson))
+			request.Header.Set("Content-Type", "application/json")
+		})
+		It("should create the order with status 201", func() {
+			matcher := mock.MatchedBy(func(o services.Order) bool {
+				return o.CustomerName == "Jan" && len(o.Items) == 1 && o.Items[0].Quantity == 2
+			})
+			mockedOrder.On("CreateOrder", matcher).Return(&services.Order{ID: "1", Total: 24.0}, nil)
+
+			controllers.CreateOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			mockedOrder.AssertCalled(GinkgoT(), "CreateOrder", matcher)
+		})
+		It("should return 400 for an empty order", func() {
+			mockedOrder.On("CreateOrder", mock.Anything).Return(nil, services.ErrOrderEmpty)
+
+			controllers.CreateOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return 404 when a coffee does not exist", func() {
+			mockedOrder.On("CreateOrder", mock.Anything).Return(nil, services.ErrCoffeeNotFound)
+
+			controllers.CreateOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+		It("should return 400 for malformed JSON", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders", bytes.NewBuffer([]byte(`{"items": "nope"}`)))
+
+			controllers.CreateOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			mockedOrder.AssertNotCalled(GinkgoT(), "CreateOrder", mock.Anything)
+		})
+	})
+
+	Describe("CancelOrder", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders/12345/cancel", nil)
+			request = withURLParam(request, "id", "12345")
+		})
+		It("should cancel the order", func() {
+			mockedOrder.On("CancelOrder", "12345").Return(&services.Order{ID: "12345", Status: services.OrderStatusCancelled}, nil)
+
+			controllers.CancelOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(services.OrderStatusCancelled))
+		})
+		It("should return 409 when the order can no longer be cancelled", func() {
+			mockedOrder.On("CancelOrder", "12345").Return(nil, services.CheckTransition(services.OrderStatusShipped, services.OrderStatusCancelled))
+
+			controllers.CancelOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("TransitionOrder", func() {
+		It("should move the order to the requested status", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders/12345/transitions", bytes.NewBuffer([]byte(`{"status": "paid"}`)))
+			request = withURLParam(request, "id", "12345")
+			mockedOrder.On("TransitionOrder", "12345", services.OrderStatusPaid).Return(&services.Order{ID: "12345", Status: services.OrderStatusPaid}, nil)
+
+			controllers.TransitionOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mockedOrder.AssertCalled(GinkgoT(), "TransitionOrder", "12345", services.OrderStatusPaid)
+		})
+		It("should reject an illegal transition with the allowed statuses", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders/12345/transitions", bytes.NewBuffer([]byte(`{"status": "shipped"}`)))
+			request = withURLParam(request, "id", "12345")
+			transitionErr := services.CheckTransition(services.OrderStatusPending, services.OrderStatusShipped)
+			mockedOrder.On("TransitionOrder", "12345", services.OrderStatusShipped).Return(nil, transitionErr)
+
+			controllers.TransitionOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+
+			var response services.JsonResponse
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Error).To(BeTrue())
+			Expect(response.Message).To(Equal("order cannot transition from pending to shipped"))
+			Expect(response.Data).To(HaveKeyWithValue("allowed", ConsistOf("paid", "cancelled")))
+		})
+		It("should return 400 for an unknown status", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders/12345/transitions", bytes.NewBuffer([]byte(`{"status": "lost"}`)))
+			request = withURLParam(request, "id", "12345")
+			mockedOrder.On("TransitionOrder", "12345", "lost").Return(nil, services.ErrInvalidOrderStatus)
+
+			controllers.TransitionOrder(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GetOrderHistory", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/orders/12345/history", nil)
+			request = withURLParam(request, "id", "12345")
+		})
+		It("should return the transition history", func() {
+			history := []*services.OrderTransition{
+				{OrderID: "12345", FromStatus: "", ToStatus: services.OrderStatusPending},
+				{OrderID: "12345", FromStatus: services.OrderStatusPending, ToStatus: services.OrderStatusPaid},
+			}
+			mockedOrder.On("GetOrderHistory", "12345").Return(history, nil)
+
+			controllers.GetOrderHistory(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response map[string][]services.OrderTransition
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response["history"]).To(HaveLen(2))
+			Expect(response["history"][1].ToStatus).To(Equal(services.OrderStatusPaid))
+		})
+		It("should return 404 for an unknown order", func() {
+			mockedOrder.On("GetOrderHistory", "12345").Return(nil, services.ErrOrderNotFound)
+
+			controllers.GetOrderHistory(recorder, request, mockedOrder)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
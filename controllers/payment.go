@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/payments"
+	"coffee/coffee-server/services"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+const paymentSignatureHeader = "X-Payment-Signature"
+
+// paymentErrorStatus maps the payment service errors to the status code returned to the client
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(err, services.ErrPaymentFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrPaymentNotRefundable), errors.Is(err, services.ErrPaymentInProgress):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPaymentTokenRequired), errors.Is(err, services.ErrInvalidWebhookEvent):
+		return http.StatusBadRequest
+	case errors.Is(err, payments.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	default:
+		return orderErrorStatus(err)
+	}
+}
+
+// POST /orders/{id}/checkout
+
+func Checkout(w http.ResponseWriter, r *http.Request, payment services.PaymentService) {
+	var checkout struct {
+		PaymentToken string `json:"payment_token"`
+	}
+	err := helpers.ReadJson(w, r, &checkout)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	paid, err := payment.Checkout(id, checkout.PaymentToken)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, paymentErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"payment": paid})
+}
+
+// GET /orders/{id}/payments
+
+func GetPaymentsByOrder(w http.ResponseWriter, r *http.Request, payment services.PaymentService) {
+	id := chi.URLParam(r, "id")
+
+	all, err := payment.GetPaymentsByOrder(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, paymentErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"payments": all})
+}
+
+// POST /payments/{id}/refund
+
+func RefundPayment(w http.ResponseWriter, r *http.Request, payment services.PaymentService) {
+	id := chi.URLParam(r, "id")
+
+	refunded, err := payment.RefundPayment(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, paymentErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"payment": refunded})
+}
+
+// POST /payments/webhook
+
+// PaymentWebhook answers the provider in JSON whatever it accepts, a provider retries an event until it
+// is acknowledged
+func PaymentWebhook(w http.ResponseWriter, r *http.Request, payment services.PaymentService) {
+	// The signature covers the raw body, so it must be read as is
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1048576))
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := payment.HandleWebhook(payload, r.Header.Get(paymentSignatureHeader))
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, paymentErrorStatus(err))
+		return
+	}
+
+	helpers.WriteJson(w, http.StatusOK, helpers.Envelop{"payment": updated})
+}
@@ -0,0 +1,110 @@
+package controllers_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/payments"
+	"coffee/coffee-server/services"
+)
+
+var mockedPayment *mocks.PaymentService
+
+var _ = Describe("Payment controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedPayment = new(mocks.PaymentService)
+	})
+
+	Describe("Checkout", func() {
+		BeforeEach(func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/orders/o1/checkout", bytes.NewBuffer([]byte(`{"payment_token": "tok_visa"}`)))
+			request = withURLParam(request, "id", "o1")
+		})
+		It("should pay the order", func() {
+			mockedPayment.On("Checkout", "o1", "tok_visa").Return(&services.Payment{ID: "p1", Status: payments.StatusCaptured}, nil)
+
+			controllers.Checkout(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			Expect(recorder.Body.String()).To(ContainSubstring(payments.StatusCaptured))
+		})
+		It("should return 402 when the payment is declined", func() {
+			mockedPayment.On("Checkout", "o1", "tok_visa").Return(nil, fmt.Errorf("%w: card_declined", services.ErrPaymentDeclined))
+
+			controllers.Checkout(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusPaymentRequired))
+			Expect(recorder.Body.String()).To(ContainSubstring("card_declined"))
+		})
+		It("should return 409 when the order is not pending", func() {
+			mockedPayment.On("Checkout", "o1", "tok_visa").Return(nil, services.CheckTransition(services.OrderStatusPaid, services.OrderStatusPaid))
+
+			controllers.Checkout(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+		It("should return 502 when the provider fails", func() {
+			mockedPayment.On("Checkout", "o1", "tok_visa").Return(nil, fmt.Errorf("%w: unavailable", services.ErrPaymentFailed))
+
+			controllers.Checkout(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadGateway))
+		})
+	})
+
+	Describe("GetPaymentsByOrder", func() {
+		It("should list the payment attempts", func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/orders/o1/payments", nil)
+			request = withURLParam(request, "id", "o1")
+			mockedPayment.On("GetPaymentsByOrder", "o1").Return([]*services.Payment{{ID: "p1"}, {ID: "p2"}}, nil)
+
+			controllers.GetPaymentsByOrder(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"p2"`))
+		})
+	})
+
+	Describe("RefundPayment", func() {
+		It("should return 409 for a payment that was not captured", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/payments/p1/refund", nil)
+			request = withURLParam(request, "id", "p1")
+			mockedPayment.On("RefundPayment", "p1").Return(nil, services.ErrPaymentNotRefundable)
+
+			controllers.RefundPayment(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("PaymentWebhook", func() {
+		It("should pass the raw body and signature to the service", func() {
+			payload := []byte(`{"reference": "fake_pay_000001", "status": "refunded"}`)
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/payments/webhook", bytes.NewBuffer(payload))
+			request.Header.Set("X-Payment-Signature", "abc")
+			mockedPayment.On("HandleWebhook", payload, "abc").Return(&services.Payment{ID: "p1"}, nil)
+
+			controllers.PaymentWebhook(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			mockedPayment.AssertCalled(GinkgoT(), "HandleWebhook", payload, "abc")
+		})
+		It("should return 401 for an invalid signature", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/payments/webhook", bytes.NewBuffer([]byte(`{}`)))
+			mockedPayment.On("HandleWebhook", []byte(`{}`), "").Return(nil, payments.ErrInvalidSignature)
+
+			controllers.PaymentWebhook(recorder, request, mockedPayment)
+
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+})
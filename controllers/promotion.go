@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// promotionErrorStatus maps the promotion service errors to the status code returned to the client
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidPromotion), errors.Is(err, services.ErrOrderRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOrderNotOfCart):
+		return http.StatusConflict
+	default:
+		return cartErrorStatus(err)
+	}
+}
+
+// GET /promotions
+
+func GetAllPromotions(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	all, err := promotion.GetAllPromotions()
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"promotions": all})
+}
+
+// GET /promotions/{id}
+
+func GetPromotionById(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	id := chi.URLParam(r, "id")
+
+	found, err := promotion.GetPromotionById(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"promotion": found})
+}
+
+// POST /promotions
+
+func CreatePromotion(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	var promotionData services.Promotion
+	err := helpers.ReadJson(w, r, &promotionData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := promotion.CreatePromotion(promotionData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"promotion": created})
+}
+
+// DELETE /promotions/{id}
+
+func DeletePromotion(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	id := chi.URLParam(r, "id")
+
+	err := promotion.DeletePromotion(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+}
+
+// POST /promotions/evaluate
+
+func EvaluatePromotions(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	var req services.PromotionRequest
+	err := helpers.ReadJson(w, r, &req)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := promotion.EvaluateCart(req)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"evaluation": evaluation})
+}
+
+// POST /promotions/redeem
+
+func RedeemPromotions(w http.ResponseWriter, r *http.Request, promotion services.PromotionService) {
+	var req struct {
+		services.PromotionRequest
+		OrderID string `json:"order_id"`
+	}
+	err := helpers.ReadJson(w, r, &req)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := promotion.RedeemPromotions(req.PromotionRequest, req.OrderID)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, promotionErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"evaluation": evaluation})
+}
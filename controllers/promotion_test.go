@@ -0,0 +1,110 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedPromotion *mocks.PromotionService
+
+var _ = Describe("Promotion controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedPromotion = new(mocks.PromotionService)
+	})
+
+	Describe("CreatePromotion", func() {
+		It("should create the promotion", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions", bytes.NewBuffer([]byte(`{"code": "SUMMER", "name": "Summer", "type": "percentage", "value": 10}`)))
+			mockedPromotion.On("CreatePromotion", mock.MatchedBy(func(p services.Promotion) bool {
+				return p.Code == "SUMMER" && p.Type == services.PromotionPercentage && p.Value == 10
+			})).Return(&services.Promotion{ID: "p1", Code: "SUMMER"}, nil)
+
+			controllers.CreatePromotion(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+		})
+		It("should return 400 for an invalid promotion", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions", bytes.NewBuffer([]byte(`{"name": "Summer", "type": "bogus"}`)))
+			mockedPromotion.On("CreatePromotion", mock.Anything).Return(nil, fmt.Errorf("%w: unknown type", services.ErrInvalidPromotion))
+
+			controllers.CreatePromotion(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GetPromotionById", func() {
+		It("should return 404 for an unknown promotion", func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/promotions/p1", nil)
+			request = withURLParam(request, "id", "p1")
+			mockedPromotion.On("GetPromotionById", "p1").Return(nil, services.ErrPromotionNotFound)
+
+			controllers.GetPromotionById(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("EvaluatePromotions", func() {
+		It("should explain the applied and rejected promotions", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions/evaluate", bytes.NewBuffer([]byte(`{"cart_id": "c1", "codes": ["SUMMER"]}`)))
+			evaluation := &services.PromotionEvaluation{
+				CartID:   "c1",
+				Applied:  []services.AppliedPromotion{{Name: "Summer", Discount: 2, Reason: "10% off the whole cart"}},
+				Rejected: []services.RejectedPromotion{{Code: "OLD", Reason: "expired"}},
+			}
+			mockedPromotion.On("EvaluateCart", services.PromotionRequest{CartID: "c1", Codes: []string{"SUMMER"}}).Return(evaluation, nil)
+
+			controllers.EvaluatePromotions(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response map[string]services.PromotionEvaluation
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response["evaluation"].Applied[0].Reason).To(Equal("10% off the whole cart"))
+			Expect(response["evaluation"].Rejected[0].Reason).To(Equal("expired"))
+		})
+		It("should return 404 for an unknown cart", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions/evaluate", bytes.NewBuffer([]byte(`{"cart_id": "c1"}`)))
+			mockedPromotion.On("EvaluateCart", mock.Anything).Return(nil, services.ErrCartNotFound)
+
+			controllers.EvaluatePromotions(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("RedeemPromotions", func() {
+		It("should pass the order along", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions/redeem", bytes.NewBuffer([]byte(`{"cart_id": "c1", "customer_id": "u1", "order_id": "o1"}`)))
+			mockedPromotion.On("RedeemPromotions", services.PromotionRequest{CartID: "c1", CustomerID: "u1"}, "o1").Return(&services.PromotionEvaluation{CartID: "c1"}, nil)
+
+			controllers.RedeemPromotions(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should refuse an order that wasn't placed from the cart with 409", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/promotions/redeem", bytes.NewBuffer([]byte(`{"cart_id": "c1", "order_id": "o2"}`)))
+			mockedPromotion.On("RedeemPromotions", services.PromotionRequest{CartID: "c1"}, "o2").Return(nil, services.ErrOrderNotOfCart)
+
+			controllers.RedeemPromotions(recorder, request, mockedPromotion)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// tenantErrorStatus maps the tenant service errors to the status code returned to the client
+func tenantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTenant):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrDatabaseUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// TenantError writes the error of a tenant that couldn't be resolved
+func TenantError(w http.ResponseWriter, err error) {
+	helpers.MessageLogs.ErrorLog.Println(err)
+	helpers.ErrorJson(w, err, tenantErrorStatus(err))
+}
+
+// GET /tenant
+
+// GetCurrentTenant returns the tenant the request was made to, with its configuration
+func GetCurrentTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := services.TenantFromContext(r.Context())
+	if !ok {
+		TenantError(w, services.ErrTenantNotFound)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"tenant": tenant})
+}
+
+// GET /tenants
+
+func GetAllTenants(w http.ResponseWriter, r *http.Request, tenants services.TenantService) {
+	all, err := tenants.GetAllTenants()
+	if err != nil {
+		TenantError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"tenants": all})
+}
+
+// GET /tenants/{id}
+
+func GetTenantById(w http.ResponseWriter, r *http.Request, tenants services.TenantService) {
+	tenant, err := tenants.GetTenantById(chi.URLParam(r, "id"))
+	if err != nil {
+		TenantError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"tenant": tenant})
+}
+
+// POST /tenants
+
+func CreateTenant(w http.ResponseWriter, r *http.Request, tenants services.TenantService) {
+	var tenantData services.Tenant
+	err := helpers.ReadJson(w, r, &tenantData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := tenants.CreateTenant(tenantData)
+	if err != nil {
+		TenantError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"tenant": created})
+}
+
+// PUT /tenants/{id}/config
+
+func UpdateTenantConfig(w http.ResponseWriter, r *http.Request, tenants services.TenantService) {
+	var config services.TenantConfig
+	err := helpers.ReadJson(w, r, &config)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	updated, err := tenants.UpdateTenantConfig(chi.URLParam(r, "id"), config)
+	if err != nil {
+		TenantError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"tenant": updated})
+}
@@ -0,0 +1,75 @@
+package controllers_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedTenant *mocks.TenantService
+
+var _ = Describe("Tenant controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedTenant = new(mocks.TenantService)
+	})
+
+	It("should return the tenant of the request with its configuration", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+		tenant := &services.Tenant{ID: "t1", Slug: "roastery", Config: services.TenantConfig{Currency: "EUR"}}
+		request = request.WithContext(services.ContextWithTenant(request.Context(), tenant))
+
+		controllers.GetCurrentTenant(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"currency": "EUR"`))
+	})
+
+	It("should return 404 when no tenant was resolved", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+
+		controllers.GetCurrentTenant(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should create the tenant", func() {
+		request, _ = http.NewRequest(http.MethodPost, "/api/v1/tenants", bytes.NewBufferString(`{"slug": "roastery", "name": "The Roastery"}`))
+		mockedTenant.On("CreateTenant", mock.MatchedBy(func(t services.Tenant) bool { return t.Slug == "roastery" })).
+			Return(&services.Tenant{ID: "t1", Slug: "roastery", Name: "The Roastery"}, nil)
+
+		controllers.CreateTenant(recorder, request, mockedTenant)
+
+		Expect(recorder.Code).To(Equal(http.StatusCreated))
+	})
+
+	It("should return 400 for an invalid configuration", func() {
+		request, _ = http.NewRequest(http.MethodPut, "/api/v1/tenants/t1/config", bytes.NewBufferString(`{"currency": "euro"}`))
+		mockedTenant.On("UpdateTenantConfig", "", services.TenantConfig{Currency: "euro"}).
+			Return(nil, fmt.Errorf("%w: currency must be an ISO 4217 code", services.ErrInvalidTenant))
+
+		controllers.UpdateTenantConfig(recorder, request, mockedTenant)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(recorder.Body.String()).To(ContainSubstring("ISO 4217"))
+	})
+
+	It("should return 404 for a missing tenant", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/tenants/t9", nil)
+		mockedTenant.On("GetTenantById", "").Return(nil, services.ErrTenantNotFound)
+
+		controllers.GetTenantById(recorder, request, mockedTenant)
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+})
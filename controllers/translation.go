@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// translationErrorStatus maps the translation service errors to the status code returned to the client
+func translationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTranslationNotFound), errors.Is(err, services.ErrCoffeeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTranslation):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrDatabaseUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func translationError(w http.ResponseWriter, err error) {
+	helpers.MessageLogs.ErrorLog.Println(err)
+	helpers.ErrorJson(w, err, translationErrorStatus(err))
+}
+
+// localize translates the coffees into the language of the request, falling back on the locale of the
+// tenant and then on the source language. The coffees are served as written when there are no
+// translations or they can't be read, the catalog stays up without them.
+func localize(w http.ResponseWriter, r *http.Request, translations services.TranslationService, coffees ...*services.Coffee) {
+	if translations == nil {
+		return
+	}
+	var locale string
+	if tenant, ok := services.TenantFromContext(r.Context()); ok {
+		locale = tenant.Config.Locale
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	lang, err := translations.Localize(coffees, services.LanguageChain(helpers.RequestLanguages(r), locale))
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println("Error localizing the coffees:", err)
+		return
+	}
+	w.Header().Set("Content-Language", lang)
+}
+
+// GET /coffees/coffee/{id}/translations
+
+func GetTranslations(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	all, err := translations.GetTranslations(chi.URLParam(r, "id"))
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"translations": all})
+}
+
+// PUT /coffees/coffee/{id}/translations/{lang}
+
+func PutTranslation(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	var translation services.Translation
+	err := helpers.ReadJson(w, r, &translation)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+	translation.CoffeeID = chi.URLParam(r, "id")
+	translation.Lang = chi.URLParam(r, "lang")
+
+	saved, err := translations.PutTranslation(translation)
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"translation": saved})
+}
+
+// DELETE /coffees/coffee/{id}/translations/{lang}
+
+func DeleteTranslation(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	err := translations.DeleteTranslation(chi.URLParam(r, "id"), chi.URLParam(r, "lang"))
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+}
+
+// GET /translations/labels
+
+func GetLabels(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	labels, err := translations.GetLabels(r.URL.Query().Get("lang"))
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"labels": labels})
+}
+
+// PUT /translations/labels
+
+func PutLabel(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	var label services.Label
+	err := helpers.ReadJson(w, r, &label)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := translations.PutLabel(label)
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"label": saved})
+}
+
+// DELETE /translations/labels?kind=&value=&lang=
+
+func DeleteLabel(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	query := r.URL.Query()
+	err := translations.DeleteLabel(query.Get("kind"), query.Get("value"), query.Get("lang"))
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+}
+
+// GET /translations/missing?lang=nl,de
+
+func GetMissingTranslations(w http.ResponseWriter, r *http.Request, translations services.TranslationService) {
+	var langs []string
+	for _, lang := range strings.Split(r.URL.Query().Get("lang"), ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+
+	missing, err := translations.MissingTranslations(langs)
+	if err != nil {
+		translationError(w, err)
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"missing": missing})
+}
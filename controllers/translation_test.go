@@ -0,0 +1,90 @@
+package controllers_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedTranslation *mocks.TranslationService
+
+var _ = Describe("Translation controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedCoffee = new(mocks.CoffeeService)
+		mockedTranslation = new(mocks.TranslationService)
+	})
+
+	It("should serve the catalog in the language of the request, then the locale of the tenant", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/coffees?lang=nl-BE", nil)
+		request.Header.Set("Accept-Language", "de")
+		tenant := &services.Tenant{ID: "t1", Config: services.TenantConfig{Locale: "fr"}}
+		request = request.WithContext(services.ContextWithTenant(request.Context(), tenant))
+
+		coffees := []*services.Coffee{{ID: "c1", Name: "Morning"}}
+		mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+		mockedTranslation.On("Localize", coffees, []string{"nl-be", "nl", "de", "fr"}).
+			Run(func(args mock.Arguments) { coffees[0].Name = "Ochtend" }).
+			Return("nl", nil)
+
+		controllers.GetAllCoffees(recorder, request, mockedCoffee, mockedTranslation)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Language")).To(Equal("nl"))
+		Expect(recorder.Header().Get("Vary")).To(Equal("Accept-Language"))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"name": "Ochtend"`))
+	})
+
+	It("should serve the coffee as written when the translations can't be read", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/coffees/coffee/c1", nil)
+		mockedCoffee.On("GetCoffeesById", "").Return(&services.Coffee{ID: "c1", Name: "Morning"}, nil)
+		mockedTranslation.On("Localize", mock.Anything, mock.Anything).Return(services.SourceLanguage, errors.New("connection refused"))
+
+		controllers.GetCoffeesById(recorder, request, mockedCoffee, mockedTranslation)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Language")).To(BeEmpty())
+		Expect(recorder.Body.String()).To(ContainSubstring(`"name": "Morning"`))
+	})
+
+	It("should return 400 for a language that isn't served", func() {
+		request, _ = http.NewRequest(http.MethodPut, "/api/v1/coffees/coffee/c1/translations/fr", bytes.NewBufferString(`{"name": "Matin"}`))
+		mockedTranslation.On("PutTranslation", services.Translation{Name: "Matin"}).
+			Return(nil, fmt.Errorf("%w: language %q isn't served", services.ErrInvalidTranslation, "fr"))
+
+		controllers.PutTranslation(recorder, request, mockedTranslation)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should return 404 for a translation that doesn't exist", func() {
+		request, _ = http.NewRequest(http.MethodDelete, "/api/v1/translations/labels?kind=roast&value=Dark&lang=nl", nil)
+		mockedTranslation.On("DeleteLabel", "roast", "Dark", "nl").Return(services.ErrTranslationNotFound)
+
+		controllers.DeleteLabel(recorder, request, mockedTranslation)
+
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should report the missing translations in the requested languages", func() {
+		request, _ = http.NewRequest(http.MethodGet, "/api/v1/translations/missing?lang=nl,%20de", nil)
+		mockedTranslation.On("MissingTranslations", []string{"nl", "de"}).
+			Return([]*services.MissingTranslation{{Lang: "nl", Kind: "roast", Key: "Dark"}}, nil)
+
+		controllers.GetMissingTranslations(recorder, request, mockedTranslation)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"key": "Dark"`))
+	})
+})
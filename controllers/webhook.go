@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// webhookErrorStatus maps the webhook service errors to the status code returned to the client
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSubscriptionNotFound), errors.Is(err, services.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidSubscription):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GET /webhooks
+
+func GetAllSubscriptions(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	all, err := webhook.GetAllSubscriptions()
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"subscriptions": all})
+}
+
+// POST /webhooks
+
+func CreateSubscription(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	var subscriptionData services.WebhookSubscription
+	err := helpers.ReadJson(w, r, &subscriptionData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created, err := webhook.CreateSubscription(subscriptionData)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusCreated, helpers.Envelop{"subscription": created})
+}
+
+// DELETE /webhooks/{id}
+
+func DeleteSubscription(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	id := chi.URLParam(r, "id")
+
+	err := webhook.DeleteSubscription(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+}
+
+// GET /webhooks/{id}/deliveries
+
+func GetDeliveries(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	id := chi.URLParam(r, "id")
+
+	deliveries, err := webhook.GetDeliveries(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"deliveries": deliveries})
+}
+
+// GET /webhooks/deliveries/dead
+
+func GetDeadDeliveries(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	deliveries, err := webhook.GetDeadDeliveries()
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"deliveries": deliveries})
+}
+
+// POST /webhooks/deliveries/{deliveryId}/redeliver
+
+func Redeliver(w http.ResponseWriter, r *http.Request, webhook services.WebhookService) {
+	id := chi.URLParam(r, "deliveryId")
+
+	delivery, err := webhook.Redeliver(id)
+	if err != nil {
+		helpers.MessageLogs.ErrorLog.Println(err)
+		helpers.ErrorJson(w, err, webhookErrorStatus(err))
+		return
+	}
+
+	helpers.Render(w, r, http.StatusOK, helpers.Envelop{"delivery": delivery})
+}
@@ -0,0 +1,82 @@
+package controllers_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+)
+
+var mockedWebhook *mocks.WebhookService
+
+var _ = Describe("Webhook controller", Label("unit"), func() {
+	BeforeEach(func() {
+		recorder = httptest.NewRecorder()
+
+		mockedWebhook = new(mocks.WebhookService)
+	})
+
+	Describe("CreateSubscription", func() {
+		It("should create the subscription", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/webhooks", bytes.NewBuffer([]byte(`{"url": "https://erp.example.com/hooks", "event_types": ["coffee.created"]}`)))
+			mockedWebhook.On("CreateSubscription", mock.MatchedBy(func(s services.WebhookSubscription) bool {
+				return s.URL == "https://erp.example.com/hooks" && len(s.EventTypes) == 1
+			})).Return(&services.WebhookSubscription{ID: "s1", Secret: "secret"}, nil)
+
+			controllers.CreateSubscription(recorder, request, mockedWebhook)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"secret": "secret"`))
+		})
+		It("should return 400 for an invalid subscription", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/webhooks", bytes.NewBuffer([]byte(`{"url": "ftp://nope"}`)))
+			mockedWebhook.On("CreateSubscription", mock.Anything).Return(nil, fmt.Errorf("%w: bad url", services.ErrInvalidSubscription))
+
+			controllers.CreateSubscription(recorder, request, mockedWebhook)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GetDeliveries", func() {
+		It("should return 404 for an unknown subscription", func() {
+			request, _ = http.NewRequest(http.MethodGet, "/api/v1/webhooks/s1/deliveries", nil)
+			request = withURLParam(request, "id", "s1")
+			mockedWebhook.On("GetDeliveries", "s1").Return(nil, services.ErrSubscriptionNotFound)
+
+			controllers.GetDeliveries(recorder, request, mockedWebhook)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("Redeliver", func() {
+		It("should return the delivery after the new attempt", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/webhooks/deliveries/d1/redeliver", nil)
+			request = withURLParam(request, "deliveryId", "d1")
+			mockedWebhook.On("Redeliver", "d1").Return(&services.WebhookDelivery{ID: "d1", Status: services.DeliveryStatusDelivered}, nil)
+
+			controllers.Redeliver(recorder, request, mockedWebhook)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"status": "delivered"`))
+		})
+		It("should return 404 for an unknown delivery", func() {
+			request, _ = http.NewRequest(http.MethodPost, "/api/v1/webhooks/deliveries/d1/redeliver", nil)
+			request = withURLParam(request, "deliveryId", "d1")
+			mockedWebhook.On("Redeliver", "d1").Return(nil, services.ErrDeliveryNotFound)
+
+			controllers.Redeliver(recorder, request, mockedWebhook)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
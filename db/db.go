@@ -1,52 +1,228 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"math/rand"
+	"os"
+	"strings"
 	"time"
 
-	_ "github.com/jackc/pgconn"
-	_ "github.com/jackc/pgx/stdlib"
-	_ "github.com/jackc/pgx/v4"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type DB struct {
 	DB DBInterface
+	// Reads runs the queries that can be served by a replica, it is DB when there are none
+	Reads Executor
 }
 
+// The pool limits used unless the DSN sets them, e.g. with pool_max_conns=20
 const (
 	maxOpenDbConn = 10
-	maxIdleDbConn = 5
+	minIdleDbConn = 2
 	maxDbLifetime = 5 * time.Minute
+	// The idle connections are checked this often, a broken one is dropped before a query picks it
+	healthCheckPeriod = 15 * time.Second
 )
 
-var SqlOpen = sql.Open
+// Option changes the configuration of the pools
+type Option func(s *settings)
 
-func ConnectPostgres(dsn string) (*DB, error) {
-	d, err := SqlOpen("pgx", dsn)
+type settings struct {
+	pool          []func(config *pgxpool.Config)
+	replicas      []string
+	maxReplicaLag time.Duration
+}
+
+func newSettings(opts []Option) *settings {
+	s := &settings{maxReplicaLag: defaultMaxReplicaLag}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithMaxConns sets the size of the pool
+func WithMaxConns(n int32) Option {
+	return func(s *settings) {
+		s.pool = append(s.pool, func(config *pgxpool.Config) {
+			config.MaxConns = n
+		})
+	}
+}
+
+// WithStatementCache sets how many prepared statements each connection keeps, zero turns the cache off
+// for the poolers that can't keep prepared statements, e.g. PgBouncer in transaction mode. The DSN can
+// also set it with statement_cache_capacity.
+func WithStatementCache(capacity int) Option {
+	return func(s *settings) {
+		s.pool = append(s.pool, func(config *pgxpool.Config) {
+			if capacity == 0 {
+				config.ConnConfig.BuildStatementCache = nil
+				config.ConnConfig.PreferSimpleProtocol = true
+				return
+			}
+			config.ConnConfig.BuildStatementCache = statementCache(capacity)
+		})
+	}
+}
+
+// WithTracer reports every query, batch and copy run on the pool to the tracer
+func WithTracer(tracer Tracer) Option {
+	return func(s *settings) {
+		s.pool = append(s.pool, func(config *pgxpool.Config) {
+			config.ConnConfig.Logger = traceLogger{tracer}
+			config.ConnConfig.LogLevel = pgx.LogLevelInfo
+		})
+	}
+}
+
+// WithReplicas adds read replicas of the primary, they get the same pool options. See Cluster.
+func WithReplicas(dsns ...string) Option {
+	return func(s *settings) {
+		s.replicas = append(s.replicas, dsns...)
+	}
+}
+
+// WithMaxReplicaLag sets how far behind the primary a replica can be and still serve reads, 10s by default
+func WithMaxReplicaLag(lag time.Duration) Option {
+	return func(s *settings) {
+		s.maxReplicaLag = lag
+	}
+}
+
+// Retry is how ConnectWithRetry waits for the primary: the waits double from Initial up to Max, each
+// randomly shortened by up to half so that instances starting together don't retry together
+type Retry struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Timeout is how long to keep trying, zero keeps trying until the context is done
+	Timeout time.Duration
+}
+
+// DefaultRetry is enough for Postgres to start next to the server, e.g. in docker-compose
+var DefaultRetry = Retry{Initial: 500 * time.Millisecond, Max: 15 * time.Second, Timeout: time.Minute}
+
+// Wait is the wait before the retry following the attempt, attempts count from 1
+func (r Retry) Wait(attempt int) time.Duration {
+	wait := r.Initial
+	for i := 1; i < attempt && wait < r.Max; i++ {
+		wait *= 2
+	}
+	if wait > r.Max {
+		wait = r.Max
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// ConnectPostgres opens the pool of the primary and checks it answers. The replicas are checked too,
+// then every few seconds, but one that is down doesn't stop the start.
+func ConnectPostgres(dsn string, opts ...Option) (*DB, error) {
+	return connect(context.Background(), dsn, nil, opts)
+}
+
+// ConnectWithRetry is ConnectPostgres waiting for the primary to answer, it gives up when the retry
+// times out or the context is done. A DSN that doesn't parse fails straight away.
+func ConnectWithRetry(ctx context.Context, dsn string, retry Retry, opts ...Option) (*DB, error) {
+	if retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, retry.Timeout)
+		defer cancel()
+	}
+	return connect(ctx, dsn, &retry, opts)
+}
+
+func connect(ctx context.Context, dsn string, retry *Retry, opts []Option) (*DB, error) {
+	pool, err := OpenPostgres(dsn, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	d.SetMaxOpenConns(maxOpenDbConn)
-	d.SetMaxIdleConns(maxIdleDbConn)
-	d.SetConnMaxLifetime(maxDbLifetime)
+	for attempt := 1; ; attempt++ {
+		err = testDB(pool)
+		if err == nil {
+			break
+		}
+		if retry == nil {
+			pool.Close()
+			return nil, err
+		}
+
+		wait := retry.Wait(attempt)
+		fmt.Fprintf(os.Stderr, "Postgres isn't answering (attempt %d), retrying in %s\n", attempt, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, fmt.Errorf("giving up connecting to Postgres after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
+		}
+	}
+
+	s := newSettings(opts)
+	if len(s.replicas) == 0 {
+		return &DB{DB: pool, Reads: pool}, nil
+	}
+
+	cluster := NewCluster(pool)
+	cluster.MaxLag = s.maxReplicaLag
+	for i, replicaDsn := range s.replicas {
+		replica, err := OpenPostgres(replicaDsn, opts...)
+		if err != nil {
+			cluster.Close()
+			return nil, fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		config := replica.Config().ConnConfig
+		cluster.Replicas = append(cluster.Replicas, NewReplica(fmt.Sprintf("%s:%d", config.Host, config.Port), replica))
+	}
+	cluster.CheckReplicas(context.Background())
+	cluster.MonitorReplicas(defaultReplicaCheckEvery)
 
-	err = testDB(d)
+	return &DB{DB: cluster, Reads: cluster.Reads()}, nil
+}
+
+// OpenPostgres sets up the pool without connecting, the connections are made by the first queries
+func OpenPostgres(dsn string, opts ...Option) (*Pool, error) {
+	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DB{DB: d}, nil
+	if !strings.Contains(dsn, "pool_max_conns") {
+		config.MaxConns = maxOpenDbConn
+	}
+	if !strings.Contains(dsn, "pool_min_conns") {
+		config.MinConns = minIdleDbConn
+	}
+	if !strings.Contains(dsn, "pool_max_conn_lifetime") {
+		config.MaxConnLifetime = maxDbLifetime
+	}
+	if !strings.Contains(dsn, "pool_health_check_period") {
+		config.HealthCheckPeriod = healthCheckPeriod
+	}
+	config.LazyConnect = true
+
+	for _, configure := range newSettings(opts).pool {
+		configure(config)
+	}
+
+	pool, err := pgxpool.ConnectConfig(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{Pool: pool}, nil
 }
 
-func testDB(d *sql.DB) error {
+func testDB(d DBInterface) error {
 	err := d.Ping()
 	if err != nil {
-		fmt.Println("Error", err)
+		fmt.Fprintln(os.Stderr, "Error", err)
 		return err
 	}
-	fmt.Println("*** Pinged database successfully! ***")
+	fmt.Fprintln(os.Stderr, "*** Pinged database successfully! ***")
 	return nil
 }
@@ -3,18 +3,59 @@ package db
 import (
 	"context"
 	"database/sql"
-	"time"
 )
 
+// Rows is the result of a query, pgx.Rows implements it
+type Rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close()
+}
+
+// Row is the first row of a query, its Scan returns sql.ErrNoRows when there is none
+type Row interface {
+	Scan(dest ...interface{}) error
+}
+
+// Executor runs queries, on the pool or within a transaction
+type Executor interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Tx is a transaction, it can be ended once and returns sql.ErrTxDone after that
+type Tx interface {
+	Executor
+	Commit() error
+	Rollback() error
+}
+
+// Querier is what the services run their queries and transactions on
+type Querier interface {
+	Executor
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// Bulk is implemented by the pool and its transactions, for the writes of many rows at once
+type Bulk interface {
+	// SendBatch sends the queries in a single round trip and returns the rows affected by each.
+	// Outside of a transaction, they are run in an implicit one.
+	SendBatch(ctx context.Context, queries []Query) ([]int64, error)
+	// CopyFrom loads the rows into the columns of the table with COPY
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error)
+}
+
+// Query is a statement of a batch
+type Query struct {
+	SQL  string
+	Args []interface{}
+}
+
 type DBInterface interface {
+	Querier
+	Bulk
 	Ping() error
-	SetMaxOpenConns(n int)
-	SetMaxIdleConns(n int)
-	SetConnMaxLifetime(d time.Duration)
 	Close() error
-	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
-	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
-	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
-
-var _ DBInterface = &sql.DB{}
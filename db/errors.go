@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgconn"
+)
+
+// Postgres error codes the callers tell apart, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	InvalidText          = "22P02"
+	UniqueViolation      = "23505"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	TooManyConnections   = "53300"
+	AdminShutdown        = "57P01"
+	CrashShutdown        = "57P02"
+	CannotConnectNow     = "57P03"
+)
+
+// SQLState returns the Postgres error code of err, or "" when it didn't come from Postgres.
+// The errors of pgx and pq both report their code through a SQLState method.
+func SQLState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	return ""
+}
+
+// IsSerializationFailure reports whether a transaction failed because of a concurrent one, it can be
+// run again from the start
+func IsSerializationFailure(err error) bool {
+	code := SQLState(err)
+	return code == SerializationFailure || code == DeadlockDetected
+}
+
+// IsConnectionError reports whether err means the database can't be reached rather than the query
+// failed: the connection couldn't be made or broke, Postgres is shutting down or starting, or the query
+// ran out of time, which is how a database that stopped answering shows up
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// A connection that couldn't be made wraps the error of the dial
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr), pgconn.Timeout(err):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+
+	// Class 08 is the connection exceptions
+	switch code := SQLState(err); {
+	case strings.HasPrefix(code, "08"):
+		return true
+	case code == TooManyConnections, code == AdminShutdown, code == CrashShutdown, code == CannotConnectNow:
+		return true
+	}
+	return false
+}
+
+var isolationLevels = map[string]sql.IsolationLevel{
+	"":                 sql.LevelDefault,
+	"default":          sql.LevelDefault,
+	"read committed":   sql.LevelReadCommitted,
+	"repeatable read":  sql.LevelRepeatableRead,
+	"serializable":     sql.LevelSerializable,
+	"read uncommitted": sql.LevelReadUncommitted,
+}
+
+// ParseIsolation parses an isolation level as written in SQL, e.g. "repeatable read" or "SERIALIZABLE";
+// underscores are accepted in place of the spaces
+func ParseIsolation(s string) (sql.IsolationLevel, error) {
+	level, ok := isolationLevels[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", " "))]
+	if !ok {
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level %q", s)
+	}
+	return level, nil
+}
@@ -0,0 +1,56 @@
+package db_test
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgconn"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection errors", Label("unit"), func() {
+	It("should tell a database that can't be reached from a failed query", func() {
+		refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		Expect(db.IsConnectionError(fmt.Errorf("failed to connect: %w", refused))).To(BeTrue())
+		Expect(db.IsConnectionError(io.ErrUnexpectedEOF)).To(BeTrue())
+		Expect(db.IsConnectionError(context.DeadlineExceeded)).To(BeTrue())
+		Expect(db.IsConnectionError(&pgconn.PgError{Code: "08006"})).To(BeTrue())
+		Expect(db.IsConnectionError(&pgconn.PgError{Code: db.CannotConnectNow})).To(BeTrue())
+
+		Expect(db.IsConnectionError(nil)).To(BeFalse())
+		Expect(db.IsConnectionError(&pgconn.PgError{Code: "23505"})).To(BeFalse())
+		Expect(db.IsConnectionError(errors.New("sql: no rows in result set"))).To(BeFalse())
+	})
+})
+
+var _ = Describe("Retry", Label("unit"), func() {
+	It("should double the waits up to the max with jitter", func() {
+		retry := db.Retry{Initial: 100 * time.Millisecond, Max: time.Second}
+		for range 20 {
+			Expect(retry.Wait(1)).To(BeNumerically("~", 75*time.Millisecond, 25*time.Millisecond))
+			Expect(retry.Wait(3)).To(BeNumerically("~", 300*time.Millisecond, 100*time.Millisecond))
+			Expect(retry.Wait(30)).To(BeNumerically("~", 750*time.Millisecond, 250*time.Millisecond))
+		}
+	})
+
+	It("should give up once the retry times out", func() {
+		retry := db.Retry{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Timeout: 100 * time.Millisecond}
+		start := time.Now()
+		_, err := db.ConnectWithRetry(context.Background(), "host=127.0.0.1 port=1 user=root dbname=coffee connect_timeout=1", retry)
+		Expect(err).To(MatchError(ContainSubstring("giving up connecting to Postgres")))
+		Expect(db.IsConnectionError(err)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+	})
+
+	It("should not retry a DSN that doesn't parse", func() {
+		_, err := db.ConnectWithRetry(context.Background(), "host=localhost port=notaport", db.DefaultRetry)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations are tracked in the table of sqlx-cli, so they can be run by coffeectl or by
+// `sqlx migrate` from the makefile interchangeably
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS _sqlx_migrations (
+	version BIGINT PRIMARY KEY,
+	description TEXT NOT NULL,
+	installed_on TIMESTAMPTZ NOT NULL DEFAULT now(),
+	success BOOLEAN NOT NULL,
+	checksum BYTEA NOT NULL,
+	execution_time BIGINT NOT NULL
+)`
+
+var ErrDirtyMigration = errors.New("a migration failed halfway and has to be fixed by hand")
+
+type Migration struct {
+	Version     int64
+	Description string
+	Up          string
+	Down        string
+	Applied     bool
+}
+
+// LoadMigrations reads the <version>_<description>.up.sql and .down.sql files, ordered by version
+func LoadMigrations(fsys fs.FS) ([]*Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, name := range names {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			continue
+		}
+		prefix, description, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Description: strings.ReplaceAll(description, "_", " ")}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationStatus marks the migrations that were applied to the database
+func MigrationStatus(ctx context.Context, d Querier, migrations []*Migration) error {
+	applied, err := appliedMigrations(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		_, migration.Applied = applied[migration.Version]
+	}
+	return nil
+}
+
+// MigrateUp applies the pending migrations in order, each in its own transaction, and returns the applied ones
+func MigrateUp(ctx context.Context, d Querier, migrations []*Migration) ([]*Migration, error) {
+	applied, err := appliedMigrations(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for _, migration := range migrations {
+		if checksum, ok := applied[migration.Version]; ok {
+			if string(checksum) != string(migration.checksum()) {
+				return done, fmt.Errorf("migration %d was changed after it was applied", migration.Version)
+			}
+			continue
+		}
+
+		err := inTx(ctx, d, func(tx Tx) error {
+			start := time.Now()
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Description, err)
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO _sqlx_migrations(version, description, success, checksum, execution_time)
+				VALUES ($1, $2, true, $3, $4)`, migration.Version, migration.Description, migration.checksum(), time.Since(start).Nanoseconds())
+			return err
+		})
+		if err != nil {
+			return done, err
+		}
+		migration.Applied = true
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the latest applied migration, it returns nil when there is nothing to revert
+func MigrateDown(ctx context.Context, d Querier, migrations []*Migration) (*Migration, error) {
+	applied, err := appliedMigrations(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d can't be reverted, it has no down file", migration.Version)
+		}
+
+		err := inTx(ctx, d, func(tx Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return fmt.Errorf("reverting migration %d %s: %w", migration.Version, migration.Description, err)
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM _sqlx_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		migration.Applied = false
+		return migration, nil
+	}
+	return nil, nil
+}
+
+func (m *Migration) checksum() []byte {
+	sum := sha512.Sum384([]byte(m.Up))
+	return sum[:]
+}
+
+// appliedMigrations returns the checksums of the applied migrations by version
+func appliedMigrations(ctx context.Context, d Querier) (map[int64][]byte, error) {
+	if _, err := d.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.QueryContext(ctx, `SELECT version, success, checksum FROM _sqlx_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64][]byte{}
+	for rows.Next() {
+		var version int64
+		var success bool
+		var checksum []byte
+		if err := rows.Scan(&version, &success, &checksum); err != nil {
+			return nil, err
+		}
+		if !success {
+			return nil, fmt.Errorf("migration %d: %w", version, ErrDirtyMigration)
+		}
+		applied[version] = checksum
+	}
+	return applied, rows.Err()
+}
+
+func inTx(ctx context.Context, d Querier, fn func(tx Tx) error) error {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/migrations"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrations", Label("unit"), func() {
+	It("should pair the up and down files and order them by version", func() {
+		files := fstest.MapFS{
+			"20241014091512_orders.up.sql":          {Data: []byte("CREATE TABLE orders();")},
+			"20241014091512_orders.down.sql":        {Data: []byte("DROP TABLE orders;")},
+			"20241002102604_init.up.sql":            {Data: []byte("CREATE TABLE coffees();")},
+			"20241016083045_order_lifecycle.up.sql": {Data: []byte("ALTER TABLE orders;")},
+			"README.md":                             {Data: []byte("not a migration")},
+		}
+
+		all, err := db.LoadMigrations(files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(3))
+
+		Expect(all[0].Version).To(Equal(int64(20241002102604)))
+		Expect(all[1].Description).To(Equal("orders"))
+		Expect(all[1].Up).To(Equal("CREATE TABLE orders();"))
+		Expect(all[1].Down).To(Equal("DROP TABLE orders;"))
+		Expect(all[2].Description).To(Equal("order lifecycle"))
+		Expect(all[2].Down).To(BeEmpty())
+	})
+
+	It("should refuse a file without a numeric version", func() {
+		_, err := db.LoadMigrations(fstest.MapFS{"init.up.sql": {Data: []byte("SELECT 1;")}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should load the migrations embedded in the binaries", func() {
+		all, err := db.LoadMigrations(migrations.Files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).NotTo(BeEmpty())
+		for _, migration := range all {
+			Expect(migration.Up).NotTo(BeEmpty(), "migration %d has no up file", migration.Version)
+		}
+	})
+})
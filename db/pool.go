@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Pool runs the queries on a native pgx pool. The statements are prepared on each connection the
+// first time they run and their descriptions cached, so the next runs skip parsing and planning.
+type Pool struct {
+	*pgxpool.Pool
+}
+
+var (
+	_ DBInterface = &Pool{}
+	_ Tx          = &poolTx{}
+	_ Bulk        = &poolTx{}
+)
+
+func (p *Pool) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return p.Pool.Query(ctx, query, args...)
+}
+
+func (p *Pool) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	return row{p.Pool.QueryRow(ctx, query, args...)}
+}
+
+func (p *Pool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tag, err := p.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result(tag), nil
+}
+
+func (p *Pool) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	txOptions, err := pgxTxOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := p.Pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &poolTx{tx}, nil
+}
+
+func (p *Pool) SendBatch(ctx context.Context, queries []Query) ([]int64, error) {
+	return sendBatch(ctx, p.Pool, queries)
+}
+
+func (p *Pool) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	return p.Pool.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+}
+
+func (p *Pool) Ping() error {
+	return p.Pool.Ping(context.Background())
+}
+
+func (p *Pool) Close() error {
+	p.Pool.Close()
+	return nil
+}
+
+// poolTx is a transaction of the pool, it ends with the errors of database/sql
+type poolTx struct {
+	pgx.Tx
+}
+
+func (t *poolTx) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return t.Tx.Query(ctx, query, args...)
+}
+
+func (t *poolTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	return row{t.Tx.QueryRow(ctx, query, args...)}
+}
+
+func (t *poolTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tag, err := t.Tx.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result(tag), nil
+}
+
+func (t *poolTx) Commit() error {
+	return txError(t.Tx.Commit(context.Background()))
+}
+
+func (t *poolTx) Rollback() error {
+	return txError(t.Tx.Rollback(context.Background()))
+}
+
+func (t *poolTx) SendBatch(ctx context.Context, queries []Query) ([]int64, error) {
+	return sendBatch(ctx, t.Tx, queries)
+}
+
+func (t *poolTx) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	return t.Tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+}
+
+func txError(err error) error {
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return sql.ErrTxDone
+	}
+	return err
+}
+
+// row returns sql.ErrNoRows like database/sql, the services compare the errors against it
+type row struct {
+	pgx.Row
+}
+
+func (r row) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// result is the sql.Result of a command, Postgres has no last insert id
+type result pgconn.CommandTag
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by Postgres, use RETURNING")
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return pgconn.CommandTag(r).RowsAffected(), nil
+}
+
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+func sendBatch(ctx context.Context, sender batchSender, queries []Query) ([]int64, error) {
+	batch := &pgx.Batch{}
+	for _, query := range queries {
+		batch.Queue(query.SQL, query.Args...)
+	}
+
+	results := sender.SendBatch(ctx, batch)
+	affected := make([]int64, 0, len(queries))
+	for i := range queries {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			return affected, fmt.Errorf("batch query %d: %w", i, err)
+		}
+		affected = append(affected, tag.RowsAffected())
+	}
+	return affected, results.Close()
+}
+
+var isolationLevelsPgx = map[sql.IsolationLevel]pgx.TxIsoLevel{
+	sql.LevelDefault:         "",
+	sql.LevelReadUncommitted: pgx.ReadUncommitted,
+	sql.LevelReadCommitted:   pgx.ReadCommitted,
+	sql.LevelRepeatableRead:  pgx.RepeatableRead,
+	sql.LevelSerializable:    pgx.Serializable,
+}
+
+func pgxTxOptions(opts *sql.TxOptions) (pgx.TxOptions, error) {
+	var txOptions pgx.TxOptions
+	if opts == nil {
+		return txOptions, nil
+	}
+
+	level, ok := isolationLevelsPgx[opts.Isolation]
+	if !ok {
+		return txOptions, fmt.Errorf("isolation level %s is not supported by Postgres", opts.Isolation)
+	}
+	txOptions.IsoLevel = level
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+	return txOptions, nil
+}
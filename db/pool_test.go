@@ -0,0 +1,148 @@
+package db_test
+
+import (
+	"bytes"
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testDSN = "host=localhost port=5432 user=root password=secret dbname=coffee sslmode=disable timezone=UTC connect_timeout=5"
+
+var _ = Describe("Pool", Label("unit"), func() {
+	It("should set up the pool without connecting", func() {
+		pool, err := db.OpenPostgres(testDSN)
+		Expect(err).NotTo(HaveOccurred())
+		defer pool.Close()
+
+		config := pool.Config()
+		Expect(config.MaxConns).To(BeEquivalentTo(10))
+		Expect(config.MinConns).To(BeEquivalentTo(2))
+		Expect(config.MaxConnLifetime).To(Equal(5 * time.Minute))
+		Expect(config.HealthCheckPeriod).To(Equal(15 * time.Second))
+		Expect(config.ConnConfig.BuildStatementCache).NotTo(BeNil())
+	})
+
+	It("should keep the pool settings of the DSN and apply the options last", func() {
+		pool, err := db.OpenPostgres(testDSN+" pool_max_conns=3 pool_min_conns=1", db.WithStatementCache(0))
+		Expect(err).NotTo(HaveOccurred())
+		defer pool.Close()
+
+		config := pool.Config()
+		Expect(config.MaxConns).To(BeEquivalentTo(3))
+		Expect(config.MinConns).To(BeEquivalentTo(1))
+		Expect(config.ConnConfig.BuildStatementCache).To(BeNil())
+		Expect(config.ConnConfig.PreferSimpleProtocol).To(BeTrue())
+
+		pool, err = db.OpenPostgres(testDSN+" pool_max_conns=3", db.WithMaxConns(7))
+		Expect(err).NotTo(HaveOccurred())
+		defer pool.Close()
+		Expect(pool.Config().MaxConns).To(BeEquivalentTo(7))
+	})
+
+	It("should refuse an invalid DSN", func() {
+		_, err := db.OpenPostgres("host=localhost pool_max_conns=many")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should refuse the isolation levels Postgres doesn't have before connecting", func() {
+		pool, err := db.OpenPostgres(testDSN)
+		Expect(err).NotTo(HaveOccurred())
+		defer pool.Close()
+
+		_, err = pool.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSnapshot})
+		Expect(err).To(MatchError("isolation level Snapshot is not supported by Postgres"))
+	})
+
+	It("should trace the operations logged by pgx", func() {
+		var traces []db.Trace
+		pool, err := db.OpenPostgres(testDSN, db.WithTracer(db.TracerFunc(func(ctx context.Context, trace db.Trace) {
+			traces = append(traces, trace)
+		})))
+		Expect(err).NotTo(HaveOccurred())
+		defer pool.Close()
+
+		logger := pool.Config().ConnConfig.Logger
+		Expect(logger).NotTo(BeNil())
+
+		ctx := context.Background()
+		logger.Log(ctx, pgx.LogLevelInfo, "Query", map[string]interface{}{"sql": "SELECT 1", "time": time.Millisecond, "rowCount": 1})
+		logger.Log(ctx, pgx.LogLevelInfo, "Exec", map[string]interface{}{"sql": "DELETE FROM coffees", "time": 2 * time.Millisecond, "commandTag": pgconn.CommandTag("DELETE 4")})
+		logger.Log(ctx, pgx.LogLevelInfo, "CopyFrom", map[string]interface{}{"tableName": pgx.Identifier{"coffees"}, "time": time.Second, "rowCount": int64(500)})
+		logger.Log(ctx, pgx.LogLevelError, "Query", map[string]interface{}{"sql": "SELECT", "err": errors.New("syntax error")})
+		logger.Log(ctx, pgx.LogLevelInfo, "Dialing PostgreSQL server", map[string]interface{}{"host": "localhost"})
+
+		Expect(traces).To(Equal([]db.Trace{
+			{Operation: "Query", SQL: "SELECT 1", Duration: time.Millisecond, Rows: 1},
+			{Operation: "Exec", SQL: "DELETE FROM coffees", Duration: 2 * time.Millisecond, Rows: 4},
+			{Operation: "CopyFrom", SQL: `"coffees"`, Duration: time.Second, Rows: 500},
+			{Operation: "Query", SQL: "SELECT", Err: errors.New("syntax error")},
+		}))
+	})
+
+	It("should log the slow and the failed queries", func() {
+		var out bytes.Buffer
+		tracer := db.SlowQueryLog(log.New(&out, "", 0), 100*time.Millisecond)
+
+		tracer.Trace(context.Background(), db.Trace{Operation: "Query", SQL: "SELECT 1", Duration: time.Millisecond})
+		tracer.Trace(context.Background(), db.Trace{Operation: "Query", SQL: "SELECT pg_sleep(1)", Duration: time.Second})
+		tracer.Trace(context.Background(), db.Trace{Operation: "Exec", SQL: "DELETE", Duration: time.Millisecond, Err: errors.New("deadlock detected")})
+
+		Expect(out.String()).To(Equal("Slow Query took 1s: SELECT pg_sleep(1)\nExec failed after 1ms: deadlock detected: DELETE\n"))
+	})
+})
+
+var _ = Describe("Pool on Postgres", Label("integration"), func() {
+	var pool *db.Pool
+
+	BeforeEach(func() {
+		dsn := os.Getenv("DSN")
+		if dsn == "" {
+			dsn = testDSN
+		}
+		var err error
+		pool, err = db.OpenPostgres(dsn)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(pool.Close)
+	})
+
+	It("should copy the rows and run the batches in a transaction", func() {
+		ctx := context.Background()
+		// A temporary table belongs to its connection, the transaction keeps a single one
+		tx, err := pool.BeginTx(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer tx.Rollback()
+
+		_, err = tx.ExecContext(ctx, `CREATE TEMPORARY TABLE bulk_test(id int, name text) ON COMMIT DROP`)
+		Expect(err).NotTo(HaveOccurred())
+
+		bulk := tx.(db.Bulk)
+		copied, err := bulk.CopyFrom(ctx, "bulk_test", []string{"id", "name"}, [][]interface{}{{1, "Espresso"}, {2, "Mocha"}, {3, "Latte"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(copied).To(BeEquivalentTo(3))
+
+		affected, err := bulk.SendBatch(ctx, []db.Query{
+			{SQL: `UPDATE bulk_test SET name = $1 WHERE id = $2`, Args: []interface{}{"Ristretto", 1}},
+			{SQL: `DELETE FROM bulk_test WHERE id > $1`, Args: []interface{}{1}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(affected).To(Equal([]int64{1, 2}))
+
+		var name string
+		Expect(tx.QueryRowContext(ctx, `SELECT name FROM bulk_test`).Scan(&name)).To(Succeed())
+		Expect(name).To(Equal("Ristretto"))
+		Expect(tx.QueryRowContext(ctx, `SELECT name FROM bulk_test WHERE id = 2`).Scan(&name)).To(MatchError(sql.ErrNoRows))
+
+		Expect(tx.Commit()).To(Succeed())
+		Expect(tx.Rollback()).To(MatchError(sql.ErrTxDone))
+	})
+})
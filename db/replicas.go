@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The replica settings used unless the options set them
+const (
+	defaultMaxReplicaLag     = 10 * time.Second
+	defaultReplicaCheckEvery = 5 * time.Second
+	replicaCheckTimeout      = 2 * time.Second
+)
+
+// replicaLagQuery returns how far behind the primary the replica is, in seconds. A replica that replayed
+// everything it received is up to date, the time of its last transaction only tells how quiet the primary is.
+const replicaLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// Cluster is a primary and its read replicas. It runs everything on the primary like a single pool,
+// only the Executor returned by Reads sends the queries to the replicas: in turn to the ones that are
+// up and not lagging more than MaxLag, to the primary when there is none. A read failing on a replica
+// is run again on the primary and the replica is left out until its next check. A client reading what it
+// just wrote reads through the primary itself, see services.PrimaryCatalog.
+type Cluster struct {
+	Primary  DBInterface
+	Replicas []*Replica
+	MaxLag   time.Duration
+
+	next atomic.Uint64
+	stop context.CancelFunc
+	done sync.WaitGroup
+}
+
+// Replica is a read replica of the cluster and the state of its last check
+type Replica struct {
+	Name string
+	DB   DBInterface
+
+	up  atomic.Bool
+	lag atomic.Int64
+}
+
+var (
+	_ DBInterface = &Cluster{}
+	_ Executor    = replicaReads{}
+)
+
+func NewCluster(primary DBInterface, replicas ...*Replica) *Cluster {
+	return &Cluster{Primary: primary, Replicas: replicas, MaxLag: defaultMaxReplicaLag}
+}
+
+func NewReplica(name string, d DBInterface) *Replica {
+	return &Replica{Name: name, DB: d}
+}
+
+// Up reports whether the replica answered its last check
+func (r *Replica) Up() bool {
+	return r.up.Load()
+}
+
+// Lag is how far behind the primary the replica was at its last check
+func (r *Replica) Lag() time.Duration {
+	return time.Duration(r.lag.Load())
+}
+
+// CheckReplicas measures the lag of every replica
+func (c *Cluster) CheckReplicas(ctx context.Context) {
+	for _, replica := range c.Replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+		var seconds float64
+		err := replica.DB.QueryRowContext(checkCtx, replicaLagQuery).Scan(&seconds)
+		cancel()
+
+		if err != nil {
+			if replica.up.Swap(false) {
+				fmt.Fprintf(os.Stderr, "Replica %s is down: %v\n", replica.Name, err)
+			}
+			continue
+		}
+		replica.lag.Store(int64(seconds * float64(time.Second)))
+		if !replica.up.Swap(true) {
+			fmt.Fprintf(os.Stderr, "Replica %s is up\n", replica.Name)
+		}
+	}
+}
+
+// MonitorReplicas checks the replicas every interval until the cluster is closed
+func (c *Cluster) MonitorReplicas(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stop = cancel
+	c.done.Add(1)
+
+	go func() {
+		defer c.done.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.CheckReplicas(ctx)
+			}
+		}
+	}()
+}
+
+// Reads returns an Executor running the queries on a replica, it is the primary without replicas
+func (c *Cluster) Reads() Executor {
+	return replicaReads{c}
+}
+
+// replica picks the replica of the next read, nil when it has to go to the primary
+func (c *Cluster) replica() *Replica {
+	n := len(c.Replicas)
+	if n == 0 {
+		return nil
+	}
+	start := int(c.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		replica := c.Replicas[(start+i)%n]
+		if replica.Up() && (c.MaxLag <= 0 || replica.Lag() <= c.MaxLag) {
+			return replica
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return c.Primary.QueryContext(ctx, query, args...)
+}
+
+func (c *Cluster) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	return c.Primary.QueryRowContext(ctx, query, args...)
+}
+
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.Primary.ExecContext(ctx, query, args...)
+}
+
+func (c *Cluster) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	return c.Primary.BeginTx(ctx, opts)
+}
+
+func (c *Cluster) SendBatch(ctx context.Context, queries []Query) ([]int64, error) {
+	return c.Primary.SendBatch(ctx, queries)
+}
+
+func (c *Cluster) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	return c.Primary.CopyFrom(ctx, table, columns, rows)
+}
+
+func (c *Cluster) Ping() error {
+	return c.Primary.Ping()
+}
+
+// Close stops the checks and closes the primary and the replicas
+func (c *Cluster) Close() error {
+	if c.stop != nil {
+		c.stop()
+		c.done.Wait()
+	}
+
+	var errs []error
+	for _, replica := range c.Replicas {
+		errs = append(errs, replica.DB.Close())
+	}
+	errs = append(errs, c.Primary.Close())
+	return errors.Join(errs...)
+}
+
+// replicaReads runs the queries on a replica picked for each of them
+type replicaReads struct {
+	cluster *Cluster
+}
+
+func (r replicaReads) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	replica := r.cluster.replica()
+	if replica == nil {
+		return r.cluster.Primary.QueryContext(ctx, query, args...)
+	}
+
+	rows, err := replica.DB.QueryContext(ctx, query, args...)
+	// Only a replica that can't be reached is left out, a failed query would fail on the primary too
+	if IsConnectionError(err) && ctx.Err() == nil {
+		replica.up.Store(false)
+		return r.cluster.Primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (r replicaReads) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	replica := r.cluster.replica()
+	if replica == nil {
+		return r.cluster.Primary.QueryRowContext(ctx, query, args...)
+	}
+	return replicaRow{
+		Row: replica.DB.QueryRowContext(ctx, query, args...),
+		fallback: func(err error) Row {
+			if !IsConnectionError(err) || ctx.Err() != nil {
+				return nil
+			}
+			replica.up.Store(false)
+			return r.cluster.Primary.QueryRowContext(ctx, query, args...)
+		},
+	}
+}
+
+// ExecContext runs on the primary, a write has no place on a replica
+func (r replicaReads) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.cluster.ExecContext(ctx, query, args...)
+}
+
+// replicaRow runs the query again on the primary when the replica can't be reached
+type replicaRow struct {
+	Row
+	fallback func(err error) Row
+}
+
+func (r replicaRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if primary := r.fallback(err); primary != nil {
+		return primary.Scan(dest...)
+	}
+	return err
+}
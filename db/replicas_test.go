@@ -0,0 +1,155 @@
+package db_test
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/mocks"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Replicas", Label("unit"), func() {
+	var (
+		primary, first, second *mocks.DBInterface
+		cluster                *db.Cluster
+	)
+
+	const query = `SELECT name FROM coffees WHERE id = $1`
+
+	// lagged answers the check of the replica with the lag in seconds
+	lagged := func(replica *mocks.DBInterface, seconds float64) {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*float64) = seconds
+		}).Return(nil)
+		replica.On("QueryRowContext", mock.Anything, mock.Anything).Return(row).Once()
+	}
+
+	down := func(replica *mocks.DBInterface) {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(errors.New("connection refused"))
+		replica.On("QueryRowContext", mock.Anything, mock.Anything).Return(row).Once()
+	}
+
+	// answers makes the database answer the query with its name
+	answers := func(d *mocks.DBInterface, name string) {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = name
+		}).Return(nil)
+		d.On("QueryRowContext", mock.Anything, query, "42").Return(row)
+	}
+
+	read := func() string {
+		var name string
+		Expect(cluster.Reads().QueryRowContext(context.Background(), query, "42").Scan(&name)).To(Succeed())
+		return name
+	}
+
+	BeforeEach(func() {
+		primary, first, second = &mocks.DBInterface{}, &mocks.DBInterface{}, &mocks.DBInterface{}
+		cluster = db.NewCluster(primary, db.NewReplica("first", first), db.NewReplica("second", second))
+	})
+
+	It("should spread the reads over the replicas that are up", func() {
+		lagged(first, 0)
+		lagged(second, 0.5)
+		cluster.CheckReplicas(context.Background())
+		Expect(cluster.Replicas[1].Lag()).To(Equal(500 * time.Millisecond))
+
+		answers(first, "first")
+		answers(second, "second")
+		Expect([]string{read(), read(), read(), read()}).To(ConsistOf("first", "second", "first", "second"))
+		primary.AssertNotCalled(GinkgoT(), "QueryRowContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("should read from the primary when the replicas are down or lagging", func() {
+		down(first)
+		lagged(second, 30)
+		cluster.CheckReplicas(context.Background())
+		Expect(cluster.Replicas[0].Up()).To(BeFalse())
+		Expect(cluster.Replicas[1].Up()).To(BeTrue())
+
+		answers(primary, "primary")
+		Expect(read()).To(Equal("primary"))
+
+		cluster.MaxLag = time.Minute
+		answers(second, "second")
+		Expect(read()).To(Equal("second"))
+	})
+
+	It("should run a failed read again on the primary and leave the replica out", func() {
+		lagged(first, 0)
+		down(second)
+		cluster.CheckReplicas(context.Background())
+
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(io.ErrUnexpectedEOF)
+		first.On("QueryRowContext", mock.Anything, query, "42").Return(row).Once()
+		first.On("QueryContext", mock.Anything, query, "42").Return(nil, io.ErrUnexpectedEOF).Once()
+		answers(primary, "primary")
+		primary.On("QueryContext", mock.Anything, query, "42").Return(&mocks.Rows{}, nil).Once()
+
+		Expect(read()).To(Equal("primary"))
+		Expect(cluster.Replicas[0].Up()).To(BeFalse())
+
+		// Back after its check, a failed query falls back the same way
+		lagged(first, 0)
+		down(second)
+		cluster.CheckReplicas(context.Background())
+		_, err := cluster.Reads().QueryContext(context.Background(), query, "42")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Replicas[0].Up()).To(BeFalse())
+		primary.AssertExpectations(GinkgoT())
+	})
+
+	It("should not hide a missing row behind the primary", func() {
+		lagged(first, 0)
+		down(second)
+		cluster.CheckReplicas(context.Background())
+
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(sql.ErrNoRows)
+		first.On("QueryRowContext", mock.Anything, query, "42").Return(row)
+
+		var name string
+		Expect(cluster.Reads().QueryRowContext(context.Background(), query, "42").Scan(&name)).To(MatchError(sql.ErrNoRows))
+		Expect(cluster.Replicas[0].Up()).To(BeTrue())
+	})
+
+	It("should give back the error of a failed query without leaving the replica out", func() {
+		lagged(first, 0)
+		down(second)
+		cluster.CheckReplicas(context.Background())
+
+		failed := errors.New(`column "nope" does not exist`)
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(failed)
+		first.On("QueryRowContext", mock.Anything, query, "42").Return(row)
+		first.On("QueryContext", mock.Anything, query, "42").Return(nil, failed)
+
+		var name string
+		Expect(cluster.Reads().QueryRowContext(context.Background(), query, "42").Scan(&name)).To(MatchError(failed))
+		_, err := cluster.Reads().QueryContext(context.Background(), query, "42")
+		Expect(err).To(MatchError(failed))
+		Expect(cluster.Replicas[0].Up()).To(BeTrue())
+		primary.AssertNotCalled(GinkgoT(), "QueryRowContext", mock.Anything, mock.Anything, mock.Anything)
+		primary.AssertNotCalled(GinkgoT(), "QueryContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("should close the primary and the replicas", func() {
+		primary.On("Close").Return(nil)
+		first.On("Close").Return(errors.New("already closed"))
+		second.On("Close").Return(nil)
+
+		cluster.MonitorReplicas(time.Hour)
+		Expect(cluster.Close()).To(MatchError("already closed"))
+		primary.AssertExpectations(GinkgoT())
+	})
+})
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// InTx returns a Querier running its queries in tx. A transaction begun on it is a savepoint of tx:
+// committing it releases the savepoint and rolling it back undoes only what was done since, so code
+// managing its own transactions can run as a part of a larger one. The options of the nested
+// transactions are ignored, they share the isolation level of tx.
+func InTx(tx Tx) Querier {
+	return &txQuerier{Tx: tx}
+}
+
+type txQuerier struct {
+	Tx
+	savepoints int
+}
+
+func (q *txQuerier) BeginTx(ctx context.Context, _ *sql.TxOptions) (Tx, error) {
+	q.savepoints++
+	name := fmt.Sprintf("sp_%d", q.savepoints)
+	if _, err := q.Tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &savepoint{Tx: q.Tx, name: name}, nil
+}
+
+// savepoint behaves like a *sql.Tx, it can be ended once and ErrTxDone is returned after that
+type savepoint struct {
+	Tx
+	name string
+	done bool
+}
+
+func (s *savepoint) Commit() error {
+	return s.end("RELEASE SAVEPOINT ")
+}
+
+func (s *savepoint) Rollback() error {
+	return s.end("ROLLBACK TO SAVEPOINT ")
+}
+
+func (s *savepoint) end(statement string) error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.Tx.ExecContext(context.Background(), statement+s.name)
+	return err
+}
@@ -0,0 +1,80 @@
+package db_test
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/mocks"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transactions", Label("unit"), func() {
+	var tx *mocks.Tx
+
+	BeforeEach(func() {
+		tx = &mocks.Tx{}
+	})
+
+	AfterEach(func() {
+		tx.AssertExpectations(GinkgoT())
+	})
+
+	It("should begin the nested transactions as savepoints and release them on commit", func() {
+		tx.On("ExecContext", context.Background(), "SAVEPOINT sp_1").Return(nil, nil).Once()
+		tx.On("ExecContext", context.Background(), "RELEASE SAVEPOINT sp_1").Return(nil, nil).Once()
+		tx.On("ExecContext", context.Background(), "SAVEPOINT sp_2").Return(nil, nil).Once()
+		tx.On("ExecContext", context.Background(), "ROLLBACK TO SAVEPOINT sp_2").Return(nil, nil).Once()
+
+		querier := db.InTx(tx)
+
+		first, err := querier.BeginTx(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Commit()).To(Succeed())
+		Expect(first.Rollback()).To(MatchError(sql.ErrTxDone))
+
+		second, err := querier.BeginTx(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second.Rollback()).To(Succeed())
+		Expect(second.Commit()).To(MatchError(sql.ErrTxDone))
+
+		tx.AssertNotCalled(GinkgoT(), "Commit")
+		tx.AssertNotCalled(GinkgoT(), "Rollback")
+	})
+
+	It("should return the error of the savepoint", func() {
+		tx.On("ExecContext", context.Background(), "SAVEPOINT sp_1").Return(nil, errors.New("current transaction is aborted"))
+
+		nested, err := db.InTx(tx).BeginTx(context.Background(), nil)
+		Expect(err).To(MatchError("current transaction is aborted"))
+		Expect(nested).To(BeNil())
+	})
+
+	It("should tell the serialization failures from the other errors", func() {
+		Expect(db.IsSerializationFailure(&pgconn.PgError{Code: db.SerializationFailure})).To(BeTrue())
+		Expect(db.IsSerializationFailure(fmt.Errorf("checkout: %w", &pgconn.PgError{Code: db.DeadlockDetected}))).To(BeTrue())
+		Expect(db.IsSerializationFailure(&pgconn.PgError{Code: "23505"})).To(BeFalse())
+		Expect(db.IsSerializationFailure(sql.ErrNoRows)).To(BeFalse())
+		Expect(db.SQLState(sql.ErrNoRows)).To(BeEmpty())
+	})
+
+	It("should parse the isolation levels", func() {
+		for value, level := range map[string]sql.IsolationLevel{
+			"":                sql.LevelDefault,
+			"SERIALIZABLE":    sql.LevelSerializable,
+			"repeatable_read": sql.LevelRepeatableRead,
+			" Read Committed": sql.LevelReadCommitted,
+		} {
+			parsed, err := db.ParseIsolation(value)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(level), value)
+		}
+
+		_, err := db.ParseIsolation("snapshot")
+		Expect(err).To(MatchError(`unknown isolation level "snapshot"`))
+	})
+})
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+)
+
+// Trace describes an operation run on the pool
+type Trace struct {
+	// Operation is Query, Exec, SendBatch or CopyFrom
+	Operation string
+	// SQL is the statement, or the table of a copy, it is empty for a batch
+	SQL      string
+	Duration time.Duration
+	// Rows is how many rows were read or written, when it is known
+	Rows int64
+	Err  error
+}
+
+// Tracer is told about the operations run on the pool, e.g. to log the slow queries or record spans.
+// It is called on the goroutine running the query, once its rows are read.
+type Tracer interface {
+	Trace(ctx context.Context, trace Trace)
+}
+
+// TracerFunc turns a function into a Tracer
+type TracerFunc func(ctx context.Context, trace Trace)
+
+func (f TracerFunc) Trace(ctx context.Context, trace Trace) {
+	f(ctx, trace)
+}
+
+// SlowQueryLog logs the operations taking longer than the threshold, and the failed ones
+func SlowQueryLog(logger *log.Logger, threshold time.Duration) Tracer {
+	return TracerFunc(func(ctx context.Context, trace Trace) {
+		switch {
+		case trace.Err != nil:
+			logger.Printf("%s failed after %s: %v: %s", trace.Operation, trace.Duration, trace.Err, trace.SQL)
+		case trace.Duration >= threshold:
+			logger.Printf("Slow %s took %s: %s", trace.Operation, trace.Duration, trace.SQL)
+		}
+	})
+}
+
+var tracedOperations = map[string]bool{"Query": true, "Exec": true, "SendBatch": true, "CopyFrom": true}
+
+// traceLogger turns the logs pgx writes after every operation into traces
+type traceLogger struct {
+	tracer Tracer
+}
+
+func (l traceLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	if !tracedOperations[msg] {
+		return
+	}
+
+	trace := Trace{Operation: msg}
+	trace.SQL, _ = data["sql"].(string)
+	if table, ok := data["tableName"].(pgx.Identifier); ok {
+		trace.SQL = table.Sanitize()
+	}
+	trace.Duration, _ = data["time"].(time.Duration)
+	trace.Err, _ = data["err"].(error)
+	switch rows := data["rowCount"].(type) {
+	case int:
+		trace.Rows = int64(rows)
+	case int64:
+		trace.Rows = rows
+	}
+	if tag, ok := data["commandTag"].(pgconn.CommandTag); ok {
+		trace.Rows = tag.RowsAffected()
+	}
+	l.tracer.Trace(ctx, trace)
+}
+
+func statementCache(capacity int) pgx.BuildStatementCacheFunc {
+	return func(conn *pgconn.PgConn) stmtcache.Cache {
+		return stmtcache.New(conn, stmtcache.ModePrepare, capacity)
+	}
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event is a domain event read from the outbox
+type Event struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Sink receives the events published by the outbox relay. An event is published at least once,
+// so sinks have to tolerate duplicates; the event ID can be used to drop them.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
+
+// MemoryBus fans events out to in-process subscribers
+type MemoryBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	buffer      int
+}
+
+func NewMemoryBus(buffer int) *MemoryBus {
+	return &MemoryBus{subscribers: map[chan Event]struct{}{}, buffer: buffer}
+}
+
+func (b *MemoryBus) Name() string {
+	return "memory"
+}
+
+// Publish hands the event to every subscriber. A subscriber that is not keeping up is dropped, its channel
+// is closed instead of blocking the relay, so it can catch up from the outbox and subscribe again.
+func (b *MemoryBus) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the published events and a function to stop the subscription
+func (b *MemoryBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, b.buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// NATSPublisher is the part of a NATS connection the sink needs; *nats.Conn satisfies it
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSSink publishes every event as JSON on "<prefix>.<event type>", e.g. "catalog.coffee.created"
+type NATSSink struct {
+	Conn          NATSPublisher
+	SubjectPrefix string
+}
+
+func NewNATSSink(conn NATSPublisher, subjectPrefix string) *NATSSink {
+	return &NATSSink{Conn: conn, SubjectPrefix: subjectPrefix}
+}
+
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.Conn.Publish(s.Subject(event), data)
+}
+
+func (s *NATSSink) Subject(event Event) string {
+	if s.SubjectPrefix == "" {
+		return event.Type
+	}
+	return s.SubjectPrefix + "." + event.Type
+}
@@ -0,0 +1,69 @@
+package events_test
+
+import (
+	"coffee/coffee-server/events"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingConn struct {
+	subjects []string
+	data     [][]byte
+}
+
+func (c *recordingConn) Publish(subject string, data []byte) error {
+	c.subjects = append(c.subjects, subject)
+	c.data = append(c.data, data)
+	return nil
+}
+
+var _ = Describe("Events", Label("unit"), func() {
+	event := events.Event{ID: 1, AggregateType: "coffee", AggregateID: "c1", Type: "coffee.created", Payload: json.RawMessage(`{"id":"c1"}`)}
+
+	Describe("MemoryBus", func() {
+		It("should fan events out to every subscriber", func() {
+			bus := events.NewMemoryBus(1)
+			first, stopFirst := bus.Subscribe()
+			second, stopSecond := bus.Subscribe()
+			defer stopSecond()
+
+			Expect(bus.Publish(context.Background(), event)).To(Succeed())
+			Expect(<-first).To(Equal(event))
+			Expect(<-second).To(Equal(event))
+
+			stopFirst()
+			Expect(bus.Publish(context.Background(), event)).To(Succeed())
+			Eventually(first).Should(BeClosed())
+		})
+
+		It("should drop a subscriber that is not keeping up instead of blocking", func() {
+			bus := events.NewMemoryBus(1)
+			slow, stop := bus.Subscribe()
+			defer stop()
+
+			Expect(bus.Publish(context.Background(), event)).To(Succeed())
+			Expect(bus.Publish(context.Background(), event)).To(Succeed())
+
+			Expect(<-slow).To(Equal(event))
+			Eventually(slow).Should(BeClosed())
+		})
+	})
+
+	Describe("NATSSink", func() {
+		It("should publish the event as JSON on its subject", func() {
+			conn := &recordingConn{}
+			sink := events.NewNATSSink(conn, "catalog")
+
+			Expect(sink.Publish(context.Background(), event)).To(Succeed())
+			Expect(conn.subjects).To(Equal([]string{"catalog.coffee.created"}))
+
+			var published events.Event
+			Expect(json.Unmarshal(conn.data[0], &published)).To(Succeed())
+			Expect(published.AggregateID).To(Equal("c1"))
+			Expect(string(published.Payload)).To(Equal(`{"id":"c1"}`))
+		})
+	})
+})
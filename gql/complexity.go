@@ -0,0 +1,220 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Limits bounds how expensive a single query can be
+type Limits struct {
+	// MaxDepth is how deeply selections can be nested
+	MaxDepth int
+	// MaxComplexity is the highest cost of a query: every field costs one, and the fields below a list
+	// count once per item it can return
+	MaxComplexity int
+}
+
+var DefaultLimits = Limits{MaxDepth: 6, MaxComplexity: 1000}
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Execute validates the request, rejects it when it goes over the limits and runs it otherwise.
+// Only queries are allowed when mutations is false, e.g. for GET requests.
+func Execute(ctx context.Context, schema graphql.Schema, limits Limits, req Request, mutations bool) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+
+	validation := graphql.ValidateDocument(&schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	operation := findOperation(doc, req.OperationName)
+	if operation == nil {
+		return errorResult("unknown operation %q", req.OperationName)
+	}
+	if operation.Operation == ast.OperationTypeMutation && !mutations {
+		return errorResult("mutations are only allowed in POST requests")
+	}
+
+	cost, depth, err := measure(schema, doc, operation, req.Variables)
+	if err != nil {
+		return errorResult("%s", err)
+	}
+	if depth > limits.MaxDepth {
+		return errorResult("query is nested %d levels deep, the limit is %d", depth, limits.MaxDepth)
+	}
+	if cost > limits.MaxComplexity {
+		return errorResult("query has a complexity of %d, the limit is %d", cost, limits.MaxComplexity)
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+// measure returns the cost and the depth of the operation, an error for a page it can't be measured by
+func measure(schema graphql.Schema, doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) (int, int, error) {
+	analysis := &analysis{schema: schema, fragments: fragments(doc), variables: variables}
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	cost, depth := analysis.cost(root, operation.SelectionSet, 1)
+	return cost, depth, analysis.err
+}
+
+func errorResult(format string, args ...interface{}) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(fmt.Sprintf(format, args...))}}
+}
+
+// findOperation returns the named operation, or the first one without a name; a document holding
+// several operations without an operationName is then refused by the execution
+func findOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" || (operation.Name != nil && operation.Name.Value == name) {
+			return operation
+		}
+	}
+	return nil
+}
+
+func fragments(doc *ast.Document) map[string]*ast.FragmentDefinition {
+	all := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			all[fragment.Name.Value] = fragment
+		}
+	}
+	return all
+}
+
+type analysis struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// err is the first negative page met
+	err error
+}
+
+// cost adds up the fields of the selection set on the given type and returns it with the deepest level reached
+func (a *analysis) cost(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return 0, depth - 1
+	}
+
+	total, deepest := 0, depth
+	for _, selection := range set.Selections {
+		var cost, reached int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			cost, reached = a.fieldCost(parent, selection, depth)
+		case *ast.InlineFragment:
+			cost, reached = a.cost(a.fragmentType(parent, selection.TypeCondition), selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			fragment, ok := a.fragments[selection.Name.Value]
+			if !ok {
+				continue
+			}
+			cost, reached = a.cost(a.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet, depth)
+		}
+
+		total += cost
+		deepest = max(deepest, reached)
+	}
+	return total, deepest
+}
+
+func (a *analysis) fieldCost(parent *graphql.Object, field *ast.Field, depth int) (int, int) {
+	// Introspection, e.g. from GraphiQL, is cheap and deeply nested by nature
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, depth
+	}
+
+	var definition *graphql.FieldDefinition
+	if parent != nil {
+		definition = parent.Fields()[field.Name.Value]
+	}
+
+	var child *graphql.Object
+	multiplier := 1
+	if definition != nil {
+		fieldType := definition.Type
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+		}
+		if list, ok := fieldType.(*graphql.List); ok {
+			multiplier = a.pageSize(field)
+			fieldType = list.OfType
+			if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+				fieldType = nonNull.OfType
+			}
+		}
+		child, _ = fieldType.(*graphql.Object)
+	}
+
+	childCost, reached := a.cost(child, field.SelectionSet, depth+1)
+	return 1 + multiplier*childCost, reached
+}
+
+// pageSize is how many items a list field can return, going by its first argument. The resolvers return
+// defaultListSize items without one, a negative one is an error.
+func (a *analysis) pageSize(field *ast.Field) int {
+	first := defaultListSize
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				first = n
+			}
+		case *ast.Variable:
+			switch n := a.variables[value.Name.Value].(type) {
+			case float64:
+				first = int(n)
+			case int:
+				first = n
+			}
+		}
+	}
+	if first < 0 {
+		if a.err == nil {
+			a.err = ErrNegativePage
+		}
+		return 0
+	}
+	return first
+}
+
+func (a *analysis) fragmentType(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := a.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
@@ -0,0 +1,170 @@
+package gql_test
+
+import (
+	"coffee/coffee-server/gql"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GraphQL", Label("unit"), func() {
+	var (
+		mockedCoffee *mocks.CoffeeService
+		schema       graphql.Schema
+	)
+
+	coffees := []*services.Coffee{
+		{ID: "c1", Name: "Espresso", Roast: "Dark", Region: "Brazil", Price: 10, GrindUnit: 1},
+		{ID: "c2", Name: "Ethiopian Espresso", Roast: "Light", Region: "Ethiopia", Price: 14, GrindUnit: 2},
+		{ID: "c3", Name: "Mocha", Roast: "Dark", Region: "Yemen", Price: 18, GrindUnit: 1},
+	}
+
+	execute := func(query string, variables map[string]interface{}) *graphql.Result {
+		return gql.Execute(context.Background(), schema, gql.DefaultLimits, gql.Request{Query: query, Variables: variables}, true)
+	}
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+
+		var err error
+		schema, err = gql.NewSchema(mockedCoffee)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("coffees", func() {
+		It("should only return the requested fields of the matching coffees", func() {
+			mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+
+			result := execute(`{ coffees(name: "espresso", roast: "dark") { id name } }`, nil)
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data).To(Equal(map[string]interface{}{
+				"coffees": []interface{}{map[string]interface{}{"id": "c1", "name": "Espresso"}},
+			}))
+		})
+
+		It("should filter by price and page through the results", func() {
+			mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+
+			result := execute(`query($min: Float) { coffees(minPrice: $min, first: 1, offset: 1) { id } }`, map[string]interface{}{"min": 12})
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data).To(Equal(map[string]interface{}{
+				"coffees": []interface{}{map[string]interface{}{"id": "c3"}},
+			}))
+		})
+	})
+
+	Describe("coffee", func() {
+		It("should return the error of the service", func() {
+			mockedCoffee.On("GetCoffeesById", "c9").Return(nil, errors.New("coffee not found"))
+
+			result := execute(`{ coffee(id: "c9") { id } }`, nil)
+			Expect(result.Errors).To(HaveLen(1))
+			Expect(result.Errors[0].Message).To(Equal("coffee not found"))
+		})
+	})
+
+	Describe("mutations", func() {
+		It("should create a coffee from the input", func() {
+			input := services.Coffee{Name: "Latte", Roast: "Light", Region: "Colombia", Price: 12.5, GrindUnit: 2}
+			created := input
+			created.ID = "c4"
+			mockedCoffee.On("CreateCoffee", input).Return(&created, nil)
+
+			result := execute(`mutation { createCoffee(input: {name: "Latte", roast: "Light", region: "Colombia", price: 12.5, grindUnit: 2}) { id price } }`, nil)
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data).To(Equal(map[string]interface{}{
+				"createCoffee": map[string]interface{}{"id": "c4", "price": float32(12.5)},
+			}))
+		})
+
+		It("should return the id of the deleted coffee", func() {
+			mockedCoffee.On("DeleteCoffee", "c1").Return(nil)
+
+			result := execute(`mutation { deleteCoffee(id: "c1") }`, nil)
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data).To(Equal(map[string]interface{}{"deleteCoffee": "c1"}))
+		})
+
+		It("should refuse mutations when they are not allowed", func() {
+			result := gql.Execute(context.Background(), schema, gql.DefaultLimits, gql.Request{Query: `mutation { deleteCoffee(id: "c1") }`}, false)
+			Expect(result.Errors).To(HaveLen(1))
+			mockedCoffee.AssertNotCalled(GinkgoT(), "DeleteCoffee", "c1")
+		})
+	})
+
+	Describe("limits", func() {
+		It("should refuse a query over the complexity limit before running it", func() {
+			limits := gql.Limits{MaxDepth: 6, MaxComplexity: 50}
+
+			// 1 + 100 coffees * 2 fields
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ coffees(first: 100) { id name } }`}, true)
+			Expect(result.Errors).To(HaveLen(1))
+			Expect(result.Errors[0].Message).To(Equal("query has a complexity of 201, the limit is 50"))
+			mockedCoffee.AssertNotCalled(GinkgoT(), "GetAllCoffees")
+		})
+
+		It("should count the fields of fragments", func() {
+			limits := gql.Limits{MaxDepth: 6, MaxComplexity: 50}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{
+				Query: `query { coffees { ...fields } } fragment fields on Coffee { id name roast }`,
+			}, true)
+			Expect(result.Errors[0].Message).To(Equal("query has a complexity of 61, the limit is 50"))
+		})
+
+		It("should refuse a query nested too deeply", func() {
+			limits := gql.Limits{MaxDepth: 1, MaxComplexity: 1000}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ coffee(id: "c1") { id } }`}, true)
+			Expect(result.Errors[0].Message).To(Equal("query is nested 2 levels deep, the limit is 1"))
+		})
+
+		It("should not count introspection", func() {
+			limits := gql.Limits{MaxDepth: 2, MaxComplexity: 5}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ __schema { types { name fields { name type { name ofType { name } } } } } }`}, true)
+			Expect(result.Errors).To(BeEmpty())
+		})
+
+		It("should refuse a page larger than 100", func() {
+			mockedCoffee.On("GetAllCoffees").Return(coffees, nil)
+			limits := gql.Limits{MaxDepth: 6, MaxComplexity: 1000}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ coffees(first: 101) { id } }`}, true)
+			Expect(result.Errors[0].Message).To(Equal(gql.ErrPageTooLarge.Error()))
+		})
+
+		It("should refuse a negative page before running the query", func() {
+			limits := gql.Limits{MaxDepth: 6, MaxComplexity: 1000}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ coffees(first: -1) { id } }`}, true)
+			Expect(result.Errors[0].Message).To(Equal(gql.ErrNegativePage.Error()))
+
+			result = gql.Execute(context.Background(), schema, limits, gql.Request{
+				Query:     `query($first: Int) { coffees(first: $first) { id } }`,
+				Variables: map[string]interface{}{"first": -5},
+			}, true)
+			Expect(result.Errors[0].Message).To(Equal(gql.ErrNegativePage.Error()))
+			mockedCoffee.AssertNotCalled(GinkgoT(), "GetAllCoffees")
+		})
+
+		It("should return the default page when first is omitted, as it is costed", func() {
+			many := make([]*services.Coffee, 30)
+			for i := range many {
+				many[i] = &services.Coffee{ID: fmt.Sprintf("c%d", i), Name: "Espresso"}
+			}
+			mockedCoffee.On("GetAllCoffees").Return(many, nil)
+			limits := gql.Limits{MaxDepth: 6, MaxComplexity: 1000}
+
+			result := gql.Execute(context.Background(), schema, limits, gql.Request{Query: `{ coffees { id } }`}, true)
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data.(map[string]interface{})["coffees"]).To(HaveLen(20))
+		})
+	})
+})
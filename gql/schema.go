@@ -0,0 +1,218 @@
+package gql
+
+import (
+	"coffee/coffee-server/services"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultListSize is the page a list without a first argument returns
+	defaultListSize = 20
+	// maxListSize is the largest page a list can return
+	maxListSize = 100
+)
+
+var (
+	ErrPageTooLarge = errors.New("first can't be larger than 100")
+	ErrNegativePage = errors.New("first can't be negative")
+)
+
+var coffeeType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Coffee",
+	Description: "A coffee of the catalog",
+	Fields: graphql.Fields{
+		"id":        coffeeField(graphql.NewNonNull(graphql.ID), func(c *services.Coffee) interface{} { return c.ID }),
+		"slug":      coffeeField(graphql.String, func(c *services.Coffee) interface{} { return c.Slug }),
+		"name":      coffeeField(graphql.NewNonNull(graphql.String), func(c *services.Coffee) interface{} { return c.Name }),
+		"roast":     coffeeField(graphql.NewNonNull(graphql.String), func(c *services.Coffee) interface{} { return c.Roast }),
+		"image":     coffeeField(graphql.NewNonNull(graphql.String), func(c *services.Coffee) interface{} { return c.Image }),
+		"region":    coffeeField(graphql.NewNonNull(graphql.String), func(c *services.Coffee) interface{} { return c.Region }),
+		"price":     coffeeField(graphql.NewNonNull(graphql.Float), func(c *services.Coffee) interface{} { return c.Price }),
+		"grindUnit": coffeeField(graphql.NewNonNull(graphql.Int), func(c *services.Coffee) interface{} { return c.GrindUnit }),
+		"createdAt": coffeeField(graphql.DateTime, func(c *services.Coffee) interface{} { return timeOrNil(c.CreatedAt) }),
+		"updatedAt": coffeeField(graphql.DateTime, func(c *services.Coffee) interface{} { return timeOrNil(c.UpdatedAt) }),
+	},
+})
+
+var coffeeInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CoffeeInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"roast":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"image":     &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		"region":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"price":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+		"grindUnit": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+func coffeeField(fieldType graphql.Output, value func(c *services.Coffee) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			coffee, ok := p.Source.(*services.Coffee)
+			if !ok {
+				return nil, nil
+			}
+			return value(coffee), nil
+		},
+	}
+}
+
+// timeOrNil leaves out the timestamps that were never set, e.g. on a coffee returned by an update
+func timeOrNil(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// NewSchema builds the GraphQL schema of the catalog, resolved through the coffee service
+func NewSchema(coffees services.CoffeeService) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"coffees": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(coffeeType))),
+				Description: "Coffees of the catalog matching all the given filters",
+				Args: graphql.FieldConfigArgument{
+					"name":     &graphql.ArgumentConfig{Type: graphql.String, Description: "Part of the name, case insensitive"},
+					"roast":    &graphql.ArgumentConfig{Type: graphql.String},
+					"region":   &graphql.ArgumentConfig{Type: graphql.String},
+					"minPrice": &graphql.ArgumentConfig{Type: graphql.Float},
+					"maxPrice": &graphql.ArgumentConfig{Type: graphql.Float},
+					"first":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListSize, Description: "At most 100, 20 when omitted"},
+					"offset":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					all, err := services.CatalogFromContext(p.Context, coffees).GetAllCoffees()
+					if err != nil {
+						return nil, err
+					}
+					return filterCoffees(all, p.Args)
+				},
+			},
+			"coffee": &graphql.Field{
+				Type: coffeeType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID), Description: "The id or the slug of the coffee, a former slug finds it too"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return services.CatalogFromContext(p.Context, coffees).GetCoffeesById(p.Args["id"].(string))
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createCoffee": &graphql.Field{
+				Type: graphql.NewNonNull(coffeeType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(coffeeInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return services.CatalogFromContext(p.Context, coffees).CreateCoffee(coffeeFromInput(p.Args["input"]))
+				},
+			},
+			"updateCoffee": &graphql.Field{
+				Type: graphql.NewNonNull(coffeeType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(coffeeInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					updated, err := services.CatalogFromContext(p.Context, coffees).UpdateCoffee(id, coffeeFromInput(p.Args["input"]))
+					if err != nil {
+						return nil, err
+					}
+					updated.ID = id
+					return updated, nil
+				},
+			},
+			"deleteCoffee": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Deletes the coffee and returns its id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := services.CatalogFromContext(p.Context, coffees).DeleteCoffee(id); err != nil {
+						return nil, err
+					}
+					return id, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func coffeeFromInput(input interface{}) services.Coffee {
+	fields, _ := input.(map[string]interface{})
+
+	coffee := services.Coffee{}
+	coffee.Name, _ = fields["name"].(string)
+	coffee.Roast, _ = fields["roast"].(string)
+	coffee.Image, _ = fields["image"].(string)
+	coffee.Region, _ = fields["region"].(string)
+	if price, ok := fields["price"].(float64); ok {
+		coffee.Price = float32(price)
+	}
+	if grindUnit, ok := fields["grindUnit"].(int); ok {
+		coffee.GrindUnit = int16(grindUnit)
+	}
+	return coffee
+}
+
+func filterCoffees(all []*services.Coffee, args map[string]interface{}) ([]*services.Coffee, error) {
+	name, _ := args["name"].(string)
+	roast, _ := args["roast"].(string)
+	region, _ := args["region"].(string)
+	minPrice, hasMin := args["minPrice"].(float64)
+	maxPrice, hasMax := args["maxPrice"].(float64)
+
+	filtered := []*services.Coffee{}
+	for _, coffee := range all {
+		switch {
+		case name != "" && !strings.Contains(strings.ToLower(coffee.Name), strings.ToLower(name)):
+		case roast != "" && !strings.EqualFold(coffee.Roast, roast):
+		case region != "" && !strings.EqualFold(coffee.Region, region):
+		case hasMin && float64(coffee.Price) < minPrice:
+		case hasMax && float64(coffee.Price) > maxPrice:
+		default:
+			filtered = append(filtered, coffee)
+		}
+	}
+
+	offset, _ := args["offset"].(int)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(filtered) {
+		offset = len(filtered)
+	}
+	filtered = filtered[offset:]
+
+	first, ok := args["first"].(int)
+	if !ok {
+		first = defaultListSize
+	}
+	switch {
+	case first < 0:
+		return nil, ErrNegativePage
+	case first > maxListSize:
+		return nil, ErrPageTooLarge
+	case first < len(filtered):
+		filtered = filtered[:first]
+	}
+	return filtered, nil
+}
@@ -8,6 +8,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Envelop map[string]interface{}
@@ -73,5 +76,53 @@ func ErrorJson(w http.ResponseWriter, err error, status ...int) {
 	var payLoad services.JsonResponse
 	payLoad.Error = true
 	payLoad.Message = err.Error()
+
+	// Typed errors can carry extra detail for the client in the data field
+	var detailed interface{ ErrorData() interface{} }
+	if errors.As(err, &detailed) {
+		payLoad.Data = detailed.ErrorData()
+	}
 	WriteJson(w, statusCode, payLoad)
 }
+
+// RequestLanguages returns the languages the client asked for, most preferred first: those of the lang
+// query parameter, then those of the Accept-Language header by their quality
+func RequestLanguages(r *http.Request) []string {
+	var langs []string
+	for _, lang := range strings.Split(r.URL.Query().Get("lang"), ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+
+	type accepted struct {
+		lang    string
+		quality float64
+	}
+	var header []accepted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang, params, _ := strings.Cut(part, ";")
+		lang = strings.TrimSpace(lang)
+		// Any language is what the fallbacks give anyway
+		if lang == "" || lang == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			header = append(header, accepted{lang, quality})
+		}
+	}
+	sort.SliceStable(header, func(i, j int) bool { return header[i].quality > header[j].quality })
+
+	for _, a := range header {
+		langs = append(langs, a.lang)
+	}
+	return langs
+}
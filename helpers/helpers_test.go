@@ -120,6 +120,35 @@ var _ = Describe("Helpers", Label("unit"), func() {
 				Expect(response.Error).To(BeTrue())
 				Expect(response.Message).To(Equal("not found"))
 			})
+
+			It("should include the error data of typed errors", func() {
+				err := services.CheckTransition(services.OrderStatusShipped, services.OrderStatusPaid)
+				helpers.ErrorJson(w, err, http.StatusConflict)
+
+				Expect(w.Code).To(Equal(http.StatusConflict))
+
+				var response services.JsonResponse
+				err = json.NewDecoder(w.Body).Decode(&response)
+
+				Expect(err).To(BeNil())
+				Expect(response.Data).To(HaveKeyWithValue("from", "shipped"))
+				Expect(response.Data).To(HaveKeyWithValue("to", "paid"))
+			})
+		})
+	})
+
+	Describe("RequestLanguages", func() {
+		It("should put the lang parameter first, then the accepted languages by quality", func() {
+			request = httptest.NewRequest(http.MethodGet, "/?lang=nl-BE", nil)
+			request.Header.Set("Accept-Language", "de;q=0.5, en-GB, fr;q=0, *;q=0.1, nl;q=0.8")
+
+			Expect(helpers.RequestLanguages(request)).To(Equal([]string{"nl-BE", "en-GB", "nl", "de"}))
+		})
+
+		It("should return no languages when the client names none", func() {
+			request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			Expect(helpers.RequestLanguages(request)).To(BeEmpty())
 		})
 	})
 })
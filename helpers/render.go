@@ -0,0 +1,474 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// The media types Render answers with
+const (
+	MediaJSON    = "application/json"
+	MediaXML     = "application/xml"
+	MediaCSV     = "text/csv"
+	MediaMsgPack = "application/msgpack"
+)
+
+// MediaTypes are the media types Render answers with, in the order a wildcard picks them
+var MediaTypes = []string{MediaJSON, MediaXML, MediaCSV, MediaMsgPack}
+
+// mediaAliases are the other names the clients give the media types
+var mediaAliases = map[string]string{
+	"text/xml":                MediaXML,
+	"application/x-msgpack":   MediaMsgPack,
+	"application/vnd.msgpack": MediaMsgPack,
+}
+
+var ErrNotAcceptable = errors.New("none of the accepted media types can be served")
+
+// NotAcceptableError lists the media types the client could have asked for. It matches ErrNotAcceptable.
+type NotAcceptableError struct {
+	Available []string
+}
+
+func (e *NotAcceptableError) Error() string {
+	return ErrNotAcceptable.Error()
+}
+
+func (e *NotAcceptableError) Is(target error) bool {
+	return target == ErrNotAcceptable
+}
+
+func (e *NotAcceptableError) ErrorData() interface{} {
+	return map[string][]string{"available": e.Available}
+}
+
+// Negotiation is the representation picked for the responses to a request
+type Negotiation struct {
+	MediaType string
+	Pretty    bool
+	// Fallback is the best accepted type other than CSV, answered for the payloads that aren't a list.
+	// It is empty when only CSV is accepted.
+	Fallback       string
+	FallbackPretty bool
+}
+
+type negotiationKey struct{}
+
+// NegotiationFromContext returns the representation Negotiate picked for the request
+func NegotiationFromContext(ctx context.Context) (Negotiation, bool) {
+	choice, ok := ctx.Value(negotiationKey{}).(Negotiation)
+	return choice, ok
+}
+
+// Negotiate picks the representation of the responses from the Accept header before the handler runs, and
+// keeps it in the request context for Render. A request accepting none of the media types gets a 406 without
+// reaching the handler, so nothing is changed for a response the client can't read. So does a request other
+// than a read accepting CSV only, it is answered a single resource which can't be one.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		choice, ok := negotiate(r.Header.Get("Accept"))
+		if !ok || (choice.Fallback == "" && r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			ErrorJson(w, &NotAcceptableError{Available: MediaTypes}, http.StatusNotAcceptable)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), negotiationKey{}, choice)))
+	})
+}
+
+// Render writes the payload in the representation Negotiate picked from the Accept header of the request:
+// JSON, indented when the pretty parameter asks for it or when any type will do, XML, CSV for the payloads
+// holding a single list, or MessagePack. They are all made from the JSON of the payload, the fields keep
+// their names. A handler served without Negotiate negotiates here. A read accepting CSV only gets a 406
+// for a payload that isn't a list, listing the available types in JSON like every error.
+func Render(w http.ResponseWriter, r *http.Request, status int, data interface{}, headers ...http.Header) error {
+	choice, ok := NegotiationFromContext(r.Context())
+	if !ok {
+		w.Header().Add("Vary", "Accept")
+		choice, ok = negotiate(r.Header.Get("Accept"))
+	}
+
+	var tree interface{}
+	var err error
+	mediaType, pretty := choice.MediaType, choice.Pretty
+	if ok && mediaType == MediaCSV {
+		if tree, err = toTree(data); err != nil {
+			return err
+		}
+		if !isList(tree) {
+			mediaType, pretty = choice.Fallback, choice.FallbackPretty
+		}
+	}
+	if !ok || mediaType == "" {
+		ErrorJson(w, &NotAcceptableError{Available: MediaTypes}, http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+	if mediaType == MediaJSON && pretty {
+		return WriteJson(w, status, data, headers...)
+	}
+
+	var out []byte
+	if mediaType == MediaJSON {
+		out, err = json.Marshal(data)
+	} else if tree == nil {
+		tree, err = toTree(data)
+	}
+	if err == nil {
+		switch mediaType {
+		case MediaXML:
+			out, err = renderXml(tree, pretty)
+		case MediaCSV:
+			out, err = renderCsv(tree)
+		case MediaMsgPack:
+			out, err = renderMsgPack(tree)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+	contentType := mediaType
+	if mediaType == MediaXML || mediaType == MediaCSV {
+		contentType += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	return err
+}
+
+type mediaRange struct {
+	mediaType string
+	pretty    bool
+	quality   float64
+}
+
+// negotiate picks the media type of the responses from the Accept header, ok is false when none of the
+// accepted ones can be served
+func negotiate(accept string) (choice Negotiation, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return Negotiation{MediaType: MediaJSON, Pretty: true, Fallback: MediaJSON, FallbackPretty: true}, true
+	}
+
+	var ranges []mediaRange
+	refused := map[string]bool{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if alias, ok := mediaAliases[mediaType]; ok {
+			mediaType = alias
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		// A type given q=0 isn't acceptable, even through a wildcard
+		if quality <= 0 {
+			refused[mediaType] = true
+			continue
+		}
+		value, set := params["pretty"]
+		ranges = append(ranges, mediaRange{
+			mediaType: mediaType,
+			// Any type will do is what the clients reading the responses themselves send, e.g. curl
+			pretty:  (set && value != "false" && value != "0") || (!set && mediaType == "*/*"),
+			quality: quality,
+		})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, accepted := range ranges {
+		for _, mediaType := range MediaTypes {
+			if !matches(accepted.mediaType, mediaType) || refused[mediaType] {
+				continue
+			}
+			if choice.MediaType == "" {
+				choice.MediaType, choice.Pretty = mediaType, accepted.pretty
+			}
+			if mediaType != MediaCSV {
+				choice.Fallback, choice.FallbackPretty = mediaType, accepted.pretty
+				return choice, true
+			}
+		}
+	}
+	return choice, choice.MediaType != ""
+}
+
+func matches(accepted string, mediaType string) bool {
+	if accepted == "*/*" || accepted == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(accepted, "*")
+	return ok && strings.HasPrefix(mediaType, prefix)
+}
+
+// object is a JSON object with the order of its keys kept, the fields of a struct stay in their order
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// toTree returns the JSON of the payload decoded into objects, lists, strings, json.Numbers, bools and nils
+func toTree(data interface{}) (interface{}, error) {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	return decodeTree(dec)
+}
+
+func decodeTree(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := &object{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key.(string))
+			obj.values[key.(string)] = value
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	default:
+		return token, nil
+	}
+}
+
+// isList tells whether the payload is an envelope holding a single list of objects, e.g. {"coffees": [...]}
+func isList(tree interface{}) bool {
+	obj, ok := tree.(*object)
+	if !ok || len(obj.keys) != 1 {
+		return false
+	}
+	switch items := obj.values[obj.keys[0]].(type) {
+	case nil:
+		return true
+	case []interface{}:
+		for _, item := range items {
+			if _, ok := item.(*object); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// renderCsv writes a row per item of the list, the columns are the fields of the items in the order
+// they first show up. A nested object or list is written as its JSON.
+func renderCsv(tree interface{}) ([]byte, error) {
+	envelope := tree.(*object)
+	items, _ := envelope.values[envelope.keys[0]].([]interface{})
+
+	var columns []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		for _, key := range item.(*object).keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	if len(columns) > 0 {
+		if err := out.Write(columns); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range items {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			cell, err := scalar(item.(*object).values[column])
+			if err != nil {
+				return nil, err
+			}
+			record[i] = cell
+		}
+		if err := out.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	out.Flush()
+	return buf.Bytes(), out.Error()
+}
+
+func scalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		out, err := json.Marshal(v)
+		return string(out), err
+	}
+}
+
+// xmlName is what an element can be named, the other keys, e.g. ids, are written as entries
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// renderXml writes the payload under a response element, an object as an element per field and a list as
+// an item element per value
+func renderXml(tree interface{}, pretty bool) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	if pretty {
+		enc.Indent("", "\t")
+	}
+	if err := writeXml(enc, "response", tree); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXml(enc *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+
+	switch v := value.(type) {
+	case *object:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range v.keys {
+			if err := writeXml(enc, key, v.values[key]); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []interface{}:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeXml(enc, "item", item); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		text, err := scalar(v)
+		if err != nil {
+			return err
+		}
+		return enc.EncodeElement(text, start)
+	}
+}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	// The str8 and bin types of the current spec, and the same bytes for the same payload
+	h.WriteExt = true
+	h.Canonical = true
+	return h
+}()
+
+// renderMsgPack writes the payload as MessagePack, the numbers as integers when they are whole
+func renderMsgPack(tree interface{}) ([]byte, error) {
+	var out []byte
+	err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(plain(tree))
+	return out, err
+}
+
+func plain(tree interface{}) interface{} {
+	switch v := tree.(type) {
+	case *object:
+		values := make(map[string]interface{}, len(v.keys))
+		for _, key := range v.keys {
+			values[key] = plain(v.values[key])
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = plain(item)
+		}
+		return values
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -0,0 +1,164 @@
+package helpers_test
+
+import (
+	"coffee/coffee-server/helpers"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ugorji/go/codec"
+)
+
+var _ = Describe("Render", Label("unit"), func() {
+	type item struct {
+		ID    string    `json:"id"`
+		Name  string    `json:"name"`
+		Price float32   `json:"price"`
+		Tags  []string  `json:"tags,omitempty"`
+		At    time.Time `json:"at"`
+	}
+
+	var (
+		w       *httptest.ResponseRecorder
+		request *http.Request
+		list    helpers.Envelop
+	)
+
+	BeforeEach(func() {
+		w = httptest.NewRecorder()
+		request = httptest.NewRequest(http.MethodGet, "/", nil)
+		at := time.Date(2024, 11, 4, 9, 30, 0, 0, time.UTC)
+		list = helpers.Envelop{"items": []item{
+			{ID: "c1", Name: "Espresso", Price: 10, At: at},
+			{ID: "c2", Name: `Latte, "large"`, Price: 12.5, Tags: []string{"milk"}, At: at},
+		}}
+	})
+
+	render := func(accept string, data interface{}) {
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		Expect(helpers.Render(w, request, http.StatusOK, data)).To(Succeed())
+	}
+
+	It("should indent the JSON when any type will do, as WriteJson does", func() {
+		render("", list)
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(w.Body.String()).To(ContainSubstring("\n\t\"items\": ["))
+
+		w = httptest.NewRecorder()
+		render("*/*", list)
+		Expect(w.Body.String()).To(ContainSubstring("\n\t\"items\": ["))
+	})
+
+	It("should write compact JSON unless asked to indent it", func() {
+		render("application/json", helpers.Envelop{"item": item{ID: "c1"}})
+		Expect(w.Body.String()).To(HavePrefix(`{"item":{"id":"c1","name":""`))
+
+		w = httptest.NewRecorder()
+		render("application/json; pretty=true", helpers.Envelop{"item": item{ID: "c1"}})
+		Expect(w.Body.String()).To(ContainSubstring("\n\t\"item\": {"))
+		Expect(w.Header().Get("Vary")).To(Equal("Accept"))
+	})
+
+	It("should write XML with the fields in their order", func() {
+		render("application/xml", list)
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/xml; charset=utf-8"))
+		Expect(w.Body.String()).To(ContainSubstring(`<response><items><item><id>c1</id><name>Espresso</name><price>10</price><at>2024-11-04T09:30:00Z</at></item>`))
+		Expect(w.Body.String()).To(ContainSubstring(`<tags><item>milk</item></tags>`))
+	})
+
+	It("should write the keys that can't name an element as entries", func() {
+		render("text/xml", helpers.Envelop{"totals": map[string]int{"42a": 1}})
+		Expect(w.Body.String()).To(ContainSubstring(`<totals><entry key="42a">1</entry></totals>`))
+	})
+
+	It("should write a list as CSV, a row per item", func() {
+		render("text/csv", list)
+		Expect(w.Header().Get("Content-Type")).To(Equal("text/csv; charset=utf-8"))
+		Expect(w.Body.String()).To(Equal("id,name,price,at,tags\n" +
+			"c1,Espresso,10,2024-11-04T09:30:00Z,\n" +
+			`c2,"Latte, ""large""",12.5,2024-11-04T09:30:00Z,"[""milk""]"` + "\n"))
+	})
+
+	It("should fall back on the next accepted type for a payload that isn't a list", func() {
+		render("text/csv, application/xml;q=0.5", helpers.Envelop{"item": item{ID: "c1"}})
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/xml; charset=utf-8"))
+	})
+
+	It("should write MessagePack", func() {
+		render("application/x-msgpack", list)
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/msgpack"))
+
+		var decoded map[string]interface{}
+		handle := &codec.MsgpackHandle{}
+		handle.RawToString = true
+		Expect(codec.NewDecoderBytes(w.Body.Bytes(), handle).Decode(&decoded)).To(Succeed())
+		items := decoded["items"].([]interface{})
+		Expect(items).To(HaveLen(2))
+		Expect(items[1]).To(HaveKeyWithValue("name", `Latte, "large"`))
+		Expect(items[1]).To(HaveKeyWithValue("price", 12.5))
+	})
+
+	It("should return 406 with the available types when none of the accepted ones can be served", func() {
+		request.Header.Set("Accept", "text/html, application/json;q=0")
+		err := helpers.Render(w, request, http.StatusOK, list)
+		Expect(err).To(MatchError(helpers.ErrNotAcceptable))
+		Expect(w.Code).To(Equal(http.StatusNotAcceptable))
+
+		var response struct {
+			Error bool                `json:"error"`
+			Data  map[string][]string `json:"data"`
+		}
+		Expect(json.Unmarshal(w.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.Data["available"]).To(ContainElement("text/csv"))
+	})
+
+	It("should keep a type given q=0 out of the wildcards", func() {
+		render("application/json;q=0, application/*", helpers.Envelop{"item": item{ID: "c1"}})
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/xml; charset=utf-8"))
+	})
+})
+
+var _ = Describe("Negotiate", Label("unit"), func() {
+	var (
+		w      *httptest.ResponseRecorder
+		called bool
+	)
+
+	serve := func(method string, accept string) {
+		request := httptest.NewRequest(method, "/", nil)
+		request.Header.Set("Accept", accept)
+		helpers.Negotiate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			Expect(helpers.Render(w, r, http.StatusOK, helpers.Envelop{"item": map[string]string{"id": "c1"}})).To(Succeed())
+		})).ServeHTTP(w, request)
+	}
+
+	BeforeEach(func() {
+		w = httptest.NewRecorder()
+		called = false
+	})
+
+	It("should answer 406 before the handler runs when no accepted type can be served", func() {
+		serve(http.MethodPost, "text/html")
+		Expect(w.Code).To(Equal(http.StatusNotAcceptable))
+		Expect(called).To(BeFalse())
+	})
+
+	It("should refuse a change accepting CSV only, it can't answer a list", func() {
+		serve(http.MethodPut, "text/csv")
+		Expect(w.Code).To(Equal(http.StatusNotAcceptable))
+		Expect(called).To(BeFalse())
+	})
+
+	It("should keep the choice in the request for Render", func() {
+		serve(http.MethodPost, "text/csv, application/xml;q=0.5")
+		Expect(called).To(BeTrue())
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/xml; charset=utf-8"))
+		Expect(w.Header().Values("Vary")).To(Equal([]string{"Accept"}))
+	})
+})
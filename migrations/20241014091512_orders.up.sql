@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS orders (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "customer_name" varchar NOT NULL,
+    "customer_email" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "total" FLOAT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "order_id" uuid NOT NULL REFERENCES orders ("id") ON DELETE CASCADE,
+    "coffee_id" uuid REFERENCES coffees ("id") ON DELETE SET NULL,
+    "name" varchar NOT NULL,
+    "quantity" INT NOT NULL CHECK ("quantity" > 0),
+    "unit_price" FLOAT NOT NULL,
+    "line_total" FLOAT NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items ("order_id");
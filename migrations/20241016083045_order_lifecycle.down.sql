@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS order_transitions;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS "paid_at",
+    DROP COLUMN IF EXISTS "roasting_at",
+    DROP COLUMN IF EXISTS "packed_at",
+    DROP COLUMN IF EXISTS "shipped_at",
+    DROP COLUMN IF EXISTS "delivered_at",
+    DROP COLUMN IF EXISTS "cancelled_at",
+    DROP COLUMN IF EXISTS "refunded_at";
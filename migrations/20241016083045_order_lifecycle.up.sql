@@ -0,0 +1,18 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS "paid_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "roasting_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "packed_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "shipped_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "delivered_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "cancelled_at" TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS "refunded_at" TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS order_transitions (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "order_id" uuid NOT NULL REFERENCES orders ("id") ON DELETE CASCADE,
+    "from_status" varchar NOT NULL,
+    "to_status" varchar NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_transitions_order_id_idx ON order_transitions ("order_id");
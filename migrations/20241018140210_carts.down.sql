@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS "cart_id";
+DROP TABLE IF EXISTS cart_items;
+DROP TABLE IF EXISTS carts;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS carts (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "user_id" varchar,
+    "status" varchar NOT NULL DEFAULT 'active',
+    "expires_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS carts_user_id_idx ON carts ("user_id") WHERE "status" = 'active';
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "cart_id" uuid NOT NULL REFERENCES carts ("id") ON DELETE CASCADE,
+    "coffee_id" uuid NOT NULL REFERENCES coffees ("id") ON DELETE CASCADE,
+    "grind" varchar NOT NULL,
+    "quantity" INT NOT NULL CHECK ("quantity" > 0),
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE ("cart_id", "coffee_id", "grind")
+);
+
+-- The cart an order was placed from, the promotions of the cart are redeemed for the order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "cart_id" uuid REFERENCES carts ("id") ON DELETE SET NULL;
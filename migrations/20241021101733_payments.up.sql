@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS payments (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "order_id" uuid NOT NULL REFERENCES orders ("id") ON DELETE CASCADE,
+    "provider" varchar NOT NULL,
+    "provider_reference" varchar,
+    "amount" FLOAT NOT NULL,
+    "status" varchar NOT NULL,
+    "failure_reason" varchar NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payments_order_id_idx ON payments ("order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS payments_provider_reference_idx ON payments ("provider", "provider_reference");
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "code" varchar UNIQUE,
+    "name" varchar NOT NULL,
+    "type" varchar NOT NULL,
+    "value" FLOAT NOT NULL DEFAULT 0,
+    "buy_quantity" INT NOT NULL DEFAULT 0,
+    "get_quantity" INT NOT NULL DEFAULT 0,
+    "roasts" jsonb NOT NULL DEFAULT '[]',
+    "regions" jsonb NOT NULL DEFAULT '[]',
+    "starts_at" TIMESTAMP WITH TIME ZONE,
+    "ends_at" TIMESTAMP WITH TIME ZONE,
+    "max_uses" INT NOT NULL DEFAULT 0,
+    "max_uses_per_customer" INT NOT NULL DEFAULT 0,
+    "stackable" BOOLEAN NOT NULL DEFAULT FALSE,
+    "priority" INT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "promotion_id" uuid NOT NULL REFERENCES promotions ("id") ON DELETE CASCADE,
+    "customer_id" varchar NOT NULL DEFAULT '',
+    "order_id" uuid REFERENCES orders ("id") ON DELETE SET NULL,
+    "discount" FLOAT NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS promotion_redemptions_promotion_id_idx ON promotion_redemptions ("promotion_id", "customer_id");
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    "id" BIGSERIAL PRIMARY KEY,
+    "aggregate_type" varchar NOT NULL,
+    "aggregate_id" varchar NOT NULL,
+    "event_type" varchar NOT NULL,
+    "payload" text NOT NULL,
+    "attempts" INT NOT NULL DEFAULT 0,
+    "last_error" varchar NOT NULL DEFAULT '',
+    "published_at" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_unpublished_idx ON outbox_events ("id") WHERE "published_at" IS NULL;
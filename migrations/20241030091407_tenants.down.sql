@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS webhook_subscriptions_tenant_id_idx;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS "tenant_id";
+
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_tenant_id_code_key;
+ALTER TABLE promotions DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE promotions ADD CONSTRAINT promotions_code_key UNIQUE ("code");
+
+DROP INDEX IF EXISTS payments_tenant_id_idx;
+ALTER TABLE payments DROP COLUMN IF EXISTS "tenant_id";
+
+DROP INDEX IF EXISTS carts_tenant_id_idx;
+ALTER TABLE carts DROP COLUMN IF EXISTS "tenant_id";
+
+DROP INDEX IF EXISTS orders_tenant_id_idx;
+ALTER TABLE orders DROP COLUMN IF EXISTS "tenant_id";
+
+DROP INDEX IF EXISTS coffees_tenant_id_idx;
+ALTER TABLE coffees DROP COLUMN IF EXISTS "tenant_id";
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    "id" uuid PRIMARY KEY NOT NULL DEFAULT (uuid_generate_v4()),
+    "slug" varchar NOT NULL UNIQUE,
+    "name" varchar NOT NULL,
+    "config" jsonb NOT NULL DEFAULT '{}',
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- The catalog from before the tenants belongs to the default tenant
+INSERT INTO tenants ("id", "slug", "name") VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default shop')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE coffees ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+CREATE INDEX IF NOT EXISTS coffees_tenant_id_idx ON coffees ("tenant_id");
+
+-- So do the orders, carts, payments, promotions and webhook subscriptions
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+CREATE INDEX IF NOT EXISTS orders_tenant_id_idx ON orders ("tenant_id");
+
+ALTER TABLE carts ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+CREATE INDEX IF NOT EXISTS carts_tenant_id_idx ON carts ("tenant_id");
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+CREATE INDEX IF NOT EXISTS payments_tenant_id_idx ON payments ("tenant_id");
+
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+-- Two shops may hand out the same promotion code
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_code_key;
+ALTER TABLE promotions ADD CONSTRAINT promotions_tenant_id_code_key UNIQUE ("tenant_id", "code");
+
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS "tenant_id" uuid NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES tenants ("id");
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_tenant_id_idx ON webhook_subscriptions ("tenant_id");
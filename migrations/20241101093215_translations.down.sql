@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS label_translations;
+
+DROP INDEX IF EXISTS coffee_translations_lang_idx;
+DROP TABLE IF EXISTS coffee_translations;
@@ -0,0 +1,24 @@
+-- A coffee in another language, the description of a coffee is only kept here, in the source language too
+CREATE TABLE IF NOT EXISTS coffee_translations (
+    "coffee_id" uuid NOT NULL REFERENCES coffees ("id") ON DELETE CASCADE,
+    "lang" varchar NOT NULL,
+    "name" varchar NOT NULL DEFAULT '',
+    "description" text NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY ("coffee_id", "lang")
+);
+
+CREATE INDEX IF NOT EXISTS coffee_translations_lang_idx ON coffee_translations ("lang");
+
+-- The roasts and regions in another language, shared by the coffees of a tenant with the same value
+CREATE TABLE IF NOT EXISTS label_translations (
+    "tenant_id" uuid NOT NULL REFERENCES tenants ("id") ON DELETE CASCADE,
+    "kind" varchar NOT NULL CHECK ("kind" IN ('roast', 'region')),
+    "value" varchar NOT NULL,
+    "lang" varchar NOT NULL,
+    "label" varchar NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY ("tenant_id", "kind", "value", "lang")
+);
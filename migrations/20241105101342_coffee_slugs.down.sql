@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS coffee_slug_history;
+
+DROP INDEX IF EXISTS coffees_tenant_id_slug_idx;
+ALTER TABLE coffees DROP COLUMN IF EXISTS "slug_custom";
+ALTER TABLE coffees DROP COLUMN IF EXISTS "slug";
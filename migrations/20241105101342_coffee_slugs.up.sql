@@ -0,0 +1,44 @@
+CREATE EXTENSION IF NOT EXISTS "unaccent";
+
+-- The slug names a coffee in the URLs of its shop, it is generated from the name and the region unless
+-- an admin picked it (slug_custom), then it is kept through the renames
+ALTER TABLE coffees ADD COLUMN IF NOT EXISTS "slug" varchar;
+ALTER TABLE coffees ADD COLUMN IF NOT EXISTS "slug_custom" boolean NOT NULL DEFAULT false;
+
+-- The coffees from before get the slug the server would have generated, the oldest coffee keeps the
+-- plain one on a collision and the others are numbered
+DO $$
+DECLARE
+    coffee record;
+    candidate varchar;
+    n integer;
+BEGIN
+    FOR coffee IN
+        SELECT "id", "tenant_id",
+            coalesce(nullif(trim(BOTH '-' FROM left(regexp_replace(lower(unaccent("name" || ' ' || "region")), '[^a-z0-9]+', '-', 'g'), 80)), ''), 'coffee') AS base
+        FROM coffees WHERE "slug" IS NULL ORDER BY "created_at", "id"
+    LOOP
+        candidate := coffee.base;
+        n := 1;
+        WHILE EXISTS (SELECT 1 FROM coffees WHERE "tenant_id" = coffee.tenant_id AND "slug" = candidate) LOOP
+            n := n + 1;
+            candidate := coffee.base || '-' || n;
+        END LOOP;
+        UPDATE coffees SET "slug" = candidate WHERE "id" = coffee.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE coffees ALTER COLUMN "slug" SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS coffees_tenant_id_slug_idx ON coffees ("tenant_id", "slug");
+
+-- The slugs a coffee had before, their URLs redirect to the current one. A slug given to another coffee
+-- leaves the history.
+CREATE TABLE IF NOT EXISTS coffee_slug_history (
+    "tenant_id" uuid NOT NULL REFERENCES tenants ("id") ON DELETE CASCADE,
+    "slug" varchar NOT NULL,
+    "coffee_id" uuid NOT NULL REFERENCES coffees ("id") ON DELETE CASCADE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY ("tenant_id", "slug")
+);
+
+CREATE INDEX IF NOT EXISTS coffee_slug_history_coffee_id_idx ON coffee_slug_history ("coffee_id");
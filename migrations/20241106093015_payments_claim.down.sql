@@ -0,0 +1 @@
+DROP INDEX IF EXISTS payments_order_id_claim_idx;
@@ -0,0 +1,4 @@
+-- An order has a single payment pending, authorized or captured at a time, a checkout claims the order
+-- by recording its payment as pending before calling the provider
+CREATE UNIQUE INDEX IF NOT EXISTS payments_order_id_claim_idx ON payments ("order_id")
+    WHERE "status" IN ('pending', 'authorized', 'captured');
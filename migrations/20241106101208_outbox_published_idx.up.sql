@@ -0,0 +1,2 @@
+-- Every instance follows the published events after the last one it saw
+CREATE INDEX IF NOT EXISTS outbox_events_published_idx ON outbox_events ("published_at", "id") WHERE "published_at" IS NOT NULL;
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// Files holds the SQL migrations, so the binaries can run them without the source tree
+//
+//go:embed *.sql
+var Files embed.FS
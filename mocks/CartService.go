@@ -0,0 +1,236 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CartService is an autogenerated mock type for the CartService type
+type CartService struct {
+	mock.Mock
+}
+
+// AddCartItem provides a mock function with given fields: cartId, item
+func (_m *CartService) AddCartItem(cartId string, item services.CartItem) (*services.Cart, error) {
+	ret := _m.Called(cartId, item)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddCartItem")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, services.CartItem) (*services.Cart, error)); ok {
+		return rf(cartId, item)
+	}
+	if rf, ok := ret.Get(0).(func(string, services.CartItem) *services.Cart); ok {
+		r0 = rf(cartId, item)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, services.CartItem) error); ok {
+		r1 = rf(cartId, item)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCart provides a mock function with given fields: userId
+func (_m *CartService) CreateCart(userId string) (*services.Cart, error) {
+	ret := _m.Called(userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCart")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Cart, error)); ok {
+		return rf(userId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Cart); ok {
+		r0 = rf(userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredCarts provides a mock function with no fields
+func (_m *CartService) DeleteExpiredCarts() (int64, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredCarts")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (int64, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCartById provides a mock function with given fields: id
+func (_m *CartService) GetCartById(id string) (*services.Cart, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCartById")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Cart, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Cart); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeCarts provides a mock function with given fields: cartId, userId
+func (_m *CartService) MergeCarts(cartId string, userId string) (*services.Cart, error) {
+	ret := _m.Called(cartId, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeCarts")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*services.Cart, error)); ok {
+		return rf(cartId, userId)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *services.Cart); ok {
+		r0 = rf(cartId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartId, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCartItem provides a mock function with given fields: cartId, itemId
+func (_m *CartService) RemoveCartItem(cartId string, itemId string) (*services.Cart, error) {
+	ret := _m.Called(cartId, itemId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCartItem")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*services.Cart, error)); ok {
+		return rf(cartId, itemId)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *services.Cart); ok {
+		r0 = rf(cartId, itemId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(cartId, itemId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCartItem provides a mock function with given fields: cartId, itemId, item
+func (_m *CartService) UpdateCartItem(cartId string, itemId string, item services.CartItem) (*services.Cart, error) {
+	ret := _m.Called(cartId, itemId, item)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCartItem")
+	}
+
+	var r0 *services.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, services.CartItem) (*services.Cart, error)); ok {
+		return rf(cartId, itemId, item)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, services.CartItem) *services.Cart); ok {
+		r0 = rf(cartId, itemId, item)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, services.CartItem) error); ok {
+		r1 = rf(cartId, itemId, item)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewCartService creates a new instance of CartService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCartService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CartService {
+	mock := &CartService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
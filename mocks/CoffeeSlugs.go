@@ -0,0 +1,88 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CoffeeSlugs is an autogenerated mock type for the CoffeeSlugs type
+type CoffeeSlugs struct {
+	mock.Mock
+}
+
+// ResetCoffeeSlug provides a mock function with given fields: id
+func (_m *CoffeeSlugs) ResetCoffeeSlug(id string) (*services.Coffee, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetCoffeeSlug")
+	}
+
+	var r0 *services.Coffee
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Coffee, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Coffee); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Coffee)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCoffeeSlug provides a mock function with given fields: id, slug
+func (_m *CoffeeSlugs) SetCoffeeSlug(id string, slug string) (*services.Coffee, error) {
+	ret := _m.Called(id, slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCoffeeSlug")
+	}
+
+	var r0 *services.Coffee
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*services.Coffee, error)); ok {
+		return rf(id, slug)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *services.Coffee); ok {
+		r0 = rf(id, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Coffee)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(id, slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewCoffeeSlugs creates a new instance of CoffeeSlugs. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCoffeeSlugs(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CoffeeSlugs {
+	mock := &CoffeeSlugs{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,90 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	events "coffee/coffee-server/events"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CoffeeStream is an autogenerated mock type for the CoffeeStream type
+type CoffeeStream struct {
+	mock.Mock
+}
+
+// Replay provides a mock function with given fields: afterId
+func (_m *CoffeeStream) Replay(afterId int64) ([]events.Event, error) {
+	ret := _m.Called(afterId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replay")
+	}
+
+	var r0 []events.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]events.Event, error)); ok {
+		return rf(afterId)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []events.Event); ok {
+		r0 = rf(afterId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]events.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(afterId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Subscribe provides a mock function with no fields
+func (_m *CoffeeStream) Subscribe() (<-chan events.Event, func()) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 <-chan events.Event
+	var r1 func()
+	if rf, ok := ret.Get(0).(func() (<-chan events.Event, func())); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() <-chan events.Event); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan events.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() func()); ok {
+		r1 = rf()
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	return r0, r1
+}
+
+// NewCoffeeStream creates a new instance of CoffeeStream. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCoffeeStream(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CoffeeStream {
+	mock := &CoffeeStream{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,15 +1,14 @@
-// Code generated by mockery v2.46.2. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
+	db "coffee/coffee-server/db"
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
 
 	sql "database/sql"
-
-	time "time"
 )
 
 // DBInterface is an autogenerated mock type for the DBInterface type
@@ -17,7 +16,37 @@ type DBInterface struct {
 	mock.Mock
 }
 
-// Close provides a mock function with given fields:
+// BeginTx provides a mock function with given fields: ctx, opts
+func (_m *DBInterface) BeginTx(ctx context.Context, opts *sql.TxOptions) (db.Tx, error) {
+	ret := _m.Called(ctx, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginTx")
+	}
+
+	var r0 db.Tx
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions) (db.Tx, error)); ok {
+		return rf(ctx, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions) db.Tx); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(db.Tx)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.TxOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Close provides a mock function with no fields
 func (_m *DBInterface) Close() error {
 	ret := _m.Called()
 
@@ -35,6 +64,34 @@ func (_m *DBInterface) Close() error {
 	return r0
 }
 
+// CopyFrom provides a mock function with given fields: ctx, table, columns, rows
+func (_m *DBInterface) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	ret := _m.Called(ctx, table, columns, rows)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CopyFrom")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, [][]interface{}) (int64, error)); ok {
+		return rf(ctx, table, columns, rows)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, [][]interface{}) int64); ok {
+		r0 = rf(ctx, table, columns, rows)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, [][]interface{}) error); ok {
+		r1 = rf(ctx, table, columns, rows)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExecContext provides a mock function with given fields: ctx, query, args
 func (_m *DBInterface) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	var _ca []interface{}
@@ -68,7 +125,7 @@ func (_m *DBInterface) ExecContext(ctx context.Context, query string, args ...in
 	return r0, r1
 }
 
-// Ping provides a mock function with given fields:
+// Ping provides a mock function with no fields
 func (_m *DBInterface) Ping() error {
 	ret := _m.Called()
 
@@ -87,7 +144,7 @@ func (_m *DBInterface) Ping() error {
 }
 
 // QueryContext provides a mock function with given fields: ctx, query, args
-func (_m *DBInterface) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+func (_m *DBInterface) QueryContext(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
 	var _ca []interface{}
 	_ca = append(_ca, ctx, query)
 	_ca = append(_ca, args...)
@@ -97,16 +154,16 @@ func (_m *DBInterface) QueryContext(ctx context.Context, query string, args ...i
 		panic("no return value specified for QueryContext")
 	}
 
-	var r0 *sql.Rows
+	var r0 db.Rows
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (*sql.Rows, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (db.Rows, error)); ok {
 		return rf(ctx, query, args...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sql.Rows); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) db.Rows); ok {
 		r0 = rf(ctx, query, args...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*sql.Rows)
+			r0 = ret.Get(0).(db.Rows)
 		}
 	}
 
@@ -120,7 +177,7 @@ func (_m *DBInterface) QueryContext(ctx context.Context, query string, args ...i
 }
 
 // QueryRowContext provides a mock function with given fields: ctx, query, args
-func (_m *DBInterface) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+func (_m *DBInterface) QueryRowContext(ctx context.Context, query string, args ...interface{}) db.Row {
 	var _ca []interface{}
 	_ca = append(_ca, ctx, query)
 	_ca = append(_ca, args...)
@@ -130,31 +187,46 @@ func (_m *DBInterface) QueryRowContext(ctx context.Context, query string, args .
 		panic("no return value specified for QueryRowContext")
 	}
 
-	var r0 *sql.Row
-	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sql.Row); ok {
+	var r0 db.Row
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) db.Row); ok {
 		r0 = rf(ctx, query, args...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*sql.Row)
+			r0 = ret.Get(0).(db.Row)
 		}
 	}
 
 	return r0
 }
 
-// SetConnMaxLifetime provides a mock function with given fields: d
-func (_m *DBInterface) SetConnMaxLifetime(d time.Duration) {
-	_m.Called(d)
-}
+// SendBatch provides a mock function with given fields: ctx, queries
+func (_m *DBInterface) SendBatch(ctx context.Context, queries []db.Query) ([]int64, error) {
+	ret := _m.Called(ctx, queries)
 
-// SetMaxIdleConns provides a mock function with given fields: n
-func (_m *DBInterface) SetMaxIdleConns(n int) {
-	_m.Called(n)
-}
+	if len(ret) == 0 {
+		panic("no return value specified for SendBatch")
+	}
 
-// SetMaxOpenConns provides a mock function with given fields: n
-func (_m *DBInterface) SetMaxOpenConns(n int) {
-	_m.Called(n)
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []db.Query) ([]int64, error)); ok {
+		return rf(ctx, queries)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []db.Query) []int64); ok {
+		r0 = rf(ctx, queries)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []db.Query) error); ok {
+		r1 = rf(ctx, queries)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // NewDBInterface creates a new instance of DBInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -0,0 +1,208 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrderService is an autogenerated mock type for the OrderService type
+type OrderService struct {
+	mock.Mock
+}
+
+// CancelOrder provides a mock function with given fields: id
+func (_m *OrderService) CancelOrder(id string) (*services.Order, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelOrder")
+	}
+
+	var r0 *services.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Order, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Order); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOrder provides a mock function with given fields: order
+func (_m *OrderService) CreateOrder(order services.Order) (*services.Order, error) {
+	ret := _m.Called(order)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 *services.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.Order) (*services.Order, error)); ok {
+		return rf(order)
+	}
+	if rf, ok := ret.Get(0).(func(services.Order) *services.Order); ok {
+		r0 = rf(order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.Order) error); ok {
+		r1 = rf(order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllOrders provides a mock function with no fields
+func (_m *OrderService) GetAllOrders() ([]*services.Order, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllOrders")
+	}
+
+	var r0 []*services.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*services.Order, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*services.Order); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderById provides a mock function with given fields: id
+func (_m *OrderService) GetOrderById(id string) (*services.Order, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderById")
+	}
+
+	var r0 *services.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Order, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Order); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderHistory provides a mock function with given fields: id
+func (_m *OrderService) GetOrderHistory(id string) ([]*services.OrderTransition, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderHistory")
+	}
+
+	var r0 []*services.OrderTransition
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*services.OrderTransition, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*services.OrderTransition); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.OrderTransition)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionOrder provides a mock function with given fields: id, status
+func (_m *OrderService) TransitionOrder(id string, status string) (*services.Order, error) {
+	ret := _m.Called(id, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionOrder")
+	}
+
+	var r0 *services.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*services.Order, error)); ok {
+		return rf(id, status)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *services.Order); ok {
+		r0 = rf(id, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(id, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOrderService creates a new instance of OrderService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrderService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrderService {
+	mock := &OrderService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
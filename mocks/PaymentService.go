@@ -0,0 +1,178 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// PaymentService is an autogenerated mock type for the PaymentService type
+type PaymentService struct {
+	mock.Mock
+}
+
+// Checkout provides a mock function with given fields: orderId, token
+func (_m *PaymentService) Checkout(orderId string, token string) (*services.Payment, error) {
+	ret := _m.Called(orderId, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checkout")
+	}
+
+	var r0 *services.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*services.Payment, error)); ok {
+		return rf(orderId, token)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *services.Payment); ok {
+		r0 = rf(orderId, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(orderId, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpireStaleClaims provides a mock function with given fields: olderThan
+func (_m *PaymentService) ExpireStaleClaims(olderThan time.Duration) (int64, error) {
+	ret := _m.Called(olderThan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireStaleClaims")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Duration) (int64, error)); ok {
+		return rf(olderThan)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration) int64); ok {
+		r0 = rf(olderThan)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration) error); ok {
+		r1 = rf(olderThan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentsByOrder provides a mock function with given fields: orderId
+func (_m *PaymentService) GetPaymentsByOrder(orderId string) ([]*services.Payment, error) {
+	ret := _m.Called(orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPaymentsByOrder")
+	}
+
+	var r0 []*services.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*services.Payment, error)); ok {
+		return rf(orderId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*services.Payment); ok {
+		r0 = rf(orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HandleWebhook provides a mock function with given fields: payload, signature
+func (_m *PaymentService) HandleWebhook(payload []byte, signature string) (*services.Payment, error) {
+	ret := _m.Called(payload, signature)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandleWebhook")
+	}
+
+	var r0 *services.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]byte, string) (*services.Payment, error)); ok {
+		return rf(payload, signature)
+	}
+	if rf, ok := ret.Get(0).(func([]byte, string) *services.Payment); ok {
+		r0 = rf(payload, signature)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]byte, string) error); ok {
+		r1 = rf(payload, signature)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPayment provides a mock function with given fields: id
+func (_m *PaymentService) RefundPayment(id string) (*services.Payment, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundPayment")
+	}
+
+	var r0 *services.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Payment, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Payment); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewPaymentService creates a new instance of PaymentService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPaymentService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PaymentService {
+	mock := &PaymentService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
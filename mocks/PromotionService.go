@@ -0,0 +1,196 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PromotionService is an autogenerated mock type for the PromotionService type
+type PromotionService struct {
+	mock.Mock
+}
+
+// CreatePromotion provides a mock function with given fields: promotion
+func (_m *PromotionService) CreatePromotion(promotion services.Promotion) (*services.Promotion, error) {
+	ret := _m.Called(promotion)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePromotion")
+	}
+
+	var r0 *services.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.Promotion) (*services.Promotion, error)); ok {
+		return rf(promotion)
+	}
+	if rf, ok := ret.Get(0).(func(services.Promotion) *services.Promotion); ok {
+		r0 = rf(promotion)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.Promotion) error); ok {
+		r1 = rf(promotion)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletePromotion provides a mock function with given fields: id
+func (_m *PromotionService) DeletePromotion(id string) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePromotion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EvaluateCart provides a mock function with given fields: req
+func (_m *PromotionService) EvaluateCart(req services.PromotionRequest) (*services.PromotionEvaluation, error) {
+	ret := _m.Called(req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EvaluateCart")
+	}
+
+	var r0 *services.PromotionEvaluation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.PromotionRequest) (*services.PromotionEvaluation, error)); ok {
+		return rf(req)
+	}
+	if rf, ok := ret.Get(0).(func(services.PromotionRequest) *services.PromotionEvaluation); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.PromotionEvaluation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.PromotionRequest) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllPromotions provides a mock function with no fields
+func (_m *PromotionService) GetAllPromotions() ([]*services.Promotion, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllPromotions")
+	}
+
+	var r0 []*services.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*services.Promotion, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*services.Promotion); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromotionById provides a mock function with given fields: id
+func (_m *PromotionService) GetPromotionById(id string) (*services.Promotion, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPromotionById")
+	}
+
+	var r0 *services.Promotion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Promotion, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Promotion); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Promotion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RedeemPromotions provides a mock function with given fields: req, orderId
+func (_m *PromotionService) RedeemPromotions(req services.PromotionRequest, orderId string) (*services.PromotionEvaluation, error) {
+	ret := _m.Called(req, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RedeemPromotions")
+	}
+
+	var r0 *services.PromotionEvaluation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.PromotionRequest, string) (*services.PromotionEvaluation, error)); ok {
+		return rf(req, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(services.PromotionRequest, string) *services.PromotionEvaluation); ok {
+		r0 = rf(req, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.PromotionEvaluation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.PromotionRequest, string) error); ok {
+		r1 = rf(req, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewPromotionService creates a new instance of PromotionService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPromotionService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PromotionService {
+	mock := &PromotionService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
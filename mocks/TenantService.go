@@ -0,0 +1,178 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TenantService is an autogenerated mock type for the TenantService type
+type TenantService struct {
+	mock.Mock
+}
+
+// CreateTenant provides a mock function with given fields: tenant
+func (_m *TenantService) CreateTenant(tenant services.Tenant) (*services.Tenant, error) {
+	ret := _m.Called(tenant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTenant")
+	}
+
+	var r0 *services.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.Tenant) (*services.Tenant, error)); ok {
+		return rf(tenant)
+	}
+	if rf, ok := ret.Get(0).(func(services.Tenant) *services.Tenant); ok {
+		r0 = rf(tenant)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.Tenant) error); ok {
+		r1 = rf(tenant)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllTenants provides a mock function with no fields
+func (_m *TenantService) GetAllTenants() ([]*services.Tenant, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllTenants")
+	}
+
+	var r0 []*services.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*services.Tenant, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*services.Tenant); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantById provides a mock function with given fields: id
+func (_m *TenantService) GetTenantById(id string) (*services.Tenant, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTenantById")
+	}
+
+	var r0 *services.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Tenant, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Tenant); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTenantBySlug provides a mock function with given fields: slug
+func (_m *TenantService) GetTenantBySlug(slug string) (*services.Tenant, error) {
+	ret := _m.Called(slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTenantBySlug")
+	}
+
+	var r0 *services.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.Tenant, error)); ok {
+		return rf(slug)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.Tenant); ok {
+		r0 = rf(slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTenantConfig provides a mock function with given fields: id, config
+func (_m *TenantService) UpdateTenantConfig(id string, config services.TenantConfig) (*services.Tenant, error) {
+	ret := _m.Called(id, config)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTenantConfig")
+	}
+
+	var r0 *services.Tenant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, services.TenantConfig) (*services.Tenant, error)); ok {
+		return rf(id, config)
+	}
+	if rf, ok := ret.Get(0).(func(string, services.TenantConfig) *services.Tenant); ok {
+		r0 = rf(id, config)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Tenant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, services.TenantConfig) error); ok {
+		r1 = rf(id, config)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTenantService creates a new instance of TenantService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTenantService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TenantService {
+	mock := &TenantService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
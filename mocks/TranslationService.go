@@ -0,0 +1,242 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TranslationService is an autogenerated mock type for the TranslationService type
+type TranslationService struct {
+	mock.Mock
+}
+
+// DeleteLabel provides a mock function with given fields: kind, value, lang
+func (_m *TranslationService) DeleteLabel(kind string, value string, lang string) error {
+	ret := _m.Called(kind, value, lang)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLabel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(kind, value, lang)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteTranslation provides a mock function with given fields: coffeeId, lang
+func (_m *TranslationService) DeleteTranslation(coffeeId string, lang string) error {
+	ret := _m.Called(coffeeId, lang)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTranslation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(coffeeId, lang)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLabels provides a mock function with given fields: lang
+func (_m *TranslationService) GetLabels(lang string) ([]*services.Label, error) {
+	ret := _m.Called(lang)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLabels")
+	}
+
+	var r0 []*services.Label
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*services.Label, error)); ok {
+		return rf(lang)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*services.Label); ok {
+		r0 = rf(lang)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Label)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(lang)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTranslations provides a mock function with given fields: coffeeId
+func (_m *TranslationService) GetTranslations(coffeeId string) ([]*services.Translation, error) {
+	ret := _m.Called(coffeeId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTranslations")
+	}
+
+	var r0 []*services.Translation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*services.Translation, error)); ok {
+		return rf(coffeeId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*services.Translation); ok {
+		r0 = rf(coffeeId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.Translation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(coffeeId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Localize provides a mock function with given fields: coffees, langs
+func (_m *TranslationService) Localize(coffees []*services.Coffee, langs []string) (string, error) {
+	ret := _m.Called(coffees, langs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Localize")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]*services.Coffee, []string) (string, error)); ok {
+		return rf(coffees, langs)
+	}
+	if rf, ok := ret.Get(0).(func([]*services.Coffee, []string) string); ok {
+		r0 = rf(coffees, langs)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func([]*services.Coffee, []string) error); ok {
+		r1 = rf(coffees, langs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MissingTranslations provides a mock function with given fields: langs
+func (_m *TranslationService) MissingTranslations(langs []string) ([]*services.MissingTranslation, error) {
+	ret := _m.Called(langs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MissingTranslations")
+	}
+
+	var r0 []*services.MissingTranslation
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string) ([]*services.MissingTranslation, error)); ok {
+		return rf(langs)
+	}
+	if rf, ok := ret.Get(0).(func([]string) []*services.MissingTranslation); ok {
+		r0 = rf(langs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.MissingTranslation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(langs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutLabel provides a mock function with given fields: label
+func (_m *TranslationService) PutLabel(label services.Label) (*services.Label, error) {
+	ret := _m.Called(label)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutLabel")
+	}
+
+	var r0 *services.Label
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.Label) (*services.Label, error)); ok {
+		return rf(label)
+	}
+	if rf, ok := ret.Get(0).(func(services.Label) *services.Label); ok {
+		r0 = rf(label)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Label)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.Label) error); ok {
+		r1 = rf(label)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutTranslation provides a mock function with given fields: translation
+func (_m *TranslationService) PutTranslation(translation services.Translation) (*services.Translation, error) {
+	ret := _m.Called(translation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutTranslation")
+	}
+
+	var r0 *services.Translation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.Translation) (*services.Translation, error)); ok {
+		return rf(translation)
+	}
+	if rf, ok := ret.Get(0).(func(services.Translation) *services.Translation); ok {
+		r0 = rf(translation)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.Translation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.Translation) error); ok {
+		r1 = rf(translation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTranslationService creates a new instance of TranslationService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTranslationService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TranslationService {
+	mock := &TranslationService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,242 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	services "coffee/coffee-server/services"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookService is an autogenerated mock type for the WebhookService type
+type WebhookService struct {
+	mock.Mock
+}
+
+// CreateSubscription provides a mock function with given fields: subscription
+func (_m *WebhookService) CreateSubscription(subscription services.WebhookSubscription) (*services.WebhookSubscription, error) {
+	ret := _m.Called(subscription)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSubscription")
+	}
+
+	var r0 *services.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(services.WebhookSubscription) (*services.WebhookSubscription, error)); ok {
+		return rf(subscription)
+	}
+	if rf, ok := ret.Get(0).(func(services.WebhookSubscription) *services.WebhookSubscription); ok {
+		r0 = rf(subscription)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(services.WebhookSubscription) error); ok {
+		r1 = rf(subscription)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSubscription provides a mock function with given fields: id
+func (_m *WebhookService) DeleteSubscription(id string) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DispatchDue provides a mock function with given fields: limit
+func (_m *WebhookService) DispatchDue(limit int) (int, error) {
+	ret := _m.Called(limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DispatchDue")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (int, error)); ok {
+		return rf(limit)
+	}
+	if rf, ok := ret.Get(0).(func(int) int); ok {
+		r0 = rf(limit)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Enqueue provides a mock function with given fields: eventType, data
+func (_m *WebhookService) Enqueue(eventType string, data interface{}) error {
+	ret := _m.Called(eventType, data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Enqueue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}) error); ok {
+		r0 = rf(eventType, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAllSubscriptions provides a mock function with no fields
+func (_m *WebhookService) GetAllSubscriptions() ([]*services.WebhookSubscription, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllSubscriptions")
+	}
+
+	var r0 []*services.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*services.WebhookSubscription, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*services.WebhookSubscription); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeadDeliveries provides a mock function with no fields
+func (_m *WebhookService) GetDeadDeliveries() ([]*services.WebhookDelivery, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeadDeliveries")
+	}
+
+	var r0 []*services.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]*services.WebhookDelivery, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []*services.WebhookDelivery); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeliveries provides a mock function with given fields: subscriptionId
+func (_m *WebhookService) GetDeliveries(subscriptionId string) ([]*services.WebhookDelivery, error) {
+	ret := _m.Called(subscriptionId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeliveries")
+	}
+
+	var r0 []*services.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*services.WebhookDelivery, error)); ok {
+		return rf(subscriptionId)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*services.WebhookDelivery); ok {
+		r0 = rf(subscriptionId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*services.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(subscriptionId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Redeliver provides a mock function with given fields: deliveryId
+func (_m *WebhookService) Redeliver(deliveryId string) (*services.WebhookDelivery, error) {
+	ret := _m.Called(deliveryId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Redeliver")
+	}
+
+	var r0 *services.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*services.WebhookDelivery, error)); ok {
+		return rf(deliveryId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *services.WebhookDelivery); ok {
+		r0 = rf(deliveryId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*services.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(deliveryId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewWebhookService creates a new instance of WebhookService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookService {
+	mock := &WebhookService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
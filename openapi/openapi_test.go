@@ -0,0 +1,145 @@
+package openapi_test
+
+import (
+	"bytes"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/openapi"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("OpenAPI", Label("unit"), func() {
+	var (
+		doc          *openapi3.T
+		mockedCoffee *mocks.CoffeeService
+		mockedOrder  *mocks.OrderService
+		models       services.Models
+	)
+
+	BeforeEach(func() {
+		var err error
+		doc, err = openapi.Load()
+		Expect(err).NotTo(HaveOccurred())
+
+		mockedCoffee = new(mocks.CoffeeService)
+		mockedOrder = new(mocks.OrderService)
+		models = services.Models{
+			Coffee:       mockedCoffee,
+			CoffeeStream: new(mocks.CoffeeStream),
+			Order:        mockedOrder,
+			Cart:         new(mocks.CartService),
+			Payment:      new(mocks.PaymentService),
+			Promotion:    new(mocks.PromotionService),
+			Webhook:      new(mocks.WebhookService),
+		}
+	})
+
+	serve := func(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	It("should describe every route of the API", func() {
+		routes, ok := router.Routes(models).(chi.Routes)
+		Expect(ok).To(BeTrue())
+
+		var missing []string
+		err := chi.Walk(routes, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			if route == "/openapi.json" || route == "/docs" {
+				return nil
+			}
+			path := doc.Paths.Find(route)
+			if path == nil || path.GetOperation(method) == nil {
+				missing = append(missing, method+" "+route)
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+	})
+
+	It("should serve the document as JSON and the Swagger UI", func() {
+		handler := router.Routes(models)
+
+		recorder := serve(handler, http.MethodGet, "/openapi.json", "")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var served map[string]interface{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &served)).To(Succeed())
+		Expect(served["openapi"]).To(Equal("3.0.3"))
+		Expect(served["paths"]).To(HaveKey("/api/v1/coffees"))
+
+		recorder = serve(handler, http.MethodGet, "/docs", "")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring("SwaggerUIBundle({ url: '/openapi.json'"))
+	})
+
+	Describe("Validator", func() {
+		var handler http.Handler
+
+		BeforeEach(func() {
+			handler = router.Routes(models, router.WithValidation())
+		})
+
+		It("should reject a request that doesn't match the document before it reaches the service", func() {
+			recorder := serve(handler, http.MethodPost, "/api/v1/orders/o1/transitions", `{"status": "teleported"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"error": true`))
+			mockedOrder.AssertNotCalled(GinkgoT(), "TransitionOrder", mock.Anything, mock.Anything)
+		})
+
+		It("should pass a valid request with its body to the handler", func() {
+			created := &services.Order{ID: "o1", Status: services.OrderStatusPending, Items: []services.OrderItem{{CoffeeID: "c1", Quantity: 2}}}
+			mockedOrder.On("CreateOrder", mock.Anything).Return(created, nil)
+
+			recorder := serve(handler, http.MethodPost, "/api/v1/orders", `{"customer_name": "Ada", "items": [{"coffee_id": "c1", "quantity": 2}]}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"id": "o1"`))
+			mockedOrder.AssertCalled(GinkgoT(), "CreateOrder", mock.MatchedBy(func(order services.Order) bool {
+				return len(order.Items) == 1 && order.Items[0].CoffeeID == "c1"
+			}))
+		})
+
+		It("should replace a response that doesn't match the document with an error", func() {
+			mockedOrder.On("GetOrderById", "o1").Return(&services.Order{ID: "o1", Status: "lost"}, nil)
+
+			recorder := serve(handler, http.MethodGet, "/api/v1/orders/o1", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).To(ContainSubstring("response does not match the OpenAPI document"))
+		})
+
+		It("should let the routes outside the document through", func() {
+			recorder := serve(handler, http.MethodGet, "/docs", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+		})
+
+		It("should leave the responses alone when validation is off", func() {
+			mockedOrder.On("GetOrderById", "o1").Return(&services.Order{ID: "o1", Status: "lost"}, nil)
+
+			recorder := serve(router.Routes(models), http.MethodGet, "/api/v1/orders/o1", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(bytes.Contains(recorder.Body.Bytes(), []byte(`"status": "lost"`))).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"bytes"
+	"coffee/coffee-server/helpers"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// Validator checks the requests and the responses of the routes described in the document.
+// An invalid request gets a 400 without reaching the handler, and a response that doesn't match the
+// document is logged and replaced by a 500 so the drift gets noticed. Every response is buffered, so
+// it is meant for development and tests.
+func Validator(doc *openapi3.T) (func(http.Handler) http.Handler, error) {
+	routes, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := routes.FindRoute(r)
+			if err != nil {
+				// Routes left out of the document, e.g. the documentation itself, aren't checked
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{Request: r, PathParams: pathParams, Route: route}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				helpers.MessageLogs.ErrorLog.Println(err)
+				helpers.ErrorJson(w, err, http.StatusBadRequest)
+				return
+			}
+
+			// Event streams never end, there is no whole response to check
+			if streams(route) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(res, r)
+
+			// A 406 refuses the Accept header of the request, any route can answer it and none lists it
+			if isJson(res.header) && res.status != http.StatusNotAcceptable {
+				err := openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+					RequestValidationInput: input,
+					Status:                 res.status,
+					Header:                 res.header,
+					Body:                   io.NopCloser(bytes.NewReader(res.body.Bytes())),
+				})
+				if err != nil {
+					err = fmt.Errorf("response does not match the OpenAPI document: %w", err)
+					helpers.MessageLogs.ErrorLog.Println(err)
+					helpers.ErrorJson(w, err, http.StatusInternalServerError)
+					return
+				}
+			}
+			res.writeTo(w)
+		})
+	}, nil
+}
+
+func streams(route *routers.Route) bool {
+	if route.Operation == nil || route.Operation.Responses == nil {
+		return false
+	}
+	ok := route.Operation.Responses.Status(http.StatusOK)
+	return ok != nil && ok.Value != nil && ok.Value.Content.Get("text/event-stream") != nil
+}
+
+// isJson leaves out the bodies the document doesn't describe, e.g. the GraphiQL page or an empty body
+func isJson(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// bufferedResponse holds the response of the handler until it has been checked
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
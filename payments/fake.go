@@ -0,0 +1,141 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const FakeProviderName = "fake"
+
+// Tokens understood by the fake provider, any other token is authorized
+const (
+	FakeTokenDeclined          = "tok_declined"
+	FakeTokenInsufficientFunds = "tok_insufficient_funds"
+	FakeTokenUnavailable       = "tok_unavailable"
+)
+
+var ErrProviderUnavailable = errors.New("payment provider unavailable")
+
+type fakePayment struct {
+	authorized float32
+	captured   float32
+	refunded   float32
+}
+
+// FakeProvider is a deterministic in-process provider for development and tests.
+// References are numbered in call order and the outcome only depends on the token.
+type FakeProvider struct {
+	secret   []byte
+	mu       sync.Mutex
+	sequence int
+	payments map[string]*fakePayment
+}
+
+var _ Provider = &FakeProvider{}
+
+// NewFakeProvider returns a fake provider verifying the webhooks with the secret, none is valid without one
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		secret:   []byte(webhookSecret),
+		payments: map[string]*fakePayment{},
+	}
+}
+
+func (f *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (f *FakeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error) {
+	if req.Token == FakeTokenUnavailable {
+		return nil, ErrProviderUnavailable
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sequence++
+	result := &Result{
+		Reference: fmt.Sprintf("fake_pay_%06d", f.sequence),
+		Amount:    req.Amount,
+		Status:    StatusAuthorized,
+	}
+
+	switch req.Token {
+	case FakeTokenDeclined:
+		result.Status = StatusFailed
+		result.FailureReason = "card_declined"
+		return result, ErrDeclined
+	case FakeTokenInsufficientFunds:
+		result.Status = StatusFailed
+		result.FailureReason = "insufficient_funds"
+		return result, ErrDeclined
+	}
+
+	f.payments[result.Reference] = &fakePayment{authorized: req.Amount}
+	return result, nil
+}
+
+func (f *FakeProvider) Capture(ctx context.Context, reference string, amount float32) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	if !ok {
+		return nil, ErrUnknownReference
+	}
+	if amount > payment.authorized-payment.captured {
+		return nil, ErrInvalidAmount
+	}
+
+	payment.captured += amount
+	return &Result{Reference: reference, Status: StatusCaptured, Amount: amount}, nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, reference string, amount float32) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	if !ok {
+		return nil, ErrUnknownReference
+	}
+	if amount > payment.captured-payment.refunded {
+		return nil, ErrInvalidAmount
+	}
+
+	payment.refunded += amount
+	return &Result{Reference: reference, Status: StatusRefunded, Amount: amount}, nil
+}
+
+// Sign returns the signature the fake provider sends along with a webhook payload
+func (f *FakeProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(hmacSum(f.secret, payload))
+}
+
+func (f *FakeProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if len(f.secret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, hmacSum(f.secret, payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func hmacSum(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,118 @@
+package payments_test
+
+import (
+	"coffee/coffee-server/payments"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake provider", Label("unit"), func() {
+	var (
+		provider *payments.FakeProvider
+		ctx      context.Context
+	)
+
+	BeforeEach(func() {
+		provider = payments.NewFakeProvider("secret")
+		ctx = context.Background()
+	})
+
+	Describe("Authorize", func() {
+		It("should hand out sequential references", func() {
+			first, err := provider.Authorize(ctx, payments.AuthorizeRequest{Amount: 10, Token: "tok_visa"})
+			Expect(err).To(BeNil())
+			second, err := provider.Authorize(ctx, payments.AuthorizeRequest{Amount: 10, Token: "tok_visa"})
+			Expect(err).To(BeNil())
+
+			Expect(first.Reference).To(Equal("fake_pay_000001"))
+			Expect(second.Reference).To(Equal("fake_pay_000002"))
+			Expect(first.Status).To(Equal(payments.StatusAuthorized))
+		})
+
+		It("should decline the declined tokens with a reason", func() {
+			result, err := provider.Authorize(ctx, payments.AuthorizeRequest{Amount: 10, Token: payments.FakeTokenInsufficientFunds})
+			Expect(err).To(MatchError(payments.ErrDeclined))
+			Expect(result.Status).To(Equal(payments.StatusFailed))
+			Expect(result.FailureReason).To(Equal("insufficient_funds"))
+		})
+
+		It("should fail without a result when unavailable", func() {
+			result, err := provider.Authorize(ctx, payments.AuthorizeRequest{Amount: 10, Token: payments.FakeTokenUnavailable})
+			Expect(err).To(MatchError(payments.ErrProviderUnavailable))
+			Expect(result).To(BeNil())
+		})
+	})
+
+	Describe("Capture and Refund", func() {
+		It("should not capture or refund more than was authorized", func() {
+			auth, err := provider.Authorize(ctx, payments.AuthorizeRequest{Amount: 10, Token: "tok_visa"})
+			Expect(err).To(BeNil())
+
+			_, err = provider.Refund(ctx, auth.Reference, 10)
+			Expect(err).To(MatchError(payments.ErrInvalidAmount))
+
+			_, err = provider.Capture(ctx, auth.Reference, 11)
+			Expect(err).To(MatchError(payments.ErrInvalidAmount))
+
+			captured, err := provider.Capture(ctx, auth.Reference, 10)
+			Expect(err).To(BeNil())
+			Expect(captured.Status).To(Equal(payments.StatusCaptured))
+
+			refunded, err := provider.Refund(ctx, auth.Reference, 10)
+			Expect(err).To(BeNil())
+			Expect(refunded.Status).To(Equal(payments.StatusRefunded))
+		})
+
+		It("should reject unknown references", func() {
+			_, err := provider.Capture(ctx, "nope", 1)
+			Expect(err).To(MatchError(payments.ErrUnknownReference))
+		})
+	})
+
+	Describe("VerifyWebhook", func() {
+		payload := []byte(`{"type": "payment.updated", "reference": "fake_pay_000001", "status": "refunded", "amount": 10}`)
+
+		It("should accept a correctly signed payload", func() {
+			event, err := provider.VerifyWebhook(payload, provider.Sign(payload))
+			Expect(err).To(BeNil())
+			Expect(event.Reference).To(Equal("fake_pay_000001"))
+			Expect(event.Status).To(Equal(payments.StatusRefunded))
+		})
+
+		It("should reject a payload signed with another secret", func() {
+			other := payments.NewFakeProvider("other")
+			_, err := provider.VerifyWebhook(payload, other.Sign(payload))
+			Expect(err).To(MatchError(payments.ErrInvalidSignature))
+		})
+
+		It("should reject a malformed signature", func() {
+			_, err := provider.VerifyWebhook(payload, "not-hex")
+			Expect(err).To(MatchError(payments.ErrInvalidSignature))
+		})
+	})
+
+	Describe("NewProvider", func() {
+		It("should only take the fake provider when it is named outside of production", func() {
+			provider, err := payments.NewProvider(payments.FakeProviderName, "secret", false)
+			Expect(err).To(BeNil())
+			Expect(provider.Name()).To(Equal(payments.FakeProviderName))
+
+			_, err = payments.NewProvider("", "secret", false)
+			Expect(err).To(MatchError(payments.ErrNoProvider))
+			_, err = payments.NewProvider(payments.FakeProviderName, "secret", true)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should require a webhook secret", func() {
+			_, err := payments.NewProvider(payments.FakeProviderName, "", false)
+			Expect(err).To(MatchError(payments.ErrNoProvider))
+		})
+
+		It("should reject unknown providers", func() {
+			_, err := payments.NewProvider("stripe", "secret", false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// StatusPending is the payment recorded before the provider is called, it claims the order
+	StatusPending    = "pending"
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusFailed     = "failed"
+	StatusRefunded   = "refunded"
+)
+
+var (
+	ErrDeclined         = errors.New("payment was declined")
+	ErrUnknownReference = errors.New("unknown payment reference")
+	ErrInvalidAmount    = errors.New("amount exceeds the remaining payment amount")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNoProvider       = errors.New("no payment provider is configured")
+)
+
+type AuthorizeRequest struct {
+	OrderID  string
+	Amount   float32
+	Currency string
+	// Token is the tokenized payment method handed out by the provider's checkout widget
+	Token string
+}
+
+type Result struct {
+	Reference     string
+	Status        string
+	Amount        float32
+	FailureReason string
+}
+
+type WebhookEvent struct {
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Status    string  `json:"status"`
+	Amount    float32 `json:"amount"`
+}
+
+// Provider is implemented by every payment service provider we can take payments with
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error)
+	Capture(ctx context.Context, reference string, amount float32) (*Result, error)
+	Refund(ctx context.Context, reference string, amount float32) (*Result, error)
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// NewProvider returns the provider configured by name. There is no default: the fake provider
+// authorizes almost any token, so it has to be named, and it is refused in production. The webhooks
+// can't be verified without a secret, it is required for every provider.
+func NewProvider(name string, webhookSecret string, production bool) (Provider, error) {
+	if webhookSecret == "" {
+		return nil, fmt.Errorf("%w: the webhook secret is required", ErrNoProvider)
+	}
+	switch name {
+	case "":
+		return nil, fmt.Errorf("%w: name one, e.g. %q in development", ErrNoProvider, FakeProviderName)
+	case FakeProviderName:
+		if production {
+			return nil, fmt.Errorf("the %q payment provider can't take payments in production", FakeProviderName)
+		}
+		return NewFakeProvider(webhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
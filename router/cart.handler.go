@@ -0,0 +1,38 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func CreateCartHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateCart(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
+func CartByIdHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetCartById(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
+func AddCartItemHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.AddCartItem(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
+func UpdateCartItemHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpdateCartItem(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
+func RemoveCartItemHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.RemoveCartItem(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
+func MergeCartsHandler(cartService services.CartService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.MergeCarts(w, r, services.CartsFromContext(r.Context(), cartService))
+	}
+}
@@ -6,28 +6,51 @@ import (
 	"net/http"
 )
 
-func CoffeeHandler(coffeeService services.CoffeeService) http.HandlerFunc {
+func CoffeeHandler(coffeeService services.CoffeeService, translationService services.TranslationService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		controllers.GetAllCoffees(w, r, coffeeService)
+		controllers.GetAllCoffees(w, r, services.CatalogFromContext(r.Context(), coffeeService), translations(r, translationService))
 	}
 }
-func CoffeeByIdHandler(coffeeService services.CoffeeService) http.HandlerFunc {
+func CoffeeByIdHandler(coffeeService services.CoffeeService, translationService services.TranslationService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		controllers.GetCoffeesById(w, r, coffeeService)
+		controllers.GetCoffeesById(w, r, services.CatalogFromContext(r.Context(), coffeeService), translations(r, translationService))
 	}
 }
 func CreateCoffeeHandler(coffeeService services.CoffeeService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		controllers.CreateCoffee(w, r, coffeeService)
+		controllers.CreateCoffee(w, r, services.CatalogFromContext(r.Context(), coffeeService))
 	}
 }
 func UpdateCoffeeHandler(coffeeService services.CoffeeService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		controllers.UpdateCoffeeById(w, r, coffeeService)
+		controllers.UpdateCoffeeById(w, r, services.CatalogFromContext(r.Context(), coffeeService))
 	}
 }
 func DeleteCoffeeHandler(coffeeService services.CoffeeService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		controllers.DeleteCoffee(w, r, coffeeService)
+		controllers.DeleteCoffee(w, r, services.CatalogFromContext(r.Context(), coffeeService))
 	}
 }
+func SetCoffeeSlugHandler(coffeeService services.CoffeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.SetCoffeeSlug(w, r, services.CatalogFromContext(r.Context(), coffeeService))
+	}
+}
+func ResetCoffeeSlugHandler(coffeeService services.CoffeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.ResetCoffeeSlug(w, r, services.CatalogFromContext(r.Context(), coffeeService))
+	}
+}
+func StreamCoffeesHandler(coffeeStream services.CoffeeStream) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.StreamCoffees(w, r, services.StreamFor(coffeeStream, tenantID(r)))
+	}
+}
+
+// translations returns the translations of the tenant of the request, nil when the catalog isn't translated
+func translations(r *http.Request, translationService services.TranslationService) services.TranslationService {
+	if translationService == nil {
+		return nil
+	}
+	return services.TranslationsFromContext(r.Context(), translationService)
+}
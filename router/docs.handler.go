@@ -0,0 +1,19 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func OpenAPIHandler(doc *openapi3.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.OpenAPI(w, r, doc)
+	}
+}
+func SwaggerUIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.SwaggerUI(w, r)
+	}
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/gql"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+)
+
+func GraphQLHandler(schema graphql.Schema, graphiQL bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if graphiQL && controllers.WantsGraphiQL(r) {
+			controllers.GraphiQL(w, r)
+			return
+		}
+		controllers.GraphQL(w, r, schema, gql.DefaultLimits)
+	}
+}
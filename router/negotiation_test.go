@@ -0,0 +1,60 @@
+package router_test
+
+import (
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Content negotiation", Label("unit"), func() {
+	var handler http.Handler
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/coffees", nil)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	BeforeEach(func() {
+		coffees := new(mocks.CoffeeService)
+		coffees.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1", Name: "Espresso"}}, nil)
+		handler = router.Routes(services.Models{Coffee: coffees}, router.WithValidation())
+	})
+
+	It("should serve the formats the document doesn't describe past the validation", func() {
+		recorder := serve("text/csv")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(HavePrefix("id,name,roast,image,region,price,grind_unit,created_at,updated_at\nc1,Espresso,"))
+	})
+
+	It("should refuse a type it can't serve with 406 on any route", func() {
+		recorder := serve("text/html")
+		Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+		Expect(recorder.Body.String()).To(ContainSubstring("application/msgpack"))
+	})
+
+	It("should answer the payment provider whatever it accepts", func() {
+		payments := new(mocks.PaymentService)
+		payments.On("HandleWebhook", []byte(`{}`), "abc").Return(&services.Payment{ID: "p1"}, nil)
+		handler = router.Routes(services.Models{Payment: payments})
+
+		for _, path := range []string{"/api/v1/payments/webhook", "/api/v2/payments/webhook"} {
+			request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+			request.Header.Set("Accept", "text/plain")
+			request.Header.Set("X-Payment-Signature", "abc")
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK), path)
+			Expect(recorder.Body.String()).To(ContainSubstring(`"p1"`))
+		}
+	})
+})
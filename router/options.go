@@ -0,0 +1,71 @@
+package router
+
+import "time"
+
+type options struct {
+	graphiQL     bool
+	validation   bool
+	tenantDomain string
+	tenantSecret []byte
+	v1Deprecated time.Time
+	v1Sunset     time.Time
+	readWrites   time.Duration
+}
+
+// Option changes how the routes are set up
+type Option func(*options)
+
+// WithGraphiQL serves the GraphiQL playground on /graphql, meant for development
+func WithGraphiQL() Option {
+	return func(o *options) {
+		o.graphiQL = true
+	}
+}
+
+// WithValidation checks the requests and the responses against the OpenAPI document, meant for development and tests
+func WithValidation() Option {
+	return func(o *options) {
+		o.validation = true
+	}
+}
+
+// WithTenantDomain resolves the tenant of a request to a subdomain of the domain from the subdomain,
+// e.g. the tenant "roastery" for roastery.coffee.example.com
+func WithTenantDomain(domain string) Option {
+	return func(o *options) {
+		o.tenantDomain = domain
+	}
+}
+
+// WithTenantTokens resolves the tenant of a request from the tenant claim of its bearer token, signed
+// with HS256 and the secret
+func WithTenantTokens(secret []byte) Option {
+	return func(o *options) {
+		o.tenantSecret = secret
+	}
+}
+
+// WithV1Deprecation sets the date the v1 routes were deprecated on, announced in their Deprecation header.
+// Without it they were deprecated on the release of the v2 routes.
+func WithV1Deprecation(deprecated time.Time) Option {
+	return func(o *options) {
+		o.v1Deprecated = deprecated
+	}
+}
+
+// WithV1Sunset sets the date the v1 routes are removed on, announced in their Sunset header. Without it
+// the v1 routes are only announced as deprecated.
+func WithV1Sunset(sunset time.Time) Option {
+	return func(o *options) {
+		o.v1Sunset = sunset
+	}
+}
+
+// WithReadYourWrites sends the catalog reads of a client to the primary for the window after it changed
+// something, so it reads its change despite the replication lag. The other clients and the background
+// jobs keep reading from the replicas.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(o *options) {
+		o.readWrites = window
+	}
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func OrderHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetAllOrders(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
+func OrderByIdHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetOrderById(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
+func CreateOrderHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateOrder(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
+func CancelOrderHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CancelOrder(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
+func TransitionOrderHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.TransitionOrder(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
+func OrderHistoryHandler(orderService services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetOrderHistory(w, r, services.OrdersFromContext(r.Context(), orderService))
+	}
+}
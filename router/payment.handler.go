@@ -0,0 +1,28 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func CheckoutHandler(paymentService services.PaymentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.Checkout(w, r, services.PaymentsFromContext(r.Context(), paymentService))
+	}
+}
+func OrderPaymentsHandler(paymentService services.PaymentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetPaymentsByOrder(w, r, services.PaymentsFromContext(r.Context(), paymentService))
+	}
+}
+func RefundPaymentHandler(paymentService services.PaymentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.RefundPayment(w, r, services.PaymentsFromContext(r.Context(), paymentService))
+	}
+}
+func PaymentWebhookHandler(paymentService services.PaymentService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.PaymentWebhook(w, r, paymentService)
+	}
+}
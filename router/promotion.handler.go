@@ -0,0 +1,38 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func PromotionHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetAllPromotions(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
+func PromotionByIdHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetPromotionById(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
+func CreatePromotionHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreatePromotion(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
+func DeletePromotionHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.DeletePromotion(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
+func EvaluatePromotionsHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.EvaluatePromotions(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
+func RedeemPromotionsHandler(promotionService services.PromotionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.RedeemPromotions(w, r, services.PromotionsFromContext(r.Context(), promotionService))
+	}
+}
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"coffee/coffee-server/services"
+	"net/http"
+	"time"
+)
+
+// WroteCookie marks the clients that changed something within the read-your-writes window
+const WroteCookie = "coffee_wrote"
+
+// readYourWrites sends the catalog reads of a client to the primary while its WroteCookie lasts. The
+// cookie is set for the window by every change the client makes that succeeds, so only the client that
+// wrote waits on the primary, the background jobs writing to the database don't count.
+func readYourWrites(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Cookie(WroteCookie); err == nil {
+				r = r.WithContext(services.ContextWithPrimaryReads(r.Context()))
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(&wroteResponse{ResponseWriter: w, window: window}, r)
+			}
+		})
+	}
+}
+
+// wroteResponse sets the WroteCookie on a response telling the change succeeded
+type wroteResponse struct {
+	http.ResponseWriter
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *wroteResponse) WriteHeader(status int) {
+	if !w.wroteHeader && status < http.StatusBadRequest {
+		http.SetCookie(w.ResponseWriter, &http.Cookie{
+			Name:     WroteCookie,
+			Value:    "1",
+			Path:     "/",
+			MaxAge:   int(w.window.Round(time.Second) / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *wroteResponse) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
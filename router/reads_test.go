@@ -0,0 +1,79 @@
+package router_test
+
+import (
+	"bytes"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+// replicatedCatalog is a mocked catalog reading from the replicas, primary reads from the primary
+type replicatedCatalog struct {
+	*mocks.CoffeeService
+	primary *mocks.CoffeeService
+}
+
+func (c replicatedCatalog) OnPrimary() services.CoffeeService {
+	return c.primary
+}
+
+var _ = Describe("Read your writes", Label("unit"), func() {
+	var (
+		replicas, primary *mocks.CoffeeService
+		handler           http.Handler
+	)
+
+	serve := func(request *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	BeforeEach(func() {
+		replicas = new(mocks.CoffeeService)
+		primary = new(mocks.CoffeeService)
+		catalog := replicatedCatalog{CoffeeService: replicas, primary: primary}
+		handler = router.Routes(services.Models{Coffee: catalog}, router.WithReadYourWrites(5*time.Second))
+	})
+
+	It("should mark the client that changed something for the window", func() {
+		replicas.On("CreateCoffee", mock.Anything).Return(&services.Coffee{ID: "c1", Name: "Espresso"}, nil)
+
+		recorder := serve(httptest.NewRequest(http.MethodPost, "/api/v2/coffees", bytes.NewBufferString(`{"name": "Espresso"}`)))
+
+		Expect(recorder.Code).To(Equal(http.StatusCreated))
+		cookies := recorder.Result().Cookies()
+		Expect(cookies).To(ContainElement(And(HaveField("Name", router.WroteCookie), HaveField("MaxAge", 5))))
+	})
+
+	It("should not mark a change that failed", func() {
+		replicas.On("CreateCoffee", mock.Anything).Return(nil, errors.New("boom"))
+
+		recorder := serve(httptest.NewRequest(http.MethodPost, "/api/v2/coffees", bytes.NewBufferString(`{"name": "Espresso"}`)))
+
+		Expect(recorder.Code).To(BeNumerically(">=", http.StatusBadRequest))
+		Expect(recorder.Result().Cookies()).To(BeEmpty())
+	})
+
+	It("should read from the primary for a marked client only", func() {
+		replicas.On("GetAllCoffees").Return([]*services.Coffee{}, nil)
+		primary.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1"}}, nil)
+
+		Expect(serve(httptest.NewRequest(http.MethodGet, "/api/v2/coffees", nil)).Code).To(Equal(http.StatusOK))
+		replicas.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 1)
+
+		request := httptest.NewRequest(http.MethodGet, "/api/v2/coffees", nil)
+		request.AddCookie(&http.Cookie{Name: router.WroteCookie, Value: "1"})
+		Expect(serve(request).Code).To(Equal(http.StatusOK))
+		primary.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 1)
+		replicas.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 1)
+	})
+})
@@ -1,31 +1,155 @@
 package router
 
 import (
+	"coffee/coffee-server/gql"
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/openapi"
 	"coffee/coffee-server/services"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 )
 
-func Routes(coffeeService services.CoffeeService) http.Handler {
+func Routes(models services.Models, opts ...Option) http.Handler {
+	// The v2 routes replaced the v1 ones on their release
+	o := options{v1Deprecated: time.Date(2024, time.November, 4, 0, 0, 0, 0, time.UTC)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	coffeeService := models.Coffee
+	coffeeStream := models.CoffeeStream
+	orderService := models.Order
+	cartService := models.Cart
+	paymentService := models.Payment
+	promotionService := models.Promotion
+	webhookService := models.Webhook
+	tenantService := models.Tenant
+	translationService := models.Translation
+
+	schema, err := gql.NewSchema(coffeeService)
+	if err != nil {
+		panic(err)
+	}
+
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
+
 	router := chi.NewRouter()
 	router.Use(middleware.Recoverer)
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://*", "https://*"},
 		AllowedMethods:   []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Deprecation", "Sunset"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	// Before the validation, a request to an unknown tenant never reaches the routes
+	if tenantService != nil {
+		router.Use(newTenantResolver(tenantService, o).Middleware)
+	}
+	if o.validation {
+		validator, err := openapi.Validator(doc)
+		if err != nil {
+			panic(err)
+		}
+		router.Use(validator)
+	}
+	router.Use(deprecateV1(o.v1Deprecated, o.v1Sunset))
+	if o.readWrites > 0 {
+		router.Use(readYourWrites(o.readWrites))
+	}
+	// The streams answer events, not a representation of a resource, and the payment provider calling the
+	// webhook takes whatever it is answered: they aren't negotiated
+	api := router.With(helpers.Negotiate)
+
+	api.Get("/api/v1/coffees", CoffeeHandler(coffeeService, translationService))
+	router.Get("/api/v1/coffees/stream", StreamCoffeesHandler(coffeeStream))
+	api.Get("/api/v1/coffees/coffee/{id}", CoffeeByIdHandler(coffeeService, translationService))
+	api.Post("/api/v1/coffees/coffee", CreateCoffeeHandler(coffeeService))
+	api.Put("/api/v1/coffees/coffee/{id}", UpdateCoffeeHandler(coffeeService))
+	api.Delete("/api/v1/coffees/coffee/{id}", DeleteCoffeeHandler(coffeeService))
+	api.Put("/api/v1/coffees/coffee/{id}/slug", SetCoffeeSlugHandler(coffeeService))
+	api.Delete("/api/v1/coffees/coffee/{id}/slug", ResetCoffeeSlugHandler(coffeeService))
+	// A store without translations, orders, payments, promotions, carts, webhooks or tenants, e.g. the
+	// memory one, has no routes for them: they answer 404 instead of failing on a nil service
+	if translationService != nil {
+		api.Get("/api/v1/coffees/coffee/{id}/translations", TranslationsHandler(translationService))
+		api.Put("/api/v1/coffees/coffee/{id}/translations/{lang}", PutTranslationHandler(translationService))
+		api.Delete("/api/v1/coffees/coffee/{id}/translations/{lang}", DeleteTranslationHandler(translationService))
+
+		api.Get("/api/v1/translations/labels", LabelsHandler(translationService))
+		api.Put("/api/v1/translations/labels", PutLabelHandler(translationService))
+		api.Delete("/api/v1/translations/labels", DeleteLabelHandler(translationService))
+		api.Get("/api/v1/translations/missing", MissingTranslationsHandler(translationService))
+	}
+
+	if orderService != nil {
+		api.Get("/api/v1/orders", OrderHandler(orderService))
+		api.Get("/api/v1/orders/{id}", OrderByIdHandler(orderService))
+		api.Post("/api/v1/orders", CreateOrderHandler(orderService))
+		api.Post("/api/v1/orders/{id}/cancel", CancelOrderHandler(orderService))
+		api.Post("/api/v1/orders/{id}/transitions", TransitionOrderHandler(orderService))
+		api.Get("/api/v1/orders/{id}/history", OrderHistoryHandler(orderService))
+	}
+
+	if paymentService != nil {
+		api.Post("/api/v1/orders/{id}/checkout", CheckoutHandler(paymentService))
+		api.Get("/api/v1/orders/{id}/payments", OrderPaymentsHandler(paymentService))
+		api.Post("/api/v1/payments/{id}/refund", RefundPaymentHandler(paymentService))
+		router.Post("/api/v1/payments/webhook", PaymentWebhookHandler(paymentService))
+	}
+
+	if promotionService != nil {
+		api.Get("/api/v1/promotions", PromotionHandler(promotionService))
+		api.Get("/api/v1/promotions/{id}", PromotionByIdHandler(promotionService))
+		api.Post("/api/v1/promotions", CreatePromotionHandler(promotionService))
+		api.Delete("/api/v1/promotions/{id}", DeletePromotionHandler(promotionService))
+		api.Post("/api/v1/promotions/evaluate", EvaluatePromotionsHandler(promotionService))
+		api.Post("/api/v1/promotions/redeem", RedeemPromotionsHandler(promotionService))
+	}
+
+	if cartService != nil {
+		api.Post("/api/v1/carts", CreateCartHandler(cartService))
+		api.Get("/api/v1/carts/{id}", CartByIdHandler(cartService))
+		api.Post("/api/v1/carts/{id}/items", AddCartItemHandler(cartService))
+		api.Put("/api/v1/carts/{id}/items/{itemId}", UpdateCartItemHandler(cartService))
+		api.Delete("/api/v1/carts/{id}/items/{itemId}", RemoveCartItemHandler(cartService))
+		api.Post("/api/v1/carts/{id}/merge", MergeCartsHandler(cartService))
+	}
+
+	if webhookService != nil {
+		api.Get("/api/v1/webhooks", SubscriptionHandler(webhookService))
+		api.Post("/api/v1/webhooks", CreateSubscriptionHandler(webhookService))
+		api.Delete("/api/v1/webhooks/{id}", DeleteSubscriptionHandler(webhookService))
+		api.Get("/api/v1/webhooks/{id}/deliveries", DeliveriesHandler(webhookService))
+		api.Get("/api/v1/webhooks/deliveries/dead", DeadDeliveriesHandler(webhookService))
+		api.Post("/api/v1/webhooks/deliveries/{deliveryId}/redeliver", RedeliverHandler(webhookService))
+	}
+
+	api.Get("/api/v1/tenant", CurrentTenantHandler())
+	if tenantService != nil {
+		api.Get("/api/v1/tenants", TenantHandler(tenantService))
+		api.Post("/api/v1/tenants", CreateTenantHandler(tenantService))
+		api.Get("/api/v1/tenants/{id}", TenantByIdHandler(tenantService))
+		api.Put("/api/v1/tenants/{id}/config", UpdateTenantConfigHandler(tenantService))
+	}
+
+	router.Route("/api/v2", func(v2 chi.Router) {
+		routesV2(v2, models)
+	})
+
+	router.Get("/graphql", GraphQLHandler(schema, o.graphiQL))
+	router.Post("/graphql", GraphQLHandler(schema, o.graphiQL))
 
-	router.Get("/api/v1/coffees", CoffeeHandler(coffeeService))
-	router.Get("/api/v1/coffees/coffee/{id}", CoffeeByIdHandler(coffeeService))
-	router.Post("/api/v1/coffees/coffee", CreateCoffeeHandler(coffeeService))
-	router.Put("/api/v1/coffees/coffee/{id}", UpdateCoffeeHandler(coffeeService))
-	router.Delete("/api/v1/coffees/coffee/{id}", DeleteCoffeeHandler(coffeeService))
+	router.Get("/openapi.json", OpenAPIHandler(doc))
+	router.Get("/docs", SwaggerUIHandler())
 
 	return router
 }
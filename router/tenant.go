@@ -0,0 +1,185 @@
+package router
+
+import (
+	"coffee/coffee-server/cache"
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// TenantHeader names the tenant of a request by its slug
+	TenantHeader = "X-Tenant"
+	// TenantClaim is the claim of a bearer token naming the tenant by its slug
+	TenantClaim = "tenant"
+
+	// The resolved tenants are kept this long, a change of their configuration shows up after it
+	tenantCacheTTL  = time.Minute
+	tenantCacheSize = 1000
+)
+
+var (
+	errInvalidToken = errors.New("invalid bearer token")
+	errTenantHeader = errors.New("the X-Tenant header doesn't match the tenant of the bearer token")
+)
+
+// tenantResolver finds the tenant of each request, from the first of: the claim of a bearer token
+// signed with the secret, the X-Tenant header, the subdomain of the domain. A request naming none is
+// made to the default tenant. Once tokens are signed, the header may only repeat the claim of the
+// token: a client can't pick a shop its token wasn't given for.
+type tenantResolver struct {
+	Tenants services.TenantService
+	Domain  string
+	Secret  []byte
+	Cache   cache.Store
+	Now     func() time.Time
+}
+
+func newTenantResolver(tenants services.TenantService, o options) *tenantResolver {
+	return &tenantResolver{
+		Tenants: tenants,
+		Domain:  strings.ToLower(strings.Trim(o.tenantDomain, ".")),
+		Secret:  o.tenantSecret,
+		Cache:   cache.NewLRU(tenantCacheSize),
+		Now:     time.Now,
+	}
+}
+
+// Middleware adds the tenant to the context of the requests, a request to an unknown tenant is refused
+func (t *tenantResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slug, err := t.slug(r)
+		if err != nil {
+			helpers.ErrorJson(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		var tenant *services.Tenant
+		if slug == "" {
+			tenant, err = t.lookup("id:"+services.DefaultTenant, func() (*services.Tenant, error) {
+				return t.Tenants.GetTenantById(services.DefaultTenant)
+			})
+		} else {
+			tenant, err = t.lookup("slug:"+slug, func() (*services.Tenant, error) {
+				return t.Tenants.GetTenantBySlug(slug)
+			})
+		}
+		if err != nil {
+			controllers.TenantError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(services.ContextWithTenant(r.Context(), tenant)))
+	})
+}
+
+// slug returns the slug of the tenant named by the request, "" when it names none
+func (t *tenantResolver) slug(r *http.Request) (string, error) {
+	header := strings.ToLower(strings.TrimSpace(r.Header.Get(TenantHeader)))
+
+	if len(t.Secret) > 0 {
+		var claim string
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			var err error
+			if claim, err = tenantClaim(token, t.Secret, t.Now()); err != nil {
+				return "", err
+			}
+		}
+		if header != "" && header != claim {
+			return "", errTenantHeader
+		}
+		if claim != "" {
+			return claim, nil
+		}
+	} else if header != "" {
+		return header, nil
+	}
+	if t.Domain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if sub, ok := strings.CutSuffix(strings.ToLower(host), "."+t.Domain); ok && !strings.Contains(sub, ".") {
+			return sub, nil
+		}
+	}
+	return "", nil
+}
+
+func (t *tenantResolver) lookup(key string, load func() (*services.Tenant, error)) (*services.Tenant, error) {
+	if data, ok := t.Cache.Get(key); ok {
+		var tenant services.Tenant
+		if err := json.Unmarshal(data, &tenant); err == nil {
+			return &tenant, nil
+		}
+	}
+
+	tenant, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(tenant); err == nil {
+		t.Cache.Set(key, data, tenantCacheTTL)
+	}
+	return tenant, nil
+}
+
+// tenantClaim verifies the HS256 token and returns its tenant claim, "" when it has none
+func tenantClaim(token string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", errInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errInvalidToken
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", errInvalidToken
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return "", errors.New("bearer token has expired")
+	}
+	slug, _ := claims[TenantClaim].(string)
+	return strings.ToLower(slug), nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// tenantID returns the id of the tenant of the request, "" when none was resolved
+func tenantID(r *http.Request) string {
+	if tenant, ok := services.TenantFromContext(r.Context()); ok {
+		return tenant.ID
+	}
+	return ""
+}
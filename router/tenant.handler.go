@@ -0,0 +1,33 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func CurrentTenantHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetCurrentTenant(w, r)
+	}
+}
+func TenantHandler(tenantService services.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetAllTenants(w, r, tenantService)
+	}
+}
+func TenantByIdHandler(tenantService services.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetTenantById(w, r, tenantService)
+	}
+}
+func CreateTenantHandler(tenantService services.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateTenant(w, r, tenantService)
+	}
+}
+func UpdateTenantConfigHandler(tenantService services.TenantService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpdateTenantConfig(w, r, tenantService)
+	}
+}
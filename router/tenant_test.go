@@ -0,0 +1,116 @@
+package router_test
+
+import (
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tenant resolution", Label("unit"), func() {
+	var (
+		tenants *mocks.TenantService
+		handler http.Handler
+	)
+
+	secret := []byte("tenant-secret")
+	defaultTenant := &services.Tenant{ID: services.DefaultTenant, Slug: services.DefaultTenantSlug}
+	roastery := &services.Tenant{ID: "t1", Slug: "roastery"}
+
+	// token signs the claims with HS256
+	token := func(claims map[string]interface{}, key []byte) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+		payload, _ := json.Marshal(claims)
+		unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	serve := func(request *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// resolve returns the slug of the tenant the request was made to
+	resolve := func(request *http.Request) string {
+		recorder := serve(request)
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		var body struct {
+			Tenant services.Tenant `json:"tenant"`
+		}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &body)).To(Succeed())
+		return body.Tenant.Slug
+	}
+
+	BeforeEach(func() {
+		tenants = new(mocks.TenantService)
+		tenants.On("GetTenantById", services.DefaultTenant).Return(defaultTenant, nil).Maybe()
+		tenants.On("GetTenantBySlug", "roastery").Return(roastery, nil).Maybe()
+		tenants.On("GetTenantBySlug", "nope").Return(nil, services.ErrTenantNotFound).Maybe()
+		handler = router.Routes(services.Models{Tenant: tenants}, router.WithTenantDomain("coffee.example.com"), router.WithTenantTokens(secret))
+	})
+
+	It("should make a request naming no tenant to the default one", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://coffee.example.com/api/v1/tenant", nil)
+		Expect(resolve(request)).To(Equal(services.DefaultTenantSlug))
+	})
+
+	It("should resolve the tenant from the header or the subdomain", func() {
+		handler = router.Routes(services.Models{Tenant: tenants}, router.WithTenantDomain("coffee.example.com"))
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+		request.Header.Set(router.TenantHeader, "Roastery")
+		Expect(resolve(request)).To(Equal("roastery"))
+
+		request = httptest.NewRequest(http.MethodGet, "http://roastery.coffee.example.com:8080/api/v1/tenant", nil)
+		Expect(resolve(request)).To(Equal("roastery"))
+
+		// Each tenant is looked up once
+		tenants.AssertNumberOfCalls(GinkgoT(), "GetTenantBySlug", 1)
+	})
+
+	It("should prefer the claim of a valid token", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://coffee.example.com/api/v1/tenant", nil)
+		request.Header.Set("Authorization", "Bearer "+token(map[string]interface{}{"tenant": "roastery"}, secret))
+		Expect(resolve(request)).To(Equal("roastery"))
+
+		request.Header.Set(router.TenantHeader, "Roastery")
+		Expect(resolve(request)).To(Equal("roastery"))
+	})
+
+	It("should only take a header repeating the claim of the token once tokens are signed", func() {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+		request.Header.Set(router.TenantHeader, "roastery")
+		Expect(serve(request).Code).To(Equal(http.StatusUnauthorized))
+
+		request.Header.Set("Authorization", "Bearer "+token(map[string]interface{}{"sub": "user-1"}, secret))
+		Expect(serve(request).Code).To(Equal(http.StatusUnauthorized))
+
+		request.Header.Set("Authorization", "Bearer "+token(map[string]interface{}{"tenant": "default"}, secret))
+		Expect(serve(request).Code).To(Equal(http.StatusUnauthorized))
+		tenants.AssertNotCalled(GinkgoT(), "GetTenantBySlug", "roastery")
+	})
+
+	It("should refuse the tokens it can't trust", func() {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/tenant", nil)
+		request.Header.Set("Authorization", "Bearer "+token(map[string]interface{}{"tenant": "roastery"}, []byte("forged")))
+		Expect(serve(request).Code).To(Equal(http.StatusUnauthorized))
+
+		request.Header.Set("Authorization", "Bearer "+token(map[string]interface{}{"tenant": "roastery", "exp": 1}, secret))
+		Expect(serve(request).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should refuse a request to an unknown tenant", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://nope.coffee.example.com/api/v1/tenant", nil)
+		Expect(serve(request).Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func TranslationsHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetTranslations(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func PutTranslationHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.PutTranslation(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func DeleteTranslationHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.DeleteTranslation(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func LabelsHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetLabels(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func PutLabelHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.PutLabel(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func DeleteLabelHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.DeleteLabel(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
+func MissingTranslationsHandler(translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetMissingTranslations(w, r, services.TranslationsFromContext(r.Context(), translationService))
+	}
+}
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/helpers"
+	"coffee/coffee-server/services"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// routesV2 serves the resources under REST paths: a resource is named by its id right after its
+// collection, /coffees/{id} instead of /coffees/coffee/{id}. A single resource is answered under its
+// singular name, a created one with 201 and a deleted one with 204, and every error, an unknown route
+// too, has the shape of services.JsonResponse.
+func routesV2(v2 chi.Router, models services.Models) {
+	coffeeService := models.Coffee
+	translationService := models.Translation
+
+	v2.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		helpers.ErrorJson(w, fmt.Errorf("no route for %s", r.URL.Path), http.StatusNotFound)
+	})
+	v2.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		helpers.ErrorJson(w, fmt.Errorf("%s isn't allowed on %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+	})
+	api := v2.With(helpers.Negotiate)
+
+	api.Get("/coffees", CoffeeHandler(coffeeService, translationService))
+	api.Post("/coffees", CreateCoffeeV2Handler(coffeeService))
+	v2.Get("/coffees/stream", StreamCoffeesHandler(models.CoffeeStream))
+	api.Get("/coffees/{id}", CoffeeV2Handler(coffeeService, translationService))
+	api.Put("/coffees/{id}", UpdateCoffeeV2Handler(coffeeService))
+	api.Delete("/coffees/{id}", noContent(DeleteCoffeeV2Handler(coffeeService)))
+	api.Put("/coffees/{id}/slug", SetCoffeeSlugHandler(coffeeService))
+	api.Delete("/coffees/{id}/slug", ResetCoffeeSlugHandler(coffeeService))
+	// The services missing from the store have no routes, like the v1 ones
+	if translationService != nil {
+		api.Get("/coffees/{id}/translations", TranslationsHandler(translationService))
+		api.Put("/coffees/{id}/translations/{lang}", PutTranslationHandler(translationService))
+		api.Delete("/coffees/{id}/translations/{lang}", noContent(DeleteTranslationHandler(translationService)))
+
+		api.Get("/translations/labels", LabelsHandler(translationService))
+		api.Put("/translations/labels", PutLabelHandler(translationService))
+		api.Delete("/translations/labels", noContent(DeleteLabelHandler(translationService)))
+		api.Get("/translations/missing", MissingTranslationsHandler(translationService))
+	}
+
+	if models.Order != nil {
+		api.Get("/orders", OrderHandler(models.Order))
+		api.Post("/orders", CreateOrderHandler(models.Order))
+		api.Get("/orders/{id}", OrderByIdHandler(models.Order))
+		api.Post("/orders/{id}/cancel", CancelOrderHandler(models.Order))
+		api.Post("/orders/{id}/transitions", TransitionOrderHandler(models.Order))
+		api.Get("/orders/{id}/history", OrderHistoryHandler(models.Order))
+	}
+
+	if models.Payment != nil {
+		api.Post("/orders/{id}/checkout", CheckoutHandler(models.Payment))
+		api.Get("/orders/{id}/payments", OrderPaymentsHandler(models.Payment))
+		api.Post("/payments/{id}/refund", RefundPaymentHandler(models.Payment))
+		v2.Post("/payments/webhook", PaymentWebhookHandler(models.Payment))
+	}
+
+	if models.Promotion != nil {
+		api.Get("/promotions", PromotionHandler(models.Promotion))
+		api.Post("/promotions", CreatePromotionHandler(models.Promotion))
+		api.Get("/promotions/{id}", PromotionByIdHandler(models.Promotion))
+		api.Delete("/promotions/{id}", noContent(DeletePromotionHandler(models.Promotion)))
+		api.Post("/promotions/evaluate", EvaluatePromotionsHandler(models.Promotion))
+		api.Post("/promotions/redeem", RedeemPromotionsHandler(models.Promotion))
+	}
+
+	if models.Cart != nil {
+		api.Post("/carts", CreateCartHandler(models.Cart))
+		api.Get("/carts/{id}", CartByIdHandler(models.Cart))
+		api.Post("/carts/{id}/items", AddCartItemHandler(models.Cart))
+		api.Put("/carts/{id}/items/{itemId}", UpdateCartItemHandler(models.Cart))
+		api.Delete("/carts/{id}/items/{itemId}", RemoveCartItemHandler(models.Cart))
+		api.Post("/carts/{id}/merge", MergeCartsHandler(models.Cart))
+	}
+
+	if models.Webhook != nil {
+		api.Get("/webhooks", SubscriptionHandler(models.Webhook))
+		api.Post("/webhooks", CreateSubscriptionHandler(models.Webhook))
+		api.Delete("/webhooks/{id}", noContent(DeleteSubscriptionHandler(models.Webhook)))
+		api.Get("/webhooks/{id}/deliveries", DeliveriesHandler(models.Webhook))
+		api.Get("/webhooks/deliveries/dead", DeadDeliveriesHandler(models.Webhook))
+		api.Post("/webhooks/deliveries/{deliveryId}/redeliver", RedeliverHandler(models.Webhook))
+	}
+
+	api.Get("/tenant", CurrentTenantHandler())
+	if models.Tenant != nil {
+		api.Get("/tenants", TenantHandler(models.Tenant))
+		api.Post("/tenants", CreateTenantHandler(models.Tenant))
+		api.Get("/tenants/{id}", TenantByIdHandler(models.Tenant))
+		api.Put("/tenants/{id}/config", UpdateTenantConfigHandler(models.Tenant))
+	}
+}
+
+func CoffeeV2Handler(coffeeService services.CoffeeService, translationService services.TranslationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetCoffeeV2(w, r, services.CatalogFromContext(r.Context(), coffeeService), translations(r, translationService))
+	}
+}
+func DeleteCoffeeV2Handler(coffeeService services.CoffeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.DeleteCoffeeV2(w, r, services.CatalogFromContext(r.Context(), coffeeService))
+	}
+}
+func CreateCoffeeV2Handler(coffeeService services.CoffeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateCoffeeV2(w, r, services.CatalogFromContext(r.Context(), coffeeService))
+	}
+}
+
+// UpdateCoffeeV2Handler reads the updated coffee back from the primary, a replica may not have the update yet
+func UpdateCoffeeV2Handler(coffeeService services.CoffeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpdateCoffeeV2(w, r, services.CatalogFromContext(services.ContextWithPrimaryReads(r.Context()), coffeeService))
+	}
+}
+
+// noContent answers 204 for the handlers writing nothing when they succeed, e.g. the deletes
+func noContent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		written := &writtenResponse{ResponseWriter: w}
+		next(written, r)
+		if !written.written {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+type writtenResponse struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *writtenResponse) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writtenResponse) Write(p []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
+
+// deprecateV1 tells the clients of the v1 routes they are deprecated (RFC 9745) and when they go away
+// (RFC 8594), linking to the v2 route replacing each of them. Without a sunset date no Sunset is sent,
+// the routes have no date to go away on yet.
+func deprecateV1(deprecated time.Time, sunset time.Time) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", deprecated.Unix())
+	sunsetDate := ""
+	if !sunset.IsZero() {
+		sunsetDate = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+				w.Header().Set("Deprecation", deprecation)
+				if sunsetDate != "" {
+					w.Header().Set("Sunset", sunsetDate)
+				}
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successorV2(r.URL.Path)))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// successorV2 returns the v2 path of a v1 one
+func successorV2(path string) string {
+	if id, ok := strings.CutPrefix(path, "/api/v1/coffees/coffee"); ok {
+		return "/api/v2/coffees" + id
+	}
+	return "/api/v2/" + strings.TrimPrefix(path, "/api/v1/")
+}
@@ -0,0 +1,154 @@
+package router_test
+
+import (
+	"bytes"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/router"
+	"coffee/coffee-server/services"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("API v2", Label("unit"), func() {
+	var (
+		coffees *mocks.CoffeeService
+		handler http.Handler
+	)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	BeforeEach(func() {
+		coffees = new(mocks.CoffeeService)
+		handler = router.Routes(services.Models{Coffee: coffees}, router.WithValidation(), router.WithV1Sunset(time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should answer a created coffee with 201 under coffee", func() {
+		coffees.On("CreateCoffee", mock.MatchedBy(func(c services.Coffee) bool { return c.Name == "Espresso" })).
+			Return(&services.Coffee{ID: "c1", Name: "Espresso"}, nil)
+
+		recorder := serve(http.MethodPost, "/api/v2/coffees", `{"name": "Espresso"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusCreated))
+		Expect(recorder.Header().Get("Location")).To(Equal("/api/v2/coffees/c1"))
+		var body map[string]services.Coffee
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &body)).To(Succeed())
+		Expect(body).To(HaveKey("coffee"))
+		Expect(recorder.Header().Get("Deprecation")).To(BeEmpty())
+	})
+
+	It("should answer an unreadable body with 400 and an unknown coffee with 404", func() {
+		Expect(serve(http.MethodPost, "/api/v2/coffees", `{"name": `).Code).To(Equal(http.StatusBadRequest))
+
+		coffees.On("GetCoffeesById", "nope").Return(nil, sql.ErrNoRows)
+		recorder := serve(http.MethodGet, "/api/v2/coffees/nope", "")
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"message": "coffee not found"`))
+	})
+
+	It("should answer the stored coffee after an update", func() {
+		coffees.On("UpdateCoffee", "c1", mock.Anything).Return(&services.Coffee{Name: "Ristretto"}, nil)
+		coffees.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Ristretto"}, nil)
+
+		recorder := serve(http.MethodPut, "/api/v2/coffees/c1", `{"name": "Ristretto"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"id": "c1"`))
+	})
+
+	It("should read the updated coffee back from the primary", func() {
+		primary := new(mocks.CoffeeService)
+		handler = router.Routes(services.Models{Coffee: replicatedCatalog{CoffeeService: coffees, primary: primary}})
+		primary.On("UpdateCoffee", "c1", mock.Anything).Return(&services.Coffee{Name: "Ristretto"}, nil)
+		primary.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Ristretto"}, nil)
+
+		recorder := serve(http.MethodPut, "/api/v2/coffees/c1", `{"name": "Ristretto"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"name": "Ristretto"`))
+		coffees.AssertNotCalled(GinkgoT(), "GetCoffeesById", mock.Anything)
+	})
+
+	It("should answer a delete with 204", func() {
+		coffees.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1"}, nil)
+		coffees.On("DeleteCoffee", "c1").Return(nil)
+
+		recorder := serve(http.MethodDelete, "/api/v2/coffees/c1", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		Expect(recorder.Body.Len()).To(BeZero())
+	})
+
+	It("should answer the delete of an unknown coffee with 404", func() {
+		coffees.On("GetCoffeesById", "nope").Return(nil, sql.ErrNoRows)
+		coffees.On("GetCoffeesById", "espresso").Return(&services.Coffee{ID: "c1", Slug: "espresso"}, nil)
+
+		Expect(serve(http.MethodDelete, "/api/v2/coffees/nope", "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(http.MethodDelete, "/api/v2/coffees/espresso", "").Code).To(Equal(http.StatusNotFound))
+		coffees.AssertNotCalled(GinkgoT(), "DeleteCoffee", mock.Anything)
+	})
+
+	It("should answer the unknown routes and methods with an error body", func() {
+		recorder := serve(http.MethodGet, "/api/v2/teas", "")
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"error": true`))
+
+		recorder = serve(http.MethodPatch, "/api/v2/coffees/c1", "")
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"error": true`))
+	})
+
+	It("should have no routes for the services the store lacks", func() {
+		handler = router.Routes(services.NewMemory())
+
+		for _, path := range []string{"/api/v1/orders", "/api/v2/orders", "/api/v2/carts/c1", "/api/v2/promotions", "/api/v2/webhooks"} {
+			Expect(serve(http.MethodGet, path, "").Code).To(Equal(http.StatusNotFound), path)
+		}
+		Expect(serve(http.MethodPost, "/api/v2/orders/o1/checkout", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should keep serving v1, deprecated in favor of v2", func() {
+		coffees.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1"}, nil)
+
+		recorder := serve(http.MethodGet, "/api/v1/coffees/coffee/c1", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Deprecation")).To(Equal("@1730678400"))
+		Expect(recorder.Header().Get("Sunset")).To(Equal("Mon, 30 Jun 2025 00:00:00 GMT"))
+		Expect(recorder.Header().Get("Link")).To(Equal(`</api/v2/coffees/c1>; rel="successor-version"`))
+	})
+
+	It("should not announce a sunset that wasn't set", func() {
+		coffees.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1"}, nil)
+		handler = router.Routes(services.Models{Coffee: coffees})
+
+		recorder := serve(http.MethodGet, "/api/v1/coffees/coffee/c1", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Deprecation")).To(Equal("@1730678400"))
+		Expect(recorder.Header()).NotTo(HaveKey("Sunset"))
+	})
+
+	It("should announce the deprecation date it was given", func() {
+		coffees.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1"}, nil)
+		handler = router.Routes(services.Models{Coffee: coffees}, router.WithV1Deprecation(time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)))
+
+		recorder := serve(http.MethodGet, "/api/v1/coffees/coffee/c1", "")
+
+		Expect(recorder.Header().Get("Deprecation")).To(Equal("@1733011200"))
+	})
+})
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"coffee/coffee-server/controllers"
+	"coffee/coffee-server/services"
+	"net/http"
+)
+
+func SubscriptionHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetAllSubscriptions(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
+func CreateSubscriptionHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateSubscription(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
+func DeleteSubscriptionHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.DeleteSubscription(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
+func DeliveriesHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetDeliveries(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
+func DeadDeliveriesHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetDeadDeliveries(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
+func RedeliverHandler(webhookService services.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controllers.Redeliver(w, r, services.WebhooksFromContext(r.Context(), webhookService))
+	}
+}
@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"coffee/coffee-server/catalogpb"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/services"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CatalogServer implements the CoffeeCatalog gRPC service on top of the coffee service
+type CatalogServer struct {
+	catalogpb.UnimplementedCoffeeCatalogServer
+	Coffees services.CoffeeService
+	Stream  services.CoffeeStream
+}
+
+func NewCatalogServer(coffees services.CoffeeService, stream services.CoffeeStream) *CatalogServer {
+	return &CatalogServer{Coffees: coffees, Stream: stream}
+}
+
+// NewServer returns a gRPC server with the catalog registered
+func NewServer(models services.Models) *grpc.Server {
+	server := grpc.NewServer()
+	catalogpb.RegisterCoffeeCatalogServer(server, NewCatalogServer(models.Coffee, models.CoffeeStream))
+	return server
+}
+
+// errorStatus maps the service errors to the gRPC status returned to the client
+func errorStatus(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, services.ErrCoffeeNotFound):
+		return status.Error(codes.NotFound, "coffee not found")
+	case errors.Is(err, services.ErrDatabaseUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (s *CatalogServer) ListCoffees(ctx context.Context, req *catalogpb.ListCoffeesRequest) (*catalogpb.ListCoffeesResponse, error) {
+	all, err := s.Coffees.GetAllCoffees()
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	res := &catalogpb.ListCoffeesResponse{Coffees: make([]*catalogpb.Coffee, 0, len(all))}
+	for _, coffee := range all {
+		res.Coffees = append(res.Coffees, toProto(coffee))
+	}
+	return res, nil
+}
+
+func (s *CatalogServer) GetCoffee(ctx context.Context, req *catalogpb.GetCoffeeRequest) (*catalogpb.Coffee, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	coffee, err := s.Coffees.GetCoffeesById(req.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return toProto(coffee), nil
+}
+
+func (s *CatalogServer) CreateCoffee(ctx context.Context, req *catalogpb.CreateCoffeeRequest) (*catalogpb.Coffee, error) {
+	if req.GetCoffee() == nil {
+		return nil, status.Error(codes.InvalidArgument, "coffee is required")
+	}
+
+	created, err := s.Coffees.CreateCoffee(fromProto(req.GetCoffee()))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	return toProto(created), nil
+}
+
+func (s *CatalogServer) UpdateCoffee(ctx context.Context, req *catalogpb.UpdateCoffeeRequest) (*catalogpb.Coffee, error) {
+	if req.GetId() == "" || req.GetCoffee() == nil {
+		return nil, status.Error(codes.InvalidArgument, "id and coffee are required")
+	}
+
+	updated, err := s.Coffees.UpdateCoffee(req.GetId(), fromProto(req.GetCoffee()))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	updated.ID = req.GetId()
+	return toProto(updated), nil
+}
+
+func (s *CatalogServer) DeleteCoffee(ctx context.Context, req *catalogpb.DeleteCoffeeRequest) (*catalogpb.DeleteCoffeeResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if err := s.Coffees.DeleteCoffee(req.GetId()); err != nil {
+		return nil, errorStatus(err)
+	}
+	return &catalogpb.DeleteCoffeeResponse{}, nil
+}
+
+// WatchCoffees sends the catalog changes, first the ones after after_event_id when it is set.
+// A client that falls behind gets Unavailable and resumes from the last event id it received.
+func (s *CatalogServer) WatchCoffees(req *catalogpb.WatchCoffeesRequest, stream grpc.ServerStreamingServer[catalogpb.CoffeeEvent]) error {
+	live, unsubscribe := s.Stream.Subscribe()
+	defer unsubscribe()
+
+	sent := map[int64]bool{}
+	if req.GetAfterEventId() > 0 {
+		missed, err := s.Stream.Replay(req.GetAfterEventId())
+		if err != nil {
+			return errorStatus(err)
+		}
+		for _, event := range missed {
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+			sent[event.ID] = true
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-live:
+			if !ok {
+				return status.Error(codes.Unavailable, "fell behind the catalog changes, resume from the last event id")
+			}
+			if sent[event.ID] {
+				continue
+			}
+			if err := sendEvent(stream, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sendEvent(stream grpc.ServerStreamingServer[catalogpb.CoffeeEvent], event events.Event) error {
+	msg := &catalogpb.CoffeeEvent{
+		Id:         event.ID,
+		CoffeeId:   event.AggregateID,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+
+	switch event.Type {
+	case services.EventCoffeeCreated:
+		msg.Type = catalogpb.EventType_EVENT_TYPE_CREATED
+	case services.EventCoffeeUpdated:
+		msg.Type = catalogpb.EventType_EVENT_TYPE_UPDATED
+	case services.EventCoffeeDeleted:
+		msg.Type = catalogpb.EventType_EVENT_TYPE_DELETED
+	}
+
+	if msg.Type != catalogpb.EventType_EVENT_TYPE_DELETED {
+		var coffee services.Coffee
+		if err := json.Unmarshal(event.Payload, &coffee); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		msg.Coffee = toProto(&coffee)
+	}
+	return stream.Send(msg)
+}
+
+func toProto(coffee *services.Coffee) *catalogpb.Coffee {
+	msg := &catalogpb.Coffee{
+		Id:        coffee.ID,
+		Name:      coffee.Name,
+		Roast:     coffee.Roast,
+		Image:     coffee.Image,
+		Region:    coffee.Region,
+		Price:     coffee.Price,
+		GrindUnit: int32(coffee.GrindUnit),
+	}
+	if !coffee.CreatedAt.IsZero() {
+		msg.CreatedAt = timestamppb.New(coffee.CreatedAt)
+	}
+	if !coffee.UpdatedAt.IsZero() {
+		msg.UpdatedAt = timestamppb.New(coffee.UpdatedAt)
+	}
+	return msg
+}
+
+func fromProto(msg *catalogpb.Coffee) services.Coffee {
+	return services.Coffee{
+		Name:      msg.GetName(),
+		Roast:     msg.GetRoast(),
+		Image:     msg.GetImage(),
+		Region:    msg.GetRegion(),
+		Price:     msg.GetPrice(),
+		GrindUnit: int16(msg.GetGrindUnit()),
+	}
+}
@@ -0,0 +1,127 @@
+package rpc_test
+
+import (
+	"coffee/coffee-server/catalogpb"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/rpc"
+	"coffee/coffee-server/services"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ = Describe("Catalog server", Label("unit"), func() {
+	var (
+		mockedCoffee *mocks.CoffeeService
+		mockedStream *mocks.CoffeeStream
+		live         chan events.Event
+		server       *grpc.Server
+		conn         *grpc.ClientConn
+		client       catalogpb.CoffeeCatalogClient
+	)
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+		mockedStream = new(mocks.CoffeeStream)
+		live = make(chan events.Event, 4)
+		mockedStream.On("Subscribe").Return((<-chan events.Event)(live), func() {})
+
+		lis := bufconn.Listen(1024 * 1024)
+		server = grpc.NewServer()
+		catalogpb.RegisterCoffeeCatalogServer(server, rpc.NewCatalogServer(mockedCoffee, mockedStream))
+		go server.Serve(lis)
+
+		var err error
+		conn, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		client = catalogpb.NewCoffeeCatalogClient(conn)
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	It("should list the coffees", func() {
+		mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1", Name: "Espresso", Price: 10, GrindUnit: 1}}, nil)
+
+		res, err := client.ListCoffees(context.Background(), &catalogpb.ListCoffeesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.GetCoffees()).To(HaveLen(1))
+		Expect(res.GetCoffees()[0].GetName()).To(Equal("Espresso"))
+		Expect(res.GetCoffees()[0].GetCreatedAt()).To(BeNil())
+	})
+
+	It("should create a coffee", func() {
+		mockedCoffee.On("CreateCoffee", services.Coffee{Name: "Latte", Roast: "Light", Price: 12, GrindUnit: 2}).
+			Return(&services.Coffee{ID: "c2", Name: "Latte", Roast: "Light", Price: 12, GrindUnit: 2}, nil)
+
+		created, err := client.CreateCoffee(context.Background(), &catalogpb.CreateCoffeeRequest{
+			Coffee: &catalogpb.Coffee{Name: "Latte", Roast: "Light", Price: 12, GrindUnit: 2},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.GetId()).To(Equal("c2"))
+	})
+
+	It("should map a missing coffee to NotFound", func() {
+		mockedCoffee.On("GetCoffeesById", "c9").Return(nil, sql.ErrNoRows)
+
+		_, err := client.GetCoffee(context.Background(), &catalogpb.GetCoffeeRequest{Id: "c9"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should map a database that is down to Unavailable", func() {
+		mockedCoffee.On("GetAllCoffees").Return(nil, &services.UnavailableError{RetryAfter: time.Second})
+
+		_, err := client.ListCoffees(context.Background(), &catalogpb.ListCoffeesRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+
+	It("should refuse a request without an id", func() {
+		_, err := client.DeleteCoffee(context.Background(), &catalogpb.DeleteCoffeeRequest{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("should stream the missed changes and then the live ones", func() {
+		payload, _ := json.Marshal(services.Coffee{ID: "c1", Name: "Espresso"})
+		mockedStream.On("Replay", int64(3)).Return([]events.Event{
+			{ID: 4, AggregateID: "c1", Type: services.EventCoffeeUpdated, Payload: payload},
+		}, nil)
+
+		stream, err := client.WatchCoffees(context.Background(), &catalogpb.WatchCoffeesRequest{AfterEventId: 3})
+		Expect(err).NotTo(HaveOccurred())
+
+		event, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.GetId()).To(Equal(int64(4)))
+		Expect(event.GetType()).To(Equal(catalogpb.EventType_EVENT_TYPE_UPDATED))
+		Expect(event.GetCoffee().GetName()).To(Equal("Espresso"))
+
+		live <- events.Event{ID: 4, AggregateID: "c1", Type: services.EventCoffeeUpdated, Payload: payload}
+		live <- events.Event{ID: 5, AggregateID: "c1", Type: services.EventCoffeeDeleted, Payload: json.RawMessage(`{"id":"c1"}`)}
+
+		event, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.GetId()).To(Equal(int64(5)))
+		Expect(event.GetType()).To(Equal(catalogpb.EventType_EVENT_TYPE_DELETED))
+		Expect(event.GetCoffee()).To(BeNil())
+
+		// Dropped for falling behind
+		close(live)
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+})
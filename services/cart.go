@@ -0,0 +1,488 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	CartStatusActive = "active"
+	CartStatusMerged = "merged"
+
+	// cartTTL is how long a cart lives after its last change
+	cartTTL = time.Hour * 24 * 7
+)
+
+// Grind choices a customer can pick for a cart item
+var GrindChoices = []string{"whole_bean", "espresso", "filter", "french_press", "moka_pot"}
+
+var (
+	ErrCartNotFound     = errors.New("cart not found")
+	ErrCartExpired      = errors.New("cart has expired")
+	ErrCartItemNotFound = errors.New("cart item not found")
+	ErrInvalidGrind     = errors.New("unknown grind choice")
+	ErrUserRequired     = errors.New("user_id is required")
+	ErrCartNotOwned     = errors.New("cart belongs to another user")
+)
+
+type CartItem struct {
+	ID        string    `json:"id,omitempty"`
+	CartID    string    `json:"cart_id,omitempty"`
+	CoffeeID  string    `json:"coffee_id"`
+	Name      string    `json:"name"`
+	Roast     string    `json:"roast,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	Grind     string    `json:"grind"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float32   `json:"unit_price"`
+	LineTotal float32   `json:"line_total"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Cart struct {
+	ID        string     `json:"id,omitempty"`
+	UserID    string     `json:"user_id,omitempty"`
+	Status    string     `json:"status"`
+	Items     []CartItem `json:"items"`
+	Total     float32    `json:"total"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CartService interface {
+	CreateCart(userId string) (*Cart, error)
+	GetCartById(id string) (*Cart, error)
+	AddCartItem(cartId string, item CartItem) (*Cart, error)
+	UpdateCartItem(cartId string, itemId string, item CartItem) (*Cart, error)
+	RemoveCartItem(cartId string, itemId string) (*Cart, error)
+	MergeCarts(cartId string, userId string) (*Cart, error)
+	DeleteExpiredCarts() (int64, error)
+}
+
+// TenantCarts is implemented by the carts kept per tenant
+type TenantCarts interface {
+	ForTenant(tenantID string) CartService
+}
+
+// CartsFor returns the carts of the tenant, holding its coffees
+func CartsFor(carts CartService, tenantID string) CartService {
+	if scoped, ok := carts.(TenantCarts); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return carts
+}
+
+// CartsFromContext returns the carts of the tenant of the request, the default one when none was resolved
+func CartsFromContext(ctx context.Context, carts CartService) CartService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return CartsFor(carts, tenant.ID)
+	}
+	return carts
+}
+
+// Concrete implementation of CartService
+type CartServiceImpl struct {
+	DB db.Querier
+	// Tenant is the shop the carts are kept by, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantCarts = (*CartServiceImpl)(nil)
+
+// ForTenant returns the carts of the tenant on the same database
+func (c *CartServiceImpl) ForTenant(tenantID string) CartService {
+	return &CartServiceImpl{DB: c.DB, Tenant: tenantID}
+}
+
+func (c *CartServiceImpl) tenant() string {
+	if c.Tenant != "" {
+		return c.Tenant
+	}
+	return DefaultTenant
+}
+
+func IsValidGrind(grind string) bool {
+	for _, choice := range GrindChoices {
+		if choice == grind {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CartServiceImpl) CreateCart(userId string) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	now := time.Now()
+	cart := Cart{
+		UserID:    userId,
+		Status:    CartStatusActive,
+		Items:     []CartItem{},
+		ExpiresAt: now.Add(cartTTL),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	query := `INSERT INTO carts(user_id, status, expires_at, created_at, updated_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6) returning id`
+
+	err := c.DB.QueryRowContext(ctx, query, nullString(userId), cart.Status, cart.ExpiresAt, now, now, c.tenant()).Scan(&cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (c *CartServiceImpl) GetCartById(id string) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return getCartById(ctx, c.DB, id, c.tenant())
+}
+
+func (c *CartServiceImpl) AddCartItem(cartId string, item CartItem) (*Cart, error) {
+	if item.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if !IsValidGrind(item.Grind) {
+		return nil, ErrInvalidGrind
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveCart(ctx, tx, cartId, c.tenant()); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM coffees WHERE id = $1 AND tenant_id = $2)`, item.CoffeeID, c.tenant()).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCoffeeNotFound
+	}
+
+	// Adding the same coffee with the same grind again increases the quantity of the existing line
+	query := `INSERT INTO cart_items(cart_id, coffee_id, grind, quantity, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (cart_id, coffee_id, grind) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`
+
+	_, err = tx.ExecContext(ctx, query, cartId, item.CoffeeID, item.Grind, item.Quantity, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return touchCart(ctx, tx, cartId, c.tenant())
+}
+
+func (c *CartServiceImpl) UpdateCartItem(cartId string, itemId string, item CartItem) (*Cart, error) {
+	if item.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if !IsValidGrind(item.Grind) {
+		return nil, ErrInvalidGrind
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveCart(ctx, tx, cartId, c.tenant()); err != nil {
+		return nil, err
+	}
+
+	var coffeeId string
+	err = tx.QueryRowContext(ctx, `SELECT coffee_id FROM cart_items WHERE id = $1 AND cart_id = $2`, itemId, cartId).Scan(&coffeeId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCartItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Changing the grind to the one of another line of the coffee moves the quantity to that line, like
+	// adding the coffee with that grind again does
+	query := `UPDATE cart_items SET quantity = quantity + $1, updated_at = $2 WHERE cart_id = $3 AND coffee_id = $4 AND grind = $5 AND id <> $6`
+
+	res, err := tx.ExecContext(ctx, query, item.Quantity, time.Now(), cartId, coffeeId, item.Grind, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if merged, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if merged > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE id = $1 AND cart_id = $2`, itemId, cartId); err != nil {
+			return nil, err
+		}
+		return touchCart(ctx, tx, cartId, c.tenant())
+	}
+
+	query = `UPDATE cart_items SET quantity = $1, grind = $2, updated_at = $3 WHERE id = $4 AND cart_id = $5`
+
+	if _, err := tx.ExecContext(ctx, query, item.Quantity, item.Grind, time.Now(), itemId, cartId); err != nil {
+		return nil, err
+	}
+
+	return touchCart(ctx, tx, cartId, c.tenant())
+}
+
+func (c *CartServiceImpl) RemoveCartItem(cartId string, itemId string) (*Cart, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveCart(ctx, tx, cartId, c.tenant()); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE id = $1 AND cart_id = $2`, itemId, cartId)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, ErrCartItemNotFound
+	}
+
+	return touchCart(ctx, tx, cartId, c.tenant())
+}
+
+// MergeCarts moves the items of an anonymous cart into the active cart of the user, e.g. after they log in
+func (c *CartServiceImpl) MergeCarts(cartId string, userId string) (*Cart, error) {
+	if userId == "" {
+		return nil, ErrUserRequired
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveCart(ctx, tx, cartId, c.tenant()); err != nil {
+		return nil, err
+	}
+
+	// Only an anonymous cart or a cart of the user can be merged, a cart id doesn't hand over the cart of another user
+	var mergeable bool
+	err = tx.QueryRowContext(ctx, `SELECT user_id IS NULL OR user_id = $2 FROM carts WHERE id = $1`, cartId, userId).Scan(&mergeable)
+	if err != nil {
+		return nil, err
+	}
+	if !mergeable {
+		return nil, ErrCartNotOwned
+	}
+
+	var userCartId string
+	query := `SELECT id FROM carts WHERE user_id = $1 AND status = $2 AND expires_at > NOW() AND id <> $3 AND tenant_id = $4
+		ORDER BY updated_at DESC LIMIT 1 FOR UPDATE`
+
+	err = tx.QueryRowContext(ctx, query, userId, CartStatusActive, cartId, c.tenant()).Scan(&userCartId)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The user has no cart yet, so the anonymous cart simply becomes theirs
+		_, err = tx.ExecContext(ctx, `UPDATE carts SET user_id = $1 WHERE id = $2`, userId, cartId)
+		if err != nil {
+			return nil, err
+		}
+		return touchCart(ctx, tx, cartId, c.tenant())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO cart_items(cart_id, coffee_id, grind, quantity, created_at, updated_at)
+		SELECT $1::uuid, coffee_id, grind, quantity, created_at, $3::timestamptz FROM cart_items WHERE cart_id = $2
+		ON CONFLICT (cart_id, coffee_id, grind) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`
+
+	_, err = tx.ExecContext(ctx, query, userCartId, cartId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE carts SET status = $1, updated_at = $2 WHERE id = $3`, CartStatusMerged, time.Now(), cartId)
+	if err != nil {
+		return nil, err
+	}
+
+	return touchCart(ctx, tx, userCartId, c.tenant())
+}
+
+func (c *CartServiceImpl) DeleteExpiredCarts() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	res, err := c.DB.ExecContext(ctx, `DELETE FROM carts WHERE expires_at <= NOW() OR status = $1`, CartStatusMerged)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// lockActiveCart locks the cart of the tenant for the rest of the transaction and checks it can still be changed
+func lockActiveCart(ctx context.Context, tx db.Executor, id string, tenantID string) error {
+	var status string
+	var expiresAt time.Time
+
+	err := tx.QueryRowContext(ctx, `SELECT status, expires_at FROM carts WHERE id = $1 AND tenant_id = $2 FOR UPDATE`, id, tenantID).Scan(&status, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != CartStatusActive {
+		return ErrCartNotFound
+	}
+	if !expiresAt.After(time.Now()) {
+		return ErrCartExpired
+	}
+	return nil
+}
+
+// touchCart extends the expiry of a changed cart, commits the transaction and returns the repriced cart
+func touchCart(ctx context.Context, tx db.Tx, id string, tenantID string) (*Cart, error) {
+	now := time.Now()
+
+	_, err := tx.ExecContext(ctx, `UPDATE carts SET expires_at = $1, updated_at = $2 WHERE id = $3`, now.Add(cartTTL), now, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := getCartById(ctx, tx, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// getCartById loads the cart of the tenant with the current catalog price of every item, so carts follow
+// price changes
+func getCartById(ctx context.Context, db db.Executor, id string, tenantID string) (*Cart, error) {
+	query := `SELECT c.id, COALESCE(c.user_id, ''), c.status, c.expires_at, c.created_at, c.updated_at,
+		i.id, i.coffee_id, co.name, co.roast, co.region, i.grind, i.quantity, co.price, i.created_at, i.updated_at
+		FROM carts c
+		LEFT JOIN cart_items i ON i.cart_id = c.id
+		LEFT JOIN coffees co ON co.id = i.coffee_id
+		WHERE c.id = $1 AND c.status = $2 AND c.tenant_id = $3
+		ORDER BY i.created_at`
+
+	rows, err := db.QueryContext(ctx, query, id, CartStatusActive, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cart *Cart
+	for rows.Next() {
+		var current Cart
+		var itemId, coffeeId, name, roast, region, grind sql.NullString
+		var quantity sql.NullInt64
+		var price sql.NullFloat64
+		var itemCreatedAt, itemUpdatedAt sql.NullTime
+
+		err := rows.Scan(
+			&current.ID,
+			&current.UserID,
+			&current.Status,
+			&current.ExpiresAt,
+			&current.CreatedAt,
+			&current.UpdatedAt,
+			&itemId,
+			&coffeeId,
+			&name,
+			&roast,
+			&region,
+			&grind,
+			&quantity,
+			&price,
+			&itemCreatedAt,
+			&itemUpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if cart == nil {
+			current.Items = []CartItem{}
+			cart = &current
+		}
+
+		if itemId.Valid {
+			cart.Items = append(cart.Items, CartItem{
+				ID:        itemId.String,
+				CartID:    cart.ID,
+				CoffeeID:  coffeeId.String,
+				Name:      name.String,
+				Roast:     roast.String,
+				Region:    region.String,
+				Grind:     grind.String,
+				Quantity:  int(quantity.Int64),
+				UnitPrice: float32(price.Float64),
+				CreatedAt: itemCreatedAt.Time,
+				UpdatedAt: itemUpdatedAt.Time,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cart == nil {
+		return nil, ErrCartNotFound
+	}
+	if !cart.ExpiresAt.After(time.Now()) {
+		return nil, ErrCartExpired
+	}
+
+	cart.Total = cart.CalculateTotal()
+	return cart, nil
+}
+
+// CalculateTotal fills in the line total of every item at its current price and returns the cart total
+func (c *Cart) CalculateTotal() float32 {
+	var total float32
+	for i := range c.Items {
+		c.Items[i].LineTotal = c.Items[i].UnitPrice * float32(c.Items[i].Quantity)
+		total += c.Items[i].LineTotal
+	}
+	return total
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
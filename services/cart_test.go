@@ -0,0 +1,173 @@
+package services_test
+
+import (
+	"coffee/coffee-server/services"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cart Service", Label("integration"), func() {
+	const espressoId = "550e8400-e29b-41d4-a716-446655440000"
+
+	var cartService services.CartService
+
+	BeforeEach(func() {
+		cartService = services.New(conn).Cart
+
+		_, err := db.Exec("DELETE FROM carts")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000', 'Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		_, err := db.Exec("DELETE FROM carts")
+		Expect(err).To(BeNil())
+	})
+
+	Describe("AddCartItem", func() {
+		It("should add up quantities for the same coffee and grind", func() {
+			cart, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+
+			_, err = cartService.AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 1})
+			Expect(err).To(BeNil())
+			cart, err = cartService.AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 2})
+			Expect(err).To(BeNil())
+
+			Expect(cart.Items).To(HaveLen(1))
+			Expect(cart.Items[0].Quantity).To(Equal(3))
+			Expect(cart.Total).To(Equal(float32(30.0)))
+		})
+
+		It("should not add a coffee of another shop", func() {
+			cart, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+
+			_, err = services.CartsFor(cartService, "00000000-0000-0000-0000-000000000002").AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 1})
+			Expect(err).To(MatchError(services.ErrCoffeeNotFound))
+		})
+
+		It("should reprice the cart when the coffee price changes", func() {
+			cart, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+			_, err = cartService.AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "filter", Quantity: 2})
+			Expect(err).To(BeNil())
+
+			_, err = services.New(conn).Coffee.UpdateCoffee(espressoId, services.Coffee{Name: "Espresso", Roast: "Dark", Image: "image1.png", Region: "Brazil", Price: 12.5, GrindUnit: 1})
+			Expect(err).To(BeNil())
+
+			cart, err = cartService.GetCartById(cart.ID)
+			Expect(err).To(BeNil())
+			Expect(cart.Items[0].UnitPrice).To(Equal(float32(12.5)))
+			Expect(cart.Total).To(Equal(float32(25.0)))
+		})
+	})
+
+	Describe("UpdateCartItem", func() {
+		It("should move the quantity to the line of the coffee with the new grind", func() {
+			cart, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+			_, err = cartService.AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 1})
+			Expect(err).To(BeNil())
+			cart, err = cartService.AddCartItem(cart.ID, services.CartItem{CoffeeID: espressoId, Grind: "filter", Quantity: 2})
+			Expect(err).To(BeNil())
+
+			var filter string
+			for _, line := range cart.Items {
+				if line.Grind == "filter" {
+					filter = line.ID
+				}
+			}
+			cart, err = cartService.UpdateCartItem(cart.ID, filter, services.CartItem{Grind: "espresso", Quantity: 3})
+			Expect(err).To(BeNil())
+			Expect(cart.Items).To(HaveLen(1))
+			Expect(cart.Items[0].Grind).To(Equal("espresso"))
+			Expect(cart.Items[0].Quantity).To(Equal(4))
+		})
+	})
+
+	Describe("expiry", func() {
+		It("should refuse expired carts and purge them", func() {
+			cart, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+			_, err = db.Exec("UPDATE carts SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1", cart.ID)
+			Expect(err).To(BeNil())
+
+			_, err = cartService.GetCartById(cart.ID)
+			Expect(err).To(MatchError(services.ErrCartExpired))
+
+			deleted, err := cartService.DeleteExpiredCarts()
+			Expect(err).To(BeNil())
+			Expect(deleted).To(Equal(int64(1)))
+		})
+	})
+
+	Describe("MergeCarts", func() {
+		It("should move the anonymous items into the user's cart", func() {
+			userCart, err := cartService.CreateCart("user-1")
+			Expect(err).To(BeNil())
+			_, err = cartService.AddCartItem(userCart.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 1})
+			Expect(err).To(BeNil())
+
+			anonymous, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+			_, err = cartService.AddCartItem(anonymous.ID, services.CartItem{CoffeeID: espressoId, Grind: "espresso", Quantity: 2})
+			Expect(err).To(BeNil())
+			_, err = cartService.AddCartItem(anonymous.ID, services.CartItem{CoffeeID: espressoId, Grind: "filter", Quantity: 1})
+			Expect(err).To(BeNil())
+
+			merged, err := cartService.MergeCarts(anonymous.ID, "user-1")
+			Expect(err).To(BeNil())
+			Expect(merged.ID).To(Equal(userCart.ID))
+			Expect(merged.Items).To(HaveLen(2))
+			Expect(merged.Total).To(Equal(float32(40.0)))
+
+			_, err = cartService.GetCartById(anonymous.ID)
+			Expect(err).To(MatchError(services.ErrCartNotFound))
+		})
+
+		It("should hand the anonymous cart to a user without one", func() {
+			anonymous, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+
+			merged, err := cartService.MergeCarts(anonymous.ID, "user-2")
+			Expect(err).To(BeNil())
+			Expect(merged.ID).To(Equal(anonymous.ID))
+			Expect(merged.UserID).To(Equal("user-2"))
+		})
+
+		It("should not take over the cart of another user", func() {
+			theirs, err := cartService.CreateCart("user-3")
+			Expect(err).To(BeNil())
+
+			_, err = cartService.MergeCarts(theirs.ID, "user-4")
+			Expect(err).To(MatchError(services.ErrCartNotOwned))
+
+			kept, err := cartService.GetCartById(theirs.ID)
+			Expect(err).To(BeNil())
+			Expect(kept.UserID).To(Equal("user-3"))
+		})
+
+		It("should not merge the carts of two shops", func() {
+			const otherShop = "00000000-0000-0000-0000-000000000002"
+			_, err := db.Exec("INSERT INTO tenants (id, slug, name) VALUES ($1, 'other', 'Other shop') ON CONFLICT DO NOTHING", otherShop)
+			Expect(err).To(BeNil())
+			theirs, err := services.CartsFor(cartService, otherShop).CreateCart("user-5")
+			Expect(err).To(BeNil())
+
+			anonymous, err := cartService.CreateCart("")
+			Expect(err).To(BeNil())
+			_, err = services.CartsFor(cartService, otherShop).MergeCarts(anonymous.ID, "user-5")
+			Expect(err).To(MatchError(services.ErrCartNotFound))
+
+			merged, err := cartService.MergeCarts(anonymous.ID, "user-5")
+			Expect(err).To(BeNil())
+			Expect(merged.ID).To(Equal(anonymous.ID))
+			Expect(merged.ID).NotTo(Equal(theirs.ID))
+		})
+	})
+})
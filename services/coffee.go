@@ -1,21 +1,26 @@
 package services
 
 import (
+	"coffee/coffee-server/db"
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 )
 
 type Coffee struct {
-	ID        string    `json:"id,omitempty"`
-	Name      string    `json:"name"`
-	Roast     string    `json:"roast"`
-	Image     string    `json:"image"`
-	Region    string    `json:"region"`
-	Price     float32   `json:"price"`
-	GrindUnit int16     `json:"grind_unit"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string    `json:"id,omitempty"`
+	Slug        string    `json:"slug,omitempty"` // Generated from the name and region unless set with CoffeeSlugs
+	Name        string    `json:"name"`
+	Roast       string    `json:"roast"`
+	Image       string    `json:"image"`
+	Region      string    `json:"region"`
+	Description string    `json:"description,omitempty"` // Only kept in the translations, served in the language of the request
+	Price       float32   `json:"price"`
+	GrindUnit   int16     `json:"grind_unit"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type CoffeeService interface {
@@ -26,99 +31,236 @@ type CoffeeService interface {
 	DeleteCoffee(id string) error
 }
 
+// CoffeeImporter is implemented by the catalogs creating many coffees faster at once than one by one
+type CoffeeImporter interface {
+	ImportCoffees(coffees []Coffee) ([]*Coffee, error)
+}
+
+// coffeeColumns are the columns an import copies into
+var coffeeColumns = []string{"id", "slug", "name", "roast", "image", "region", "price", "grind_unit", "created_at", "updated_at", "tenant_id"}
+
+// coffeeSelect are the columns scanCoffee reads
+const coffeeSelect = `id, slug, name, roast, image, region, price, grind_unit, created_at, updated_at`
+
+func scanCoffee(row db.Row) (*Coffee, error) {
+	var coffee Coffee
+	err := row.Scan(
+		&coffee.ID,
+		&coffee.Slug,
+		&coffee.Name,
+		&coffee.Roast,
+		&coffee.Image,
+		&coffee.Region,
+		&coffee.Price,
+		&coffee.GrindUnit,
+		&coffee.CreatedAt,
+		&coffee.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &coffee, nil
+}
+
 // Concrete implementation of CoffeeService
 type CoffeeServiceImpl struct {
-	DB *sql.DB
+	DB db.Querier
+	// Reads serves the catalog reads, e.g. from the replicas, they are served by DB when it is nil
+	Reads db.Executor
+	// Tenant is the shop of the catalog, every query is limited to it. It is DefaultTenant when empty.
+	Tenant string
+}
+
+var (
+	_ TenantCatalog  = (*CoffeeServiceImpl)(nil)
+	_ CoffeeSlugs    = (*CoffeeServiceImpl)(nil)
+	_ PrimaryCatalog = (*CoffeeServiceImpl)(nil)
+)
+
+// PrimaryCatalog is implemented by the catalogs reading from the replicas, for the clients that have to read
+// their own writes
+type PrimaryCatalog interface {
+	// OnPrimary returns the catalog reading from the primary
+	OnPrimary() CoffeeService
+}
+
+type primaryReadsKey struct{}
+
+// ContextWithPrimaryReads returns the context of a request from a client that just changed something, its
+// catalog reads go to the primary so they see the change despite the replication lag
+func ContextWithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+func primaryReads(ctx context.Context) bool {
+	on, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return on
+}
+
+// OnPrimary returns the catalog of the tenant reading from the primary
+func (c *CoffeeServiceImpl) OnPrimary() CoffeeService {
+	return &CoffeeServiceImpl{DB: c.DB, Tenant: c.Tenant}
+}
+
+// ForTenant returns the catalog of the tenant on the same database
+func (c *CoffeeServiceImpl) ForTenant(tenantID string) CoffeeService {
+	return &CoffeeServiceImpl{DB: c.DB, Reads: c.Reads, Tenant: tenantID}
+}
+
+func (c *CoffeeServiceImpl) tenant() string {
+	if c.Tenant != "" {
+		return c.Tenant
+	}
+	return DefaultTenant
+}
+
+// coffeeEvent is the payload of the events of a coffee, the tenant tells the subscribers whose catalog changed
+type coffeeEvent struct {
+	Coffee
+	TenantID string `json:"tenant_id"`
+}
+
+func (c *CoffeeServiceImpl) reads() db.Executor {
+	if c.Reads != nil {
+		return c.Reads
+	}
+	return c.DB
 }
 
 func (c *CoffeeServiceImpl) GetAllCoffees() ([]*Coffee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	query := `SELECT id, name, roast, image, region, price, grind_unit, created_at, updated_at FROM coffees`
+	query := `SELECT ` + coffeeSelect + ` FROM coffees WHERE tenant_id = $1`
 
-	rows, err := c.DB.QueryContext(ctx, query)
+	rows, err := c.reads().QueryContext(ctx, query, c.tenant())
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var coffees []*Coffee
 
 	for rows.Next() {
-		var coffee Coffee
-		err := rows.Scan(
-			&coffee.ID,
-			&coffee.Name,
-			&coffee.Roast,
-			&coffee.Image,
-			&coffee.Region,
-			&coffee.Price,
-			&coffee.GrindUnit,
-			&coffee.CreatedAt,
-			&coffee.UpdatedAt,
-		)
+		coffee, err := scanCoffee(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		coffees = append(coffees, &coffee)
+		coffees = append(coffees, coffee)
 	}
-	return coffees, nil
+	return coffees, rows.Err()
 }
 
 func (c *CoffeeServiceImpl) CreateCoffee(coffee Coffee) (*Coffee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	query := `INSERT INTO coffees(name, roast, image, region, price, grind_unit, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) returning *`
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := c.lockSlugs(ctx, tx); err != nil {
+		return nil, err
+	}
+	coffee.Slug, err = c.generateSlug(ctx, tx, "", coffee.Name, coffee.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO coffees(slug, name, roast, image, region, price, grind_unit, created_at, updated_at, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning id`
 
-	_, err := c.DB.ExecContext(ctx, query, coffee.Name, coffee.Roast, coffee.Image, coffee.Region, coffee.Price, coffee.GrindUnit, time.Now(), time.Now())
+	err = tx.QueryRowContext(ctx, query, coffee.Slug, coffee.Name, coffee.Roast, coffee.Image, coffee.Region, coffee.Price, coffee.GrindUnit, time.Now(), time.Now(), c.tenant()).Scan(&coffee.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := insertOutboxEvent(ctx, tx, AggregateCoffee, coffee.ID, EventCoffeeCreated, coffeeEvent{coffee, c.tenant()}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &coffee, nil
 }
 
+// GetCoffeesById finds the coffee by its id or its slug. A slug the coffee had before finds it too,
+// the caller can tell from the slug of the coffee that it moved.
 func (c *CoffeeServiceImpl) GetCoffeesById(id string) (*Coffee, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	query := `SELECT id, name, roast, image, region, price, grind_unit, created_at, updated_at FROM coffees WHERE id=$1`
+	if isUUID(id) {
+		query := `SELECT ` + coffeeSelect + ` FROM coffees WHERE id=$1 AND tenant_id = $2`
+		return scanCoffee(c.reads().QueryRowContext(ctx, query, id, c.tenant()))
+	}
 
-	var coffee Coffee
+	query := `SELECT ` + coffeeSelect + ` FROM coffees WHERE slug = $1 AND tenant_id = $2`
+	coffee, err := scanCoffee(c.reads().QueryRowContext(ctx, query, id, c.tenant()))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return coffee, err
+	}
 
-	row := c.DB.QueryRowContext(ctx, query, id)
+	query = `SELECT c.id, c.slug, c.name, c.roast, c.image, c.region, c.price, c.grind_unit, c.created_at, c.updated_at
+		FROM coffee_slug_history h JOIN coffees c ON c.id = h.coffee_id WHERE h.slug = $1 AND h.tenant_id = $2`
+	return scanCoffee(c.reads().QueryRowContext(ctx, query, id, c.tenant()))
+}
 
-	err := row.Scan(
-		&coffee.ID,
-		&coffee.Name,
-		&coffee.Roast,
-		&coffee.Image,
-		&coffee.Region,
-		&coffee.Price,
-		&coffee.GrindUnit,
-		&coffee.CreatedAt,
-		&coffee.UpdatedAt,
-	)
+func (c *CoffeeServiceImpl) UpdateCoffee(id string, coffee Coffee) (*Coffee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
 
+	tx, err := c.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &coffee, nil
-}
+	defer tx.Rollback()
 
-func (c *CoffeeServiceImpl) UpdateCoffee(id string, coffee Coffee) (*Coffee, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
+	var current Coffee
+	var custom bool
+	query := `SELECT name, region, slug, slug_custom FROM coffees WHERE id = $1 AND tenant_id = $2 FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, id, c.tenant()).Scan(&current.Name, &current.Region, &current.Slug, &custom)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing changed, nothing is published
+		return &coffee, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	query := `UPDATE coffees SET name = $1, roast = $2, image = $3, region = $4, price = $5, grind_unit = $6, updated_at = $7 WHERE id = $8 returning *`
+	// A generated slug follows the name and the region, the URLs of the previous one redirect
+	coffee.Slug = current.Slug
+	if !custom && Slugify(coffee.Name, coffee.Region) != Slugify(current.Name, current.Region) {
+		if err := c.lockSlugs(ctx, tx); err != nil {
+			return nil, err
+		}
+		if coffee.Slug, err = c.generateSlug(ctx, tx, id, coffee.Name, coffee.Region); err != nil {
+			return nil, err
+		}
+	}
 
-	_, err := c.DB.ExecContext(ctx, query, coffee.Name, coffee.Roast, coffee.Image, coffee.Region, coffee.Price, coffee.GrindUnit, time.Now(), id)
+	query = `UPDATE coffees SET name = $1, roast = $2, image = $3, region = $4, price = $5, grind_unit = $6, slug = $7, updated_at = $8 WHERE id = $9 AND tenant_id = $10`
 
+	_, err = tx.ExecContext(ctx, query, coffee.Name, coffee.Roast, coffee.Image, coffee.Region, coffee.Price, coffee.GrindUnit, coffee.Slug, time.Now(), id, c.tenant())
 	if err != nil {
 		return nil, err
 	}
+	if err := c.moveSlug(ctx, tx, id, current.Slug, coffee.Slug); err != nil {
+		return nil, err
+	}
+
+	event := coffee
+	event.ID = id
+	if err := insertOutboxEvent(ctx, tx, AggregateCoffee, id, EventCoffeeUpdated, coffeeEvent{event, c.tenant()}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &coffee, nil
 }
 
@@ -126,12 +268,96 @@ func (c *CoffeeServiceImpl) DeleteCoffee(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 
-	query := `DELETE FROM coffees WHERE id = $1`
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM coffees WHERE id = $1 AND tenant_id = $2`
 
-	_, err := c.DB.ExecContext(ctx, query, id)
+	res, err := tx.ExecContext(ctx, query, id, c.tenant())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected > 0 {
+		if err := insertOutboxEvent(ctx, tx, AggregateCoffee, id, EventCoffeeDeleted, map[string]string{"id": id, "tenant_id": c.tenant()}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ImportCoffees creates the coffees in a single transaction: they are copied into the table and their
+// events sent to the outbox in one batch. COPY can't return the ids, they are generated here, and the
+// ids and slugs the coffees came with are ignored like CreateCoffee does. Within a unit of work, where
+// bulk writes aren't available, the coffees are inserted one by one.
+func (c *CoffeeServiceImpl) ImportCoffees(coffees []Coffee) ([]*Coffee, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := c.lockSlugs(ctx, tx); err != nil {
+		return nil, err
+	}
+	query := `SELECT slug FROM coffees WHERE tenant_id = $1 UNION SELECT slug FROM coffee_slug_history WHERE tenant_id = $1`
+	taken, err := takenSlugs(ctx, tx, query, c.tenant())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	imported := make([]*Coffee, 0, len(coffees))
+	rows := make([][]interface{}, 0, len(coffees))
+	queries := make([]db.Query, 0, len(coffees))
+	for _, coffee := range coffees {
+		coffee.ID, err = newUUID()
+		if err != nil {
+			return nil, err
+		}
+		coffee.CreatedAt, coffee.UpdatedAt = now, now
+		coffee.Slug = uniqueSlug(Slugify(coffee.Name, coffee.Region), taken)
+		taken[coffee.Slug] = true
+
+		rows = append(rows, []interface{}{coffee.ID, coffee.Slug, coffee.Name, coffee.Roast, coffee.Image, coffee.Region, coffee.Price, coffee.GrindUnit, now, now, c.tenant()})
+		event, err := outboxEventQuery(AggregateCoffee, coffee.ID, EventCoffeeCreated, coffeeEvent{coffee, c.tenant()})
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, event)
+		imported = append(imported, &coffee)
+	}
+
+	if bulk, ok := tx.(db.Bulk); ok {
+		if _, err := bulk.CopyFrom(ctx, "coffees", coffeeColumns, rows); err != nil {
+			return nil, err
+		}
+		if _, err := bulk.SendBatch(ctx, queries); err != nil {
+			return nil, err
+		}
+	} else {
+		insert := `INSERT INTO coffees(` + strings.Join(coffeeColumns, ", ") + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+		for i, row := range rows {
+			if _, err := tx.ExecContext(ctx, insert, row...); err != nil {
+				return nil, err
+			}
+			if _, err := tx.ExecContext(ctx, queries[i].SQL, queries[i].Args...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return imported, nil
 }
@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	database "coffee/coffee-server/db"
+	"coffee/coffee-server/services"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// BenchmarkGetAllCoffees compares reading a catalog of 100 coffees through the native pool, with and
+// without its statement cache, to the database/sql pool the services used before. It needs Postgres
+// and replaces the catalog of the database:
+//
+//	DSN=... go test ./services -run '^$' -bench GetAllCoffees
+func BenchmarkGetAllCoffees(b *testing.B) {
+	dsn := os.Getenv("DSN")
+	if dsn == "" {
+		b.Skip("DSN is not set")
+	}
+
+	pool, err := database.OpenPostgres(dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(); err != nil {
+		b.Skip("Postgres is not reachable: ", err)
+	}
+
+	ctx := context.Background()
+	if _, err := pool.ExecContext(ctx, "DELETE FROM coffees"); err != nil {
+		b.Fatal(err)
+	}
+	coffees := make([]services.Coffee, 100)
+	for i := range coffees {
+		coffees[i] = services.Coffee{Name: fmt.Sprintf("Coffee %d", i), Roast: "Medium", Region: "Brazil", Price: 10, GrindUnit: 1}
+	}
+	if _, err := (&services.CoffeeServiceImpl{DB: pool}).ImportCoffees(coffees); err != nil {
+		b.Fatal(err)
+	}
+
+	uncached, err := database.OpenPostgres(dsn, database.WithStatementCache(0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer uncached.Close()
+
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(10)
+	sqlDB.SetMaxIdleConns(5)
+
+	b.Run("pgxpool", func(b *testing.B) {
+		benchmarkGetAllCoffees(b, &services.CoffeeServiceImpl{DB: pool})
+	})
+	b.Run("pgxpool without statement cache", func(b *testing.B) {
+		benchmarkGetAllCoffees(b, &services.CoffeeServiceImpl{DB: uncached})
+	})
+	b.Run("database/sql", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := getAllCoffeesSQL(sqlDB); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
+
+func benchmarkGetAllCoffees(b *testing.B, service services.CoffeeService) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := service.GetAllCoffees(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// getAllCoffeesSQL is GetAllCoffees as it ran on database/sql
+func getAllCoffeesSQL(d *sql.DB) ([]*services.Coffee, error) {
+	rows, err := d.Query(`SELECT id, name, roast, image, region, price, grind_unit, created_at, updated_at FROM coffees`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coffees []*services.Coffee
+	for rows.Next() {
+		var coffee services.Coffee
+		err := rows.Scan(&coffee.ID, &coffee.Name, &coffee.Roast, &coffee.Image, &coffee.Region, &coffee.Price, &coffee.GrindUnit, &coffee.CreatedAt, &coffee.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		coffees = append(coffees, &coffee)
+	}
+	return coffees, rows.Err()
+}
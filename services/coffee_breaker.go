@@ -0,0 +1,144 @@
+package services
+
+import (
+	"coffee/coffee-server/breaker"
+	"coffee/coffee-server/db"
+	"errors"
+	"time"
+)
+
+var ErrDatabaseUnavailable = errors.New("the database is unavailable")
+
+// UnavailableError is returned while the database can't be reached, RetryAfter is when to try again
+// when it is known. It matches ErrDatabaseUnavailable.
+type UnavailableError struct {
+	RetryAfter time.Duration
+	// Err is the connection error of the call, nil when the breaker failed it fast
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err != nil {
+		return ErrDatabaseUnavailable.Error() + ": " + e.Err.Error()
+	}
+	return ErrDatabaseUnavailable.Error()
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrDatabaseUnavailable
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// BreakerCoffeeService fails the calls fast while the database is down, instead of making each of
+// them wait for its timeout. Only the connection errors count against the database: a coffee that
+// isn't found or a constraint that fails means it answered.
+type BreakerCoffeeService struct {
+	Next    CoffeeService
+	Breaker *breaker.Breaker
+}
+
+var (
+	_ CoffeeService  = (*BreakerCoffeeService)(nil)
+	_ TenantCatalog  = (*BreakerCoffeeService)(nil)
+	_ CoffeeSlugs    = (*BreakerCoffeeService)(nil)
+	_ PrimaryCatalog = (*BreakerCoffeeService)(nil)
+)
+
+func NewBreakerCoffeeService(next CoffeeService, b *breaker.Breaker) *BreakerCoffeeService {
+	return &BreakerCoffeeService{Next: next, Breaker: b}
+}
+
+// ForTenant returns the catalog of the tenant behind the same breaker, they share the database
+func (s *BreakerCoffeeService) ForTenant(tenantID string) CoffeeService {
+	return &BreakerCoffeeService{Next: CatalogFor(s.Next, tenantID), Breaker: s.Breaker}
+}
+
+// OnPrimary returns the catalog reading from the primary behind the same breaker, the primary is the database it watches
+func (s *BreakerCoffeeService) OnPrimary() CoffeeService {
+	return &BreakerCoffeeService{Next: onPrimary(s.Next), Breaker: s.Breaker}
+}
+
+func (s *BreakerCoffeeService) GetAllCoffees() ([]*Coffee, error) {
+	var coffees []*Coffee
+	err := s.call(func() (err error) {
+		coffees, err = s.Next.GetAllCoffees()
+		return err
+	})
+	return coffees, err
+}
+
+func (s *BreakerCoffeeService) CreateCoffee(coffee Coffee) (*Coffee, error) {
+	var created *Coffee
+	err := s.call(func() (err error) {
+		created, err = s.Next.CreateCoffee(coffee)
+		return err
+	})
+	return created, err
+}
+
+func (s *BreakerCoffeeService) GetCoffeesById(id string) (*Coffee, error) {
+	var found *Coffee
+	err := s.call(func() (err error) {
+		found, err = s.Next.GetCoffeesById(id)
+		return err
+	})
+	return found, err
+}
+
+func (s *BreakerCoffeeService) UpdateCoffee(id string, coffee Coffee) (*Coffee, error) {
+	var updated *Coffee
+	err := s.call(func() (err error) {
+		updated, err = s.Next.UpdateCoffee(id, coffee)
+		return err
+	})
+	return updated, err
+}
+
+func (s *BreakerCoffeeService) DeleteCoffee(id string) error {
+	return s.call(func() error {
+		return s.Next.DeleteCoffee(id)
+	})
+}
+
+func (s *BreakerCoffeeService) SetCoffeeSlug(id string, slug string) (*Coffee, error) {
+	slugs, err := slugsOf(s.Next)
+	if err != nil {
+		return nil, err
+	}
+	var updated *Coffee
+	err = s.call(func() (err error) {
+		updated, err = slugs.SetCoffeeSlug(id, slug)
+		return err
+	})
+	return updated, err
+}
+
+func (s *BreakerCoffeeService) ResetCoffeeSlug(id string) (*Coffee, error) {
+	slugs, err := slugsOf(s.Next)
+	if err != nil {
+		return nil, err
+	}
+	var updated *Coffee
+	err = s.call(func() (err error) {
+		updated, err = slugs.ResetCoffeeSlug(id)
+		return err
+	})
+	return updated, err
+}
+
+func (s *BreakerCoffeeService) call(fn func() error) error {
+	if err := s.Breaker.Allow(); err != nil {
+		return &UnavailableError{RetryAfter: s.Breaker.RetryAfter()}
+	}
+
+	err := fn()
+	down := db.IsConnectionError(err)
+	s.Breaker.Done(down)
+	if down {
+		return &UnavailableError{RetryAfter: s.Breaker.RetryAfter(), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"coffee/coffee-server/breaker"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"database/sql"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Breaker coffee service", Label("unit"), func() {
+	var (
+		mockedCoffee *mocks.CoffeeService
+		guarded      *services.BreakerCoffeeService
+		now          time.Time
+	)
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+		now = time.Date(2024, 11, 4, 9, 0, 0, 0, time.UTC)
+		b := breaker.New(2, 10*time.Second)
+		b.Now = func() time.Time { return now }
+		guarded = services.NewBreakerCoffeeService(mockedCoffee, b)
+	})
+
+	It("should fail fast while the database is down", func() {
+		mockedCoffee.On("GetAllCoffees").Return(nil, refused).Twice()
+
+		for range 2 {
+			_, err := guarded.GetAllCoffees()
+			Expect(err).To(MatchError(services.ErrDatabaseUnavailable))
+			Expect(errors.Is(err, syscall.ECONNREFUSED)).To(BeTrue())
+		}
+
+		_, err := guarded.GetCoffeesById("c1")
+		var unavailable *services.UnavailableError
+		Expect(errors.As(err, &unavailable)).To(BeTrue())
+		Expect(unavailable.RetryAfter).To(Equal(10 * time.Second))
+		mockedCoffee.AssertNotCalled(GinkgoT(), "GetCoffeesById", "c1")
+
+		// Back after the cooldown
+		now = now.Add(10 * time.Second)
+		mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1"}, nil).Once()
+		coffee, err := guarded.GetCoffeesById("c1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffee.ID).To(Equal("c1"))
+		Expect(guarded.Breaker.State()).To(Equal(breaker.Closed))
+	})
+
+	It("should pass the errors of a database that answered through", func() {
+		mockedCoffee.On("GetCoffeesById", "c9").Return(nil, sql.ErrNoRows).Times(3)
+		mockedCoffee.On("DeleteCoffee", "c9").Return(errors.New("violates foreign key constraint"))
+
+		for range 3 {
+			_, err := guarded.GetCoffeesById("c9")
+			Expect(err).To(MatchError(sql.ErrNoRows))
+		}
+		Expect(guarded.DeleteCoffee("c9")).To(MatchError("violates foreign key constraint"))
+		Expect(guarded.Breaker.State()).To(Equal(breaker.Closed))
+	})
+})
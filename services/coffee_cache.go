@@ -0,0 +1,168 @@
+package services
+
+import (
+	"coffee/coffee-server/cache"
+	"coffee/coffee-server/events"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// CachedCoffeeService serves the catalog reads from a cache in front of another CoffeeService.
+// Every write through it invalidates the whole catalog, which changes a few times a day: the cache
+// keys carry a generation that is bumped, so a read still loading the previous catalog can't put it
+// back. Concurrent misses of the same key share a single call to the next service. The misses are
+// loaded from the primary: a replica lagging behind a write would put the row from before it back
+// for the whole TTL.
+//
+// The catalogs of the tenants share the store and the generation, a write to one of them invalidates all.
+type CachedCoffeeService struct {
+	Next  CoffeeService
+	Store cache.Store
+	TTL   time.Duration
+
+	tenant     string
+	generation *atomic.Uint64
+	loads      *cache.Group
+}
+
+var (
+	_ CoffeeService  = (*CachedCoffeeService)(nil)
+	_ TenantCatalog  = (*CachedCoffeeService)(nil)
+	_ CoffeeSlugs    = (*CachedCoffeeService)(nil)
+	_ PrimaryCatalog = (*CachedCoffeeService)(nil)
+)
+
+func NewCachedCoffeeService(next CoffeeService, store cache.Store, ttl time.Duration) *CachedCoffeeService {
+	return &CachedCoffeeService{Next: next, Store: store, TTL: ttl, generation: &atomic.Uint64{}, loads: &cache.Group{}}
+}
+
+// ForTenant returns the cached catalog of the tenant
+func (c *CachedCoffeeService) ForTenant(tenantID string) CoffeeService {
+	return &CachedCoffeeService{
+		Next:       CatalogFor(c.Next, tenantID),
+		Store:      c.Store,
+		TTL:        c.TTL,
+		tenant:     tenantID,
+		generation: c.generation,
+		loads:      c.loads,
+	}
+}
+
+// OnPrimary returns the cached catalog, its misses are always loaded from the primary
+func (c *CachedCoffeeService) OnPrimary() CoffeeService {
+	return c
+}
+
+func (c *CachedCoffeeService) GetAllCoffees() ([]*Coffee, error) {
+	var coffees []*Coffee
+	err := c.read("all", &coffees, func() (interface{}, error) {
+		return onPrimary(c.Next).GetAllCoffees()
+	})
+	return coffees, err
+}
+
+func (c *CachedCoffeeService) GetCoffeesById(id string) (*Coffee, error) {
+	var coffee *Coffee
+	err := c.read("id:"+id, &coffee, func() (interface{}, error) {
+		return onPrimary(c.Next).GetCoffeesById(id)
+	})
+	return coffee, err
+}
+
+func (c *CachedCoffeeService) CreateCoffee(coffee Coffee) (*Coffee, error) {
+	defer c.Invalidate()
+	return c.Next.CreateCoffee(coffee)
+}
+
+func (c *CachedCoffeeService) UpdateCoffee(id string, coffee Coffee) (*Coffee, error) {
+	defer c.Invalidate()
+	return c.Next.UpdateCoffee(id, coffee)
+}
+
+func (c *CachedCoffeeService) DeleteCoffee(id string) error {
+	defer c.Invalidate()
+	return c.Next.DeleteCoffee(id)
+}
+
+func (c *CachedCoffeeService) SetCoffeeSlug(id string, slug string) (*Coffee, error) {
+	slugs, err := slugsOf(c.Next)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Invalidate()
+	return slugs.SetCoffeeSlug(id, slug)
+}
+
+func (c *CachedCoffeeService) ResetCoffeeSlug(id string) (*Coffee, error) {
+	slugs, err := slugsOf(c.Next)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Invalidate()
+	return slugs.ResetCoffeeSlug(id)
+}
+
+// Invalidate drops the cached catalog, the entries of the previous generation are never read again
+func (c *CachedCoffeeService) Invalidate() {
+	c.generation.Add(1)
+}
+
+// Follow invalidates the cache on the catalog changes made elsewhere until the context is done. The
+// changes of another instance arrive once the outbox relayed them and the OutboxFeed of this instance
+// followed them, a couple of seconds later. A change made straight in the database writes no event, it
+// shows once the TTL ran out. When the stream drops the subscription some changes may have been missed,
+// so the cache is invalidated before subscribing again.
+func (c *CachedCoffeeService) Follow(ctx context.Context, stream CoffeeStream) {
+	for ctx.Err() == nil {
+		changes, unsubscribe := stream.Subscribe()
+		c.follow(ctx, changes)
+		unsubscribe()
+		c.Invalidate()
+	}
+}
+
+func (c *CachedCoffeeService) follow(ctx context.Context, changes <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-changes:
+			if !ok {
+				return
+			}
+			if event.AggregateType == AggregateCoffee {
+				c.Invalidate()
+			}
+		}
+	}
+}
+
+// read decodes the cached value of the key into out, loading it from the next service on a miss.
+// Each caller decodes its own copy, so callers can't change what the others get.
+func (c *CachedCoffeeService) read(key string, out interface{}, load func() (interface{}, error)) error {
+	key = fmt.Sprintf("coffees:%s:%d:%s", c.tenant, c.generation.Load(), key)
+
+	data, ok := c.Store.Get(key)
+	if !ok {
+		var err error
+		data, err, _ = c.loads.Do(key, func() ([]byte, error) {
+			value, err := load()
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Store.Set(key, data, c.TTL)
+			return data, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, out)
+}
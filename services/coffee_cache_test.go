@@ -0,0 +1,186 @@
+package services_test
+
+import (
+	"coffee/coffee-server/cache"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Cached coffee service", Label("unit"), func() {
+	var (
+		mockedCoffee *mocks.CoffeeService
+		cached       *services.CachedCoffeeService
+	)
+
+	catalog := []*services.Coffee{{ID: "c1", Name: "Espresso"}, {ID: "c2", Name: "Mocha"}}
+
+	BeforeEach(func() {
+		mockedCoffee = new(mocks.CoffeeService)
+		cached = services.NewCachedCoffeeService(mockedCoffee, cache.NewLRU(100), time.Minute)
+	})
+
+	It("should serve the catalog from the cache after the first read", func() {
+		mockedCoffee.On("GetAllCoffees").Return(catalog, nil).Once()
+		mockedCoffee.On("GetCoffeesById", "c1").Return(catalog[0], nil).Once()
+
+		for range 3 {
+			coffees, err := cached.GetAllCoffees()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coffees).To(HaveLen(2))
+
+			coffee, err := cached.GetCoffeesById("c1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(coffee.Name).To(Equal("Espresso"))
+		}
+		mockedCoffee.AssertExpectations(GinkgoT())
+	})
+
+	It("should not cache the errors", func() {
+		mockedCoffee.On("GetCoffeesById", "c9").Return(nil, errors.New("sql: no rows in result set")).Twice()
+
+		_, err := cached.GetCoffeesById("c9")
+		Expect(err).To(HaveOccurred())
+		_, err = cached.GetCoffeesById("c9")
+		Expect(err).To(HaveOccurred())
+		mockedCoffee.AssertExpectations(GinkgoT())
+	})
+
+	It("should give every caller its own copy", func() {
+		mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Espresso"}, nil).Once()
+
+		first, _ := cached.GetCoffeesById("c1")
+		first.Name = "changed by the caller"
+
+		second, _ := cached.GetCoffeesById("c1")
+		Expect(second.Name).To(Equal("Espresso"))
+	})
+
+	It("should read the catalog again after a write", func() {
+		mockedCoffee.On("GetAllCoffees").Return(catalog, nil).Once()
+		mockedCoffee.On("UpdateCoffee", "c1", mock.Anything).Return(&services.Coffee{Name: "Ristretto"}, nil)
+		mockedCoffee.On("DeleteCoffee", "c2").Return(nil)
+		mockedCoffee.On("CreateCoffee", mock.Anything).Return(&services.Coffee{ID: "c3"}, nil)
+
+		cached.GetAllCoffees()
+		cached.GetAllCoffees()
+
+		mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1", Name: "Ristretto"}}, nil).Once()
+		_, err := cached.UpdateCoffee("c1", services.Coffee{Name: "Ristretto"})
+		Expect(err).NotTo(HaveOccurred())
+
+		coffees, _ := cached.GetAllCoffees()
+		Expect(coffees[0].Name).To(Equal("Ristretto"))
+
+		mockedCoffee.On("GetAllCoffees").Return([]*services.Coffee{}, nil).Twice()
+		Expect(cached.DeleteCoffee("c2")).To(Succeed())
+		cached.GetAllCoffees()
+		_, err = cached.CreateCoffee(services.Coffee{Name: "Kona"})
+		Expect(err).NotTo(HaveOccurred())
+		cached.GetAllCoffees()
+
+		mockedCoffee.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 4)
+	})
+
+	It("should read a coffee again after its slug changed and refuse a catalog without slugs", func() {
+		_, err := cached.SetCoffeeSlug("c1", "house-blend")
+		Expect(err).To(MatchError(services.ErrSlugsUnsupported))
+
+		slugs := new(mocks.CoffeeSlugs)
+		cached = services.NewCachedCoffeeService(sluggedCatalog{mockedCoffee, slugs}, cache.NewLRU(100), time.Minute)
+		mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Slug: "espresso"}, nil).Once()
+		slugs.On("SetCoffeeSlug", "c1", "house-blend").Return(&services.Coffee{ID: "c1", Slug: "house-blend"}, nil)
+
+		cached.GetCoffeesById("c1")
+		_, err = cached.SetCoffeeSlug("c1", "house-blend")
+		Expect(err).NotTo(HaveOccurred())
+
+		mockedCoffee.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Slug: "house-blend"}, nil).Once()
+		coffee, err := cached.GetCoffeesById("c1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffee.Slug).To(Equal("house-blend"))
+		mockedCoffee.AssertNumberOfCalls(GinkgoT(), "GetCoffeesById", 2)
+	})
+
+	It("should load the misses from the primary", func() {
+		primary := new(mocks.CoffeeService)
+		cached = services.NewCachedCoffeeService(&replicatedCatalog{CoffeeService: mockedCoffee, primary: primary}, cache.NewLRU(100), time.Minute)
+		mockedCoffee.On("UpdateCoffee", "c1", mock.Anything).Return(&services.Coffee{ID: "c1", Name: "Ristretto"}, nil)
+		primary.On("GetCoffeesById", "c1").Return(&services.Coffee{ID: "c1", Name: "Ristretto"}, nil).Once()
+
+		_, err := cached.UpdateCoffee("c1", services.Coffee{Name: "Ristretto"})
+		Expect(err).NotTo(HaveOccurred())
+		coffee, err := cached.GetCoffeesById("c1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffee.Name).To(Equal("Ristretto"))
+		mockedCoffee.AssertNotCalled(GinkgoT(), "GetCoffeesById", mock.Anything)
+		primary.AssertExpectations(GinkgoT())
+	})
+
+	It("should make a single call for concurrent misses", func() {
+		release := make(chan struct{})
+		mockedCoffee.On("GetAllCoffees").Run(func(mock.Arguments) { <-release }).Return(catalog, nil)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				coffees, err := cached.GetAllCoffees()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(coffees).To(HaveLen(2))
+			}()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		mockedCoffee.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 1)
+	})
+
+	It("should invalidate on the catalog changes made elsewhere", func() {
+		bus := events.NewMemoryBus(8)
+		stream := &services.CoffeeStreamImpl{Bus: bus}
+		mockedCoffee.On("GetAllCoffees").Return(catalog, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cached.Follow(ctx, stream)
+
+		cached.GetAllCoffees()
+		cached.GetAllCoffees()
+		mockedCoffee.AssertNumberOfCalls(GinkgoT(), "GetAllCoffees", 1)
+
+		// The subscription starts in the background, publish until the change is seen
+		Eventually(func() int {
+			bus.Publish(ctx, events.Event{ID: 1, AggregateType: services.AggregateCoffee, AggregateID: "c1", Type: services.EventCoffeeUpdated})
+			cached.GetAllCoffees()
+			return len(mockedCoffee.Calls)
+		}).Should(BeNumerically(">", 1))
+	})
+})
+
+// sluggedCatalog is a mocked catalog letting the slugs be set
+type sluggedCatalog struct {
+	*mocks.CoffeeService
+	*mocks.CoffeeSlugs
+}
+
+// replicatedCatalog reads from a replica and hands out the catalog of the primary like CoffeeServiceImpl does
+type replicatedCatalog struct {
+	services.CoffeeService
+	primary services.CoffeeService
+}
+
+func (c *replicatedCatalog) OnPrimary() services.CoffeeService {
+	return c.primary
+}
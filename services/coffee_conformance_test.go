@@ -0,0 +1,233 @@
+package services_test
+
+import (
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/services"
+	"database/sql"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// coffeeServiceConformance describes the behavior every CoffeeService implementation has to share,
+// newService returns an implementation holding an empty catalog
+func coffeeServiceConformance(newService func() services.CoffeeService) {
+	var service services.CoffeeService
+
+	missingId := "550e8400-e29b-41d4-a716-446655440099"
+	mocha := services.Coffee{Name: "Mocha", Roast: "Medium", Image: "mocha.png", Region: "Ethiopia", Price: 15.5, GrindUnit: 1}
+
+	BeforeEach(func() {
+		service = newService()
+	})
+
+	It("should start with an empty catalog", func() {
+		coffees, err := service.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffees).To(BeEmpty())
+	})
+
+	It("should give a created coffee a UUID and a slug and only fill in those", func() {
+		created, err := service.CreateCoffee(mocha)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID).To(MatchRegexp(uuidPattern.String()))
+
+		expected := mocha
+		expected.ID, expected.Slug = created.ID, "mocha-ethiopia"
+		Expect(created).To(Equal(&expected))
+	})
+
+	It("should store the created coffee with its timestamps", func() {
+		before := time.Now()
+		created, err := service.CreateCoffee(mocha)
+		Expect(err).NotTo(HaveOccurred())
+
+		stored, err := service.GetCoffeesById(created.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Name).To(Equal(mocha.Name))
+		Expect(stored.Price).To(Equal(mocha.Price))
+		Expect(stored.CreatedAt).To(BeTemporally("~", before, time.Second))
+		Expect(stored.UpdatedAt).To(BeTemporally("==", stored.CreatedAt))
+
+		all, err := service.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(1))
+		Expect(all[0]).To(Equal(stored))
+	})
+
+	It("should return sql.ErrNoRows for a missing coffee", func() {
+		_, err := service.GetCoffeesById(missingId)
+		Expect(err).To(MatchError(sql.ErrNoRows))
+	})
+
+	It("should update the fields and the updated_at of a coffee", func() {
+		created, err := service.CreateCoffee(mocha)
+		Expect(err).NotTo(HaveOccurred())
+		original, err := service.GetCoffeesById(created.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(5 * time.Millisecond)
+		change := mocha
+		change.Name, change.Price = "Mocha Java", 17
+		updated, err := service.UpdateCoffee(created.ID, change)
+		Expect(err).NotTo(HaveOccurred())
+		change.Slug = "mocha-java-ethiopia"
+		Expect(updated).To(Equal(&change))
+
+		stored, err := service.GetCoffeesById(created.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Name).To(Equal("Mocha Java"))
+		Expect(stored.Price).To(Equal(float32(17)))
+		Expect(stored.CreatedAt).To(BeTemporally("==", original.CreatedAt))
+		Expect(stored.UpdatedAt).To(BeTemporally(">", original.UpdatedAt))
+	})
+
+	It("should ignore an update or a delete of a missing coffee", func() {
+		_, err := service.UpdateCoffee(missingId, mocha)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.DeleteCoffee(missingId)).To(Succeed())
+
+		coffees, err := service.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffees).To(BeEmpty())
+	})
+
+	It("should delete a coffee", func() {
+		kept, err := service.CreateCoffee(mocha)
+		Expect(err).NotTo(HaveOccurred())
+		deleted, err := service.CreateCoffee(services.Coffee{Name: "Kona", Roast: "Light", Region: "Hawaii", Price: 30})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(service.DeleteCoffee(deleted.ID)).To(Succeed())
+
+		_, err = service.GetCoffeesById(deleted.ID)
+		Expect(err).To(MatchError(sql.ErrNoRows))
+		coffees, err := service.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffees).To(HaveLen(1))
+		Expect(coffees[0].ID).To(Equal(kept.ID))
+	})
+
+	Describe("Slugs", func() {
+		It("should number the slug of a coffee with the name and region of another", func() {
+			first, err := service.CreateCoffee(mocha)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := service.CreateCoffee(mocha)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.Slug).To(Equal("mocha-ethiopia"))
+			Expect(second.Slug).To(Equal("mocha-ethiopia-2"))
+			found, err := service.GetCoffeesById("mocha-ethiopia-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.ID).To(Equal(second.ID))
+		})
+
+		It("should find a renamed coffee by its former slug and keep the slug from the others", func() {
+			created, err := service.CreateCoffee(mocha)
+			Expect(err).NotTo(HaveOccurred())
+			renamed := mocha
+			renamed.Name = "Mocha Java"
+			_, err = service.UpdateCoffee(created.ID, renamed)
+			Expect(err).NotTo(HaveOccurred())
+
+			found, err := service.GetCoffeesById("mocha-ethiopia")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.ID).To(Equal(created.ID))
+			Expect(found.Slug).To(Equal("mocha-java-ethiopia"))
+
+			other, err := service.CreateCoffee(mocha)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.Slug).To(Equal("mocha-ethiopia-2"))
+
+			// Renamed back, the coffee gets its former slug again
+			_, err = service.UpdateCoffee(created.ID, mocha)
+			Expect(err).NotTo(HaveOccurred())
+			found, err = service.GetCoffeesById(created.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.Slug).To(Equal("mocha-ethiopia"))
+		})
+
+		It("should keep an overridden slug through the renames until it is reset", func() {
+			slugs, ok := service.(services.CoffeeSlugs)
+			Expect(ok).To(BeTrue())
+			created, err := service.CreateCoffee(mocha)
+			Expect(err).NotTo(HaveOccurred())
+			other, err := service.CreateCoffee(services.Coffee{Name: "Kona", Region: "Hawaii"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = slugs.SetCoffeeSlug(created.ID, "Our Mocha")
+			Expect(err).To(MatchError(services.ErrInvalidSlug))
+			_, err = slugs.SetCoffeeSlug(created.ID, other.Slug)
+			Expect(err).To(MatchError(services.ErrSlugTaken))
+			_, err = slugs.SetCoffeeSlug(missingId, "our-mocha")
+			Expect(err).To(MatchError(services.ErrCoffeeNotFound))
+
+			set, err := slugs.SetCoffeeSlug(created.ID, "our-mocha")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Slug).To(Equal("our-mocha"))
+			renamed := mocha
+			renamed.Name = "Mocha Java"
+			_, err = service.UpdateCoffee(created.ID, renamed)
+			Expect(err).NotTo(HaveOccurred())
+			found, err := service.GetCoffeesById("mocha-ethiopia")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.Slug).To(Equal("our-mocha"))
+
+			reset, err := slugs.ResetCoffeeSlug(created.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reset.Slug).To(Equal("mocha-java-ethiopia"))
+			found, err = service.GetCoffeesById("our-mocha")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.ID).To(Equal(created.ID))
+		})
+	})
+}
+
+var _ = Describe("Coffee service conformance", func() {
+	Describe("MemoryCoffeeService", Label("unit"), func() {
+		coffeeServiceConformance(func() services.CoffeeService {
+			return services.NewMemoryCoffeeService(events.NewMemoryBus(8))
+		})
+	})
+
+	Describe("CoffeeServiceImpl", Label("integration"), func() {
+		coffeeServiceConformance(func() services.CoffeeService {
+			_, err := db.Exec("DELETE FROM coffees")
+			Expect(err).NotTo(HaveOccurred())
+			return &services.CoffeeServiceImpl{DB: conn}
+		})
+	})
+})
+
+var _ = Describe("Memory coffee stream", Label("unit"), func() {
+	It("should publish the catalog changes and replay them", func() {
+		bus := events.NewMemoryBus(8)
+		memory := services.NewMemoryCoffeeService(bus)
+		live, stop := memory.Subscribe()
+		defer stop()
+
+		created, err := memory.CreateCoffee(services.Coffee{Name: "Mocha"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = memory.UpdateCoffee(created.ID, services.Coffee{Name: "Mocha Java"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memory.DeleteCoffee(created.ID)).To(Succeed())
+
+		var received []string
+		for range 3 {
+			event := <-live
+			Expect(event.AggregateID).To(Equal(created.ID))
+			received = append(received, event.Type)
+		}
+		Expect(received).To(Equal([]string{services.EventCoffeeCreated, services.EventCoffeeUpdated, services.EventCoffeeDeleted}))
+
+		missed, err := memory.Replay(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missed).To(HaveLen(2))
+		Expect(missed[0].ID).To(Equal(int64(2)))
+		Expect(string(missed[0].Payload)).To(ContainSubstring(`"name":"Mocha Java"`))
+	})
+})
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"coffee/coffee-server/events"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memoryEventLog is how many past catalog changes the in-memory catalog keeps to replay
+const memoryEventLog = 1000
+
+// MemoryCoffeeService keeps the catalog in memory, for development and tests without Postgres.
+// It behaves like CoffeeServiceImpl: ids are random UUIDs, the slugs are generated the same way and
+// the former ones still find their coffee, the timestamps have the precision of Postgres, a missing
+// coffee is sql.ErrNoRows, and updating or deleting one is a no-op.
+// It also feeds the catalog changes to the stream, since there is no outbox to relay them.
+type MemoryCoffeeService struct {
+	Bus *events.MemoryBus
+
+	mu      sync.RWMutex
+	coffees map[string]Coffee
+	order   []string
+	log     []events.Event
+	lastId  int64
+	// formerSlugs are the slugs the coffees had before, customSlugs the coffees whose slug was set
+	formerSlugs map[string]string
+	customSlugs map[string]bool
+}
+
+var (
+	_ CoffeeService = (*MemoryCoffeeService)(nil)
+	_ CoffeeStream  = (*MemoryCoffeeService)(nil)
+	_ CoffeeSlugs   = (*MemoryCoffeeService)(nil)
+)
+
+func NewMemoryCoffeeService(bus *events.MemoryBus) *MemoryCoffeeService {
+	return &MemoryCoffeeService{Bus: bus, coffees: map[string]Coffee{}, formerSlugs: map[string]string{}, customSlugs: map[string]bool{}}
+}
+
+// GetAllCoffees returns the coffees in the order they were created, nil when there are none like the database does
+func (m *MemoryCoffeeService) GetAllCoffees() ([]*Coffee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var coffees []*Coffee
+	for _, id := range m.order {
+		coffee := m.coffees[id]
+		coffees = append(coffees, &coffee)
+	}
+	return coffees, nil
+}
+
+func (m *MemoryCoffeeService) CreateCoffee(coffee Coffee) (*Coffee, error) {
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	coffee.ID = id
+	coffee.Slug = uniqueSlug(Slugify(coffee.Name, coffee.Region), m.takenSlugs(id))
+	stored := coffee
+	stored.CreatedAt, stored.UpdatedAt = now, now
+	m.coffees[id] = stored
+	m.order = append(m.order, id)
+
+	m.publish(id, EventCoffeeCreated, coffee)
+	// Like the database, only the id and the slug are filled in on the returned coffee
+	return &coffee, nil
+}
+
+func (m *MemoryCoffeeService) GetCoffeesById(id string) (*Coffee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	coffee, ok := m.coffees[id]
+	if !ok {
+		coffee, ok = m.bySlug(id)
+	}
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &coffee, nil
+}
+
+func (m *MemoryCoffeeService) bySlug(slug string) (Coffee, bool) {
+	for _, coffee := range m.coffees {
+		if coffee.Slug == slug {
+			return coffee, true
+		}
+	}
+	coffee, ok := m.coffees[m.formerSlugs[slug]]
+	return coffee, ok
+}
+
+// takenSlugs are the slugs the other coffees have or had, called with the lock held
+func (m *MemoryCoffeeService) takenSlugs(id string) map[string]bool {
+	taken := map[string]bool{}
+	for _, coffee := range m.coffees {
+		if coffee.ID != id {
+			taken[coffee.Slug] = true
+		}
+	}
+	for slug, owner := range m.formerSlugs {
+		if owner != id {
+			taken[slug] = true
+		}
+	}
+	return taken
+}
+
+// moveSlug gives the stored coffee the slug, keeping its previous one, called with the lock held
+func (m *MemoryCoffeeService) moveSlug(stored *Coffee, slug string) {
+	if stored.Slug == slug {
+		return
+	}
+	delete(m.formerSlugs, slug)
+	m.formerSlugs[stored.Slug] = stored.ID
+	stored.Slug = slug
+}
+
+func (m *MemoryCoffeeService) SetCoffeeSlug(id string, slug string) (*Coffee, error) {
+	if err := ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	return m.changeSlug(id, true, func(stored Coffee) (string, error) {
+		for _, coffee := range m.coffees {
+			if coffee.ID != id && coffee.Slug == slug {
+				return "", fmt.Errorf("%w: %q names another coffee", ErrSlugTaken, slug)
+			}
+		}
+		return slug, nil
+	})
+}
+
+func (m *MemoryCoffeeService) ResetCoffeeSlug(id string) (*Coffee, error) {
+	return m.changeSlug(id, false, func(stored Coffee) (string, error) {
+		return uniqueSlug(Slugify(stored.Name, stored.Region), m.takenSlugs(id)), nil
+	})
+}
+
+func (m *MemoryCoffeeService) changeSlug(id string, custom bool, pick func(stored Coffee) (string, error)) (*Coffee, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.coffees[id]
+	if !ok {
+		return nil, ErrCoffeeNotFound
+	}
+	slug, err := pick(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	m.moveSlug(&stored, slug)
+	stored.UpdatedAt = memoryNow()
+	m.coffees[id] = stored
+	if custom {
+		m.customSlugs[id] = true
+	} else {
+		delete(m.customSlugs, id)
+	}
+
+	m.publish(id, EventCoffeeUpdated, stored)
+	coffee := stored
+	return &coffee, nil
+}
+
+func (m *MemoryCoffeeService) UpdateCoffee(id string, coffee Coffee) (*Coffee, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.coffees[id]
+	if ok {
+		previous := stored
+		stored = coffee
+		stored.ID, stored.Slug, stored.CreatedAt, stored.UpdatedAt = id, previous.Slug, previous.CreatedAt, memoryNow()
+		if !m.customSlugs[id] && Slugify(coffee.Name, coffee.Region) != Slugify(previous.Name, previous.Region) {
+			m.moveSlug(&stored, uniqueSlug(Slugify(coffee.Name, coffee.Region), m.takenSlugs(id)))
+		}
+		m.coffees[id] = stored
+
+		coffee.Slug = stored.Slug
+		event := coffee
+		event.ID = id
+		m.publish(id, EventCoffeeUpdated, event)
+	}
+	return &coffee, nil
+}
+
+func (m *MemoryCoffeeService) DeleteCoffee(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.coffees[id]; !ok {
+		return nil
+	}
+	delete(m.coffees, id)
+	delete(m.customSlugs, id)
+	for slug, owner := range m.formerSlugs {
+		if owner == id {
+			delete(m.formerSlugs, slug)
+		}
+	}
+	for i, existing := range m.order {
+		if existing == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+
+	m.publish(id, EventCoffeeDeleted, map[string]string{"id": id})
+	return nil
+}
+
+func (m *MemoryCoffeeService) Subscribe() (<-chan events.Event, func()) {
+	return m.Bus.Subscribe()
+}
+
+// Replay returns the catalog changes after the given event ID, as far back as the log goes
+func (m *MemoryCoffeeService) Replay(afterId int64) ([]events.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	missed := []events.Event{}
+	for _, event := range m.log {
+		if event.ID > afterId {
+			missed = append(missed, event)
+		}
+	}
+	return missed, nil
+}
+
+// publish logs the change and hands it to the bus, it is called with the lock held so the events keep
+// the order of the writes
+func (m *MemoryCoffeeService) publish(id string, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	m.lastId++
+	event := events.Event{
+		ID:            m.lastId,
+		AggregateType: AggregateCoffee,
+		AggregateID:   id,
+		Type:          eventType,
+		Payload:       payload,
+		OccurredAt:    memoryNow(),
+	}
+
+	m.log = append(m.log, event)
+	if len(m.log) > memoryEventLog {
+		m.log = m.log[len(m.log)-memoryEventLog:]
+	}
+	if m.Bus != nil {
+		m.Bus.Publish(context.Background(), event)
+	}
+}
+
+// memoryNow drops what Postgres can't store, the nanoseconds and the monotonic clock
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// newUUID returns a random version 4 UUID, as generated by uuid_generate_v4()
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrInvalidSlug      = errors.New("invalid slug")
+	ErrSlugTaken        = errors.New("slug is taken")
+	ErrSlugsUnsupported = errors.New("the catalog can't change the slugs")
+)
+
+const (
+	// maxSlugLength is how long a generated slug gets before its number, in bytes
+	maxSlugLength = 80
+	// slugLockKey is the advisory lock held while giving a coffee a slug, with the tenant as second key,
+	// so two coffees of a shop can't pick the same free slug at once
+	slugLockKey = 7312
+)
+
+var (
+	coffeeSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	uuidLike   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// CoffeeSlugs is implemented by the catalogs letting an admin pick the slugs of the coffees
+type CoffeeSlugs interface {
+	// SetCoffeeSlug gives the coffee the slug, it is kept through the renames until it is reset
+	SetCoffeeSlug(id string, slug string) (*Coffee, error)
+	// ResetCoffeeSlug generates the slug of the coffee from its name and region again
+	ResetCoffeeSlug(id string) (*Coffee, error)
+}
+
+// slugsOf returns the catalog as CoffeeSlugs, for the catalogs wrapping another one
+func slugsOf(coffees CoffeeService) (CoffeeSlugs, error) {
+	slugs, ok := coffees.(CoffeeSlugs)
+	if !ok {
+		return nil, ErrSlugsUnsupported
+	}
+	return slugs, nil
+}
+
+// Slugify makes the slug of a coffee from its name and region: lowercase ASCII letters and digits
+// separated by dashes, the accents dropped, e.g. "Café Crème" from "Côte d'Ivoire" is
+// cafe-creme-cote-d-ivoire. A name without any of them gives "coffee".
+func Slugify(name string, region string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(name + " " + region)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// The accent of the letter before
+		case 'a' <= r && r <= 'z' || '0' <= r && r <= '9':
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	generated := slug.String()
+	if len(generated) > maxSlugLength {
+		generated = strings.TrimRight(generated[:maxSlugLength], "-")
+	}
+	if generated == "" {
+		return "coffee"
+	}
+	return generated
+}
+
+// ValidateSlug checks a slug picked by an admin. It can't look like a UUID, the coffees are looked up by both.
+func ValidateSlug(slug string) error {
+	if !coffeeSlug.MatchString(slug) {
+		return fmt.Errorf("%w: slug must be lowercase letters and digits separated by dashes", ErrInvalidSlug)
+	}
+	if isUUID(slug) {
+		return fmt.Errorf("%w: slug can't be a UUID", ErrInvalidSlug)
+	}
+	return nil
+}
+
+func isUUID(s string) bool {
+	return uuidLike.MatchString(s)
+}
+
+// uniqueSlug returns the base, or the base numbered from 2 when it is taken
+func uniqueSlug(base string, taken map[string]bool) string {
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// lockSlugs holds the slugs of the tenant until the transaction ends
+func (c *CoffeeServiceImpl) lockSlugs(ctx context.Context, tx db.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1::int, hashtext($2))`, slugLockKey, c.tenant())
+	return err
+}
+
+// generateSlug returns a free slug for the coffee made from its name and region. The slugs the other
+// coffees had before are taken too, so their old URLs keep redirecting. It is called with the slugs locked.
+func (c *CoffeeServiceImpl) generateSlug(ctx context.Context, tx db.Tx, id string, name string, region string) (string, error) {
+	base := Slugify(name, region)
+
+	query := `SELECT slug FROM coffees WHERE tenant_id = $1 AND id::text <> $2 AND (slug = $3 OR slug LIKE $4)
+		UNION SELECT slug FROM coffee_slug_history WHERE tenant_id = $1 AND coffee_id::text <> $2 AND (slug = $3 OR slug LIKE $4)`
+
+	taken, err := takenSlugs(ctx, tx, query, c.tenant(), id, base, base+"-%")
+	if err != nil {
+		return "", err
+	}
+	return uniqueSlug(base, taken), nil
+}
+
+// takenSlugs returns the slugs the query selects
+func takenSlugs(ctx context.Context, tx db.Tx, query string, args ...interface{}) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := map[string]bool{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		taken[slug] = true
+	}
+	return taken, rows.Err()
+}
+
+// moveSlug keeps the previous slug of the coffee in its history, and takes the new one out of it
+func (c *CoffeeServiceImpl) moveSlug(ctx context.Context, tx db.Tx, id string, from string, to string) error {
+	if from == to {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM coffee_slug_history WHERE tenant_id = $1 AND slug = $2`, c.tenant(), to); err != nil {
+		return err
+	}
+	query := `INSERT INTO coffee_slug_history(tenant_id, slug, coffee_id, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, slug) DO UPDATE SET coffee_id = EXCLUDED.coffee_id, created_at = EXCLUDED.created_at`
+	_, err := tx.ExecContext(ctx, query, c.tenant(), from, id, time.Now())
+	return err
+}
+
+// SetCoffeeSlug gives the coffee the slug, a slug another coffee had before is taken from its history
+func (c *CoffeeServiceImpl) SetCoffeeSlug(id string, slug string) (*Coffee, error) {
+	if err := ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	return c.changeSlug(id, true, func(ctx context.Context, tx db.Tx, current *Coffee) (string, error) {
+		var taken bool
+		query := `SELECT EXISTS (SELECT 1 FROM coffees WHERE tenant_id = $1 AND slug = $2 AND id::text <> $3)`
+		if err := tx.QueryRowContext(ctx, query, c.tenant(), slug, id).Scan(&taken); err != nil {
+			return "", err
+		}
+		if taken {
+			return "", fmt.Errorf("%w: %q names another coffee", ErrSlugTaken, slug)
+		}
+		return slug, nil
+	})
+}
+
+func (c *CoffeeServiceImpl) ResetCoffeeSlug(id string) (*Coffee, error) {
+	return c.changeSlug(id, false, func(ctx context.Context, tx db.Tx, current *Coffee) (string, error) {
+		return c.generateSlug(ctx, tx, id, current.Name, current.Region)
+	})
+}
+
+// changeSlug gives the coffee the slug picked from its current state, publishing the change like an update
+func (c *CoffeeServiceImpl) changeSlug(id string, custom bool, pick func(ctx context.Context, tx db.Tx, current *Coffee) (string, error)) (*Coffee, error) {
+	if !isUUID(id) {
+		return nil, ErrCoffeeNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The row is locked before the slugs like UpdateCoffee does
+	query := `SELECT ` + coffeeSelect + ` FROM coffees WHERE id = $1 AND tenant_id = $2 FOR UPDATE`
+	coffee, err := scanCoffee(tx.QueryRowContext(ctx, query, id, c.tenant()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCoffeeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.lockSlugs(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	slug, err := pick(ctx, tx, coffee)
+	if err != nil {
+		return nil, err
+	}
+
+	coffee.UpdatedAt = time.Now()
+	query = `UPDATE coffees SET slug = $1, slug_custom = $2, updated_at = $3 WHERE id = $4 AND tenant_id = $5`
+	if _, err := tx.ExecContext(ctx, query, slug, custom, coffee.UpdatedAt, id, c.tenant()); err != nil {
+		return nil, err
+	}
+	if err := c.moveSlug(ctx, tx, id, coffee.Slug, slug); err != nil {
+		return nil, err
+	}
+	coffee.Slug = slug
+
+	if err := insertOutboxEvent(ctx, tx, AggregateCoffee, id, EventCoffeeUpdated, coffeeEvent{*coffee, c.tenant()}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return coffee, nil
+}
@@ -0,0 +1,35 @@
+package services_test
+
+import (
+	"coffee/coffee-server/services"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Coffee slugs", Label("unit"), func() {
+	DescribeTable("should make a slug from the name and region",
+		func(name string, region string, slug string) {
+			Expect(services.Slugify(name, region)).To(Equal(slug))
+		},
+		Entry("plain words", "Mocha", "Ethiopia", "mocha-ethiopia"),
+		Entry("accents and punctuation", "Café Crème", "Côte d'Ivoire", "cafe-creme-cote-d-ivoire"),
+		Entry("digits and spaces around", "  No. 5 ", "", "no-5"),
+		Entry("nothing to keep", "珈琲", "", "coffee"),
+	)
+
+	It("should cut a long slug without leaving a dash", func() {
+		slug := services.Slugify(strings.Repeat("abcdefghi ", 10), "Kenya")
+		Expect(len(slug)).To(BeNumerically("<=", 80))
+		Expect(slug).NotTo(HaveSuffix("-"))
+		Expect(slug).To(HavePrefix("abcdefghi-abcdefghi"))
+	})
+
+	It("should only take slugs that can't be mistaken for an id", func() {
+		Expect(services.ValidateSlug("house-blend-2")).To(Succeed())
+		Expect(services.ValidateSlug("House Blend")).To(MatchError(services.ErrInvalidSlug))
+		Expect(services.ValidateSlug("house--blend")).To(MatchError(services.ErrInvalidSlug))
+		Expect(services.ValidateSlug("550e8400-e29b-41d4-a716-446655440099")).To(MatchError(services.ErrInvalidSlug))
+	})
+})
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/events"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// replayBatch is how many past events are read from the outbox at once when a client resumes
+const replayBatch = 500
+
+// CoffeeStream feeds the live stream of catalog changes
+type CoffeeStream interface {
+	Subscribe() (<-chan events.Event, func())
+	Replay(afterId int64) ([]events.Event, error)
+}
+
+// Concrete implementation of CoffeeStream: live events come from the bus the OutboxFeed of the instance
+// publishes to, missed ones are read back from the outbox
+type CoffeeStreamImpl struct {
+	DB  db.Querier
+	Bus *events.MemoryBus
+}
+
+func (c *CoffeeStreamImpl) Subscribe() (<-chan events.Event, func()) {
+	return c.Bus.Subscribe()
+}
+
+// Replay returns the published coffee events after the given event ID, oldest first
+func (c *CoffeeStreamImpl) Replay(afterId int64) ([]events.Event, error) {
+	all := []events.Event{}
+	for {
+		batch, err := c.replayBatch(afterId)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < replayBatch {
+			return all, nil
+		}
+		afterId = batch[len(batch)-1].ID
+	}
+}
+
+func (c *CoffeeStreamImpl) replayBatch(afterId int64) ([]events.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at FROM outbox_events
+		WHERE aggregate_type = $1 AND id > $2 AND published_at IS NOT NULL ORDER BY id LIMIT $3`
+
+	rows, err := c.DB.QueryContext(ctx, query, AggregateCoffee, afterId, replayBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []events.Event
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.Type, &payload, &event.OccurredAt); err != nil {
+			return nil, err
+		}
+		event.Payload = json.RawMessage(payload)
+		batch = append(batch, event)
+	}
+	return batch, rows.Err()
+}
+
+// StreamFor returns the changes of the catalog of the tenant. The events from before the tenants carry
+// none, they are the changes of the default tenant.
+func StreamFor(stream CoffeeStream, tenantID string) CoffeeStream {
+	if tenantID == "" {
+		tenantID = DefaultTenant
+	}
+	return &tenantStream{Next: stream, Tenant: tenantID}
+}
+
+type tenantStream struct {
+	Next   CoffeeStream
+	Tenant string
+}
+
+func (s *tenantStream) Subscribe() (<-chan events.Event, func()) {
+	in, unsubscribe := s.Next.Subscribe()
+	out := make(chan events.Event, cap(in))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+		for event := range in {
+			if !s.owns(event) {
+				continue
+			}
+			select {
+			case out <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}
+}
+
+func (s *tenantStream) Replay(afterId int64) ([]events.Event, error) {
+	all, err := s.Next.Replay(afterId)
+	if err != nil {
+		return nil, err
+	}
+	owned := []events.Event{}
+	for _, event := range all {
+		if s.owns(event) {
+			owned = append(owned, event)
+		}
+	}
+	return owned, nil
+}
+
+// owns reports whether the event is a change of the catalog of the tenant
+func (s *tenantStream) owns(event events.Event) bool {
+	if event.AggregateType != AggregateCoffee {
+		return false
+	}
+	var payload struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return false
+	}
+	if payload.TenantID == "" {
+		return s.Tenant == DefaultTenant
+	}
+	return payload.TenantID == s.Tenant
+}
@@ -1,21 +1,25 @@
 package services_test
 
 import (
+	database "coffee/coffee-server/db"
+	"coffee/coffee-server/mocks"
 	"coffee/coffee-server/services"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"log"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
 
-	_ "github.com/jackc/pgconn"
-	_ "github.com/jackc/pgx/stdlib"
-	_ "github.com/jackc/pgx/v4"
-	_ "github.com/lib/pq"
+	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
 var (
 	db            *sql.DB
+	conn          database.DBInterface // The pool the services run their queries on
 	coffeeService services.CoffeeService
 )
 
@@ -28,9 +32,14 @@ var _ = BeforeSuite(func() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	// The fixtures go through database/sql, the services through the pool like the server
+	conn, err = database.OpenPostgres(connStr)
+	if err != nil {
+		log.Fatalf("Failed to set up the pool: %v", err)
+	}
 
 	// Initialize the Models struct with the database connection
-	models := services.New(db)
+	models := services.New(conn)
 	coffeeService = models.Coffee
 
 })
@@ -39,6 +48,9 @@ var _ = AfterSuite(func() {
 	if db != nil {
 		db.Close()
 	}
+	if conn != nil {
+		conn.Close()
+	}
 })
 
 var _ = Describe("Coffee Service", Label("integration"), func() {
@@ -71,6 +83,9 @@ var _ = Describe("Coffee Service", Label("integration"), func() {
 
 			createdCoffee, err := coffeeService.CreateCoffee(newCoffee)
 			Expect(err).To(BeNil())
+			Expect(createdCoffee.ID).NotTo(BeEmpty())
+
+			newCoffee.ID = createdCoffee.ID
 			Expect(createdCoffee).To(Equal(&newCoffee))
 		})
 	})
@@ -121,3 +136,129 @@ var _ = Describe("Coffee Service", Label("integration"), func() {
 		})
 	})
 })
+
+var _ = Describe("Coffee Service on a query executor", Label("unit"), func() {
+	var (
+		conn    *mocks.DBInterface
+		tx      *mocks.Tx
+		service *services.CoffeeServiceImpl
+	)
+
+	BeforeEach(func() {
+		conn = &mocks.DBInterface{}
+		tx = &mocks.Tx{}
+		service = &services.CoffeeServiceImpl{DB: conn}
+	})
+
+	AfterEach(func() {
+		conn.AssertExpectations(GinkgoT())
+		tx.AssertExpectations(GinkgoT())
+	})
+
+	It("returns the error of the query", func() {
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DefaultTenant).Return(nil, errors.New("connection refused"))
+
+		coffees, err := service.GetAllCoffees()
+		Expect(err).To(MatchError("connection refused"))
+		Expect(coffees).To(BeNil())
+	})
+
+	It("reads the catalog through Reads when it is set", func() {
+		reads := &mocks.DBInterface{}
+		service.Reads = reads
+		reads.On("QueryContext", mock.Anything, mock.Anything, services.DefaultTenant).Return(nil, errors.New("replica is down")).Once()
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+		// "42" isn't a UUID, it is looked up as a slug and then as a former slug
+		reads.On("QueryRowContext", mock.Anything, mock.Anything, "42", services.DefaultTenant).Return(row).Twice()
+
+		_, err := service.GetAllCoffees()
+		Expect(err).To(MatchError("replica is down"))
+		_, err = service.GetCoffeesById("42")
+		Expect(err).To(MatchError(sql.ErrNoRows))
+		reads.AssertExpectations(GinkgoT())
+	})
+
+	It("returns the error when the transaction can't begin", func() {
+		conn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(nil, errors.New("too many connections"))
+
+		coffee, err := service.CreateCoffee(services.Coffee{Name: "Espresso"})
+		Expect(err).To(MatchError("too many connections"))
+		Expect(coffee).To(BeNil())
+	})
+
+	It("rolls back the transaction when the update fails", func() {
+		conn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(tx, nil)
+		tx.On("QueryRowContext", mock.Anything, mock.Anything, "42", services.DefaultTenant).Return(&rowsOf{{"Espresso", "", "espresso", false}})
+		tx.On("ExecContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, "42", services.DefaultTenant).
+			Return(nil, errors.New("deadlock detected"))
+		tx.On("Rollback").Return(nil)
+
+		_, err := service.UpdateCoffee("42", services.Coffee{Name: "Espresso"})
+		Expect(err).To(MatchError("deadlock detected"))
+		tx.AssertNotCalled(GinkgoT(), "Commit")
+	})
+
+	It("commits a delete of a missing coffee without an event", func() {
+		conn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(tx, nil)
+		tx.On("ExecContext", mock.Anything, `DELETE FROM coffees WHERE id = $1 AND tenant_id = $2`, "42", services.DefaultTenant).Return(driver.RowsAffected(0), nil)
+		tx.On("Commit").Return(nil)
+		tx.On("Rollback").Return(sql.ErrTxDone)
+
+		Expect(service.DeleteCoffee("42")).To(Succeed())
+		tx.AssertNumberOfCalls(GinkgoT(), "ExecContext", 1)
+	})
+})
+
+var _ = Describe("Coffee import", func() {
+	coffees := []services.Coffee{
+		{ID: "ignored", Name: "Espresso", Roast: "Dark", Region: "Brazil", Price: 10, GrindUnit: 1},
+		{Name: "Mocha", Roast: "Medium", Region: "Yemen", Price: 12.5, GrindUnit: 2},
+	}
+
+	It("inserts the coffees one by one without bulk writes", Label("unit"), func() {
+		conn := &mocks.DBInterface{}
+		tx := &mocks.Tx{}
+		conn.On("BeginTx", mock.Anything, (*sql.TxOptions)(nil)).Return(tx, nil)
+		tx.On("ExecContext", mock.Anything, mock.MatchedBy(func(query string) bool { return strings.Contains(query, "pg_advisory_xact_lock") }), mock.Anything, services.DefaultTenant).
+			Return(driver.RowsAffected(0), nil).Once()
+		tx.On("QueryContext", mock.Anything, mock.MatchedBy(func(query string) bool { return strings.HasPrefix(query, "SELECT slug FROM coffees") }), services.DefaultTenant).
+			Return(&rowsOf{{"espresso-brazil"}}, nil).Once()
+		tx.On("ExecContext", mock.Anything, mock.MatchedBy(func(query string) bool { return strings.HasPrefix(query, "INSERT INTO coffees(id, slug, name") }),
+			mock.Anything, "espresso-brazil-2", "Espresso", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, services.DefaultTenant).Return(driver.RowsAffected(1), nil).Once()
+		tx.On("ExecContext", mock.Anything, mock.MatchedBy(func(query string) bool { return strings.HasPrefix(query, "INSERT INTO coffees(id, slug, name") }),
+			mock.Anything, "mocha-yemen", "Mocha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, services.DefaultTenant).Return(driver.RowsAffected(1), nil).Once()
+		tx.On("ExecContext", mock.Anything, mock.MatchedBy(func(query string) bool { return strings.HasPrefix(query, "INSERT INTO outbox_events") }),
+			services.AggregateCoffee, mock.Anything, services.EventCoffeeCreated, mock.Anything, mock.Anything).Return(driver.RowsAffected(1), nil).Twice()
+		tx.On("Commit").Return(nil)
+		tx.On("Rollback").Return(sql.ErrTxDone)
+
+		imported, err := (&services.CoffeeServiceImpl{DB: conn}).ImportCoffees(coffees)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imported).To(HaveLen(2))
+		Expect(imported[0].ID).NotTo(Equal("ignored"))
+		Expect(imported[0].ID).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+		Expect(imported[1].Name).To(Equal("Mocha"))
+		Expect(imported[0].Slug).To(Equal("espresso-brazil-2"))
+		Expect(imported[0].CreatedAt).NotTo(BeZero())
+		Expect(imported[0].UpdatedAt).To(Equal(imported[0].CreatedAt))
+		tx.AssertExpectations(GinkgoT())
+	})
+
+	It("copies the coffees and their events", Label("integration"), func() {
+		_, err := db.Exec("DELETE FROM coffees")
+		Expect(err).NotTo(HaveOccurred())
+
+		impl := &services.CoffeeServiceImpl{DB: conn}
+		imported, err := impl.ImportCoffees(coffees)
+		Expect(err).NotTo(HaveOccurred())
+
+		all, err := impl.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(2))
+
+		var events int
+		Expect(db.QueryRow(`SELECT count(*) FROM outbox_events WHERE aggregate_id = $1 AND event_type = $2`, imported[1].ID, services.EventCoffeeCreated).Scan(&events)).To(Succeed())
+		Expect(events).To(Equal(1))
+	})
+})
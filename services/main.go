@@ -1,7 +1,10 @@
 package services
 
 import (
-	"database/sql"
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/payments"
+	"coffee/coffee-server/webhooks"
 	"time"
 )
 
@@ -9,12 +12,93 @@ const dbTimeout = time.Second * 3
 
 type Models struct {
 	Coffee       CoffeeService
+	CoffeeStream CoffeeStream
+	Order        OrderService
+	Cart         CartService
+	Payment      PaymentService
+	Promotion    PromotionService
+	Webhook      WebhookService
+	Outbox       OutboxService
+	Tenant       TenantService
+	Translation  TranslationService
+	Events       *events.MemoryBus
+	// Feed publishes to Events the events relayed by any instance, it is nil without a database
+	Feed *OutboxFeed
+	// Tx runs units of work spanning several services, it is nil in a unit of work and without a database
+	Tx           *TxManager
 	JsonResponse JsonResponse
 }
 
-func New(dbPool *sql.DB) Models {
+// New returns the models backed by the database. They take no payment until a provider is given with
+// WithPaymentProvider, there is no default one.
+func New(dbPool db.Querier) Models {
+	orders := &OrderServiceImpl{DB: dbPool}
+	carts := &CartServiceImpl{DB: dbPool}
+	hooks := &WebhookServiceImpl{DB: dbPool, Sender: webhooks.NewSender()}
+	bus := events.NewMemoryBus(64)
+
 	return Models{
 		Coffee:       &CoffeeServiceImpl{DB: dbPool}, // Initialize the concrete CoffeeService
+		CoffeeStream: &CoffeeStreamImpl{DB: dbPool, Bus: bus},
+		Order:        orders,
+		Cart:         carts,
+		Promotion:    &PromotionServiceImpl{DB: dbPool, Carts: carts},
+		Webhook:      hooks,
+		Outbox:       NewOutboxService(dbPool, &WebhookSink{Webhooks: hooks}),
+		Tenant:       &TenantServiceImpl{DB: dbPool},
+		Translation:  &TranslationServiceImpl{DB: dbPool},
+		Events:       bus,
+		Feed:         NewOutboxFeed(dbPool, bus),
+		Tx: NewTxManager(dbPool, func(tx db.Querier) Models {
+			return txModels(tx, bus)
+		}),
 		JsonResponse: JsonResponse{},
 	}
 }
+
+// NewMemory returns the models of a catalog kept in memory, to run the server without Postgres.
+// Only the catalog and its stream are served, the other services need the database.
+func NewMemory() Models {
+	bus := events.NewMemoryBus(64)
+	coffees := NewMemoryCoffeeService(bus)
+
+	return Models{
+		Coffee:       coffees,
+		CoffeeStream: coffees,
+		Events:       bus,
+		JsonResponse: JsonResponse{},
+	}
+}
+
+// WithPaymentProvider returns the models taking payments through the given provider
+func (m Models) WithPaymentProvider(dbPool db.Querier, provider payments.Provider) Models {
+	m.Payment = NewPaymentService(dbPool, provider, m.Order)
+	return m
+}
+
+// WithOrdersInTx returns the models placing the orders in units of work of Tx
+func (m Models) WithOrdersInTx() Models {
+	if m.Tx != nil {
+		m.Order = NewTxOrderService(m.Order, m.Tx)
+	}
+	return m
+}
+
+// WithCatalogReads returns the models serving the catalog reads through reads, e.g. the replicas
+func (m Models) WithCatalogReads(dbPool db.Querier, reads db.Executor) Models {
+	m.Coffee = &CoffeeServiceImpl{DB: dbPool, Reads: reads}
+	return m
+}
+
+// WithLanguages returns the models serving the catalog in the given languages besides the source language
+func (m Models) WithLanguages(dbPool db.Querier, languages ...string) Models {
+	m.Translation = &TranslationServiceImpl{DB: dbPool, Languages: languages}
+	return m
+}
+
+// WithEventSinks returns the models relaying the outbox to the given sinks as well, e.g. a NATS connection
+func (m Models) WithEventSinks(dbPool db.Querier, sinks ...events.Sink) Models {
+	all := []events.Sink{&WebhookSink{Webhooks: m.Webhook}}
+	m.Outbox = NewOutboxService(dbPool, append(all, sinks...)...)
+	return m
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderEmpty         = errors.New("order must contain at least one item")
+	ErrInvalidQuantity    = errors.New("item quantity must be greater than zero")
+	ErrCoffeeNotFound     = errors.New("coffee not found")
+	ErrInvalidOrderStatus = errors.New("unknown order status")
+)
+
+type OrderItem struct {
+	ID        string    `json:"id,omitempty"`
+	OrderID   string    `json:"order_id,omitempty"`
+	CoffeeID  string    `json:"coffee_id"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float32   `json:"unit_price"`
+	LineTotal float32   `json:"line_total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Order struct {
+	ID            string      `json:"id,omitempty"`
+	CustomerName  string      `json:"customer_name"`
+	CustomerEmail string      `json:"customer_email"`
+	CartID        string      `json:"cart_id,omitempty"` // the cart the order was placed from, if any
+	Status        string      `json:"status"`
+	Total         float32     `json:"total"`
+	Items         []OrderItem `json:"items"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	StatusTimestamps
+}
+
+type OrderService interface {
+	GetAllOrders() ([]*Order, error)
+	CreateOrder(order Order) (*Order, error)
+	GetOrderById(id string) (*Order, error)
+	CancelOrder(id string) (*Order, error)
+	TransitionOrder(id string, status string) (*Order, error)
+	GetOrderHistory(id string) ([]*OrderTransition, error)
+}
+
+// TenantOrders is implemented by the orders taken per tenant
+type TenantOrders interface {
+	ForTenant(tenantID string) OrderService
+}
+
+// OrdersFor returns the orders of the tenant, taking its coffees
+func OrdersFor(orders OrderService, tenantID string) OrderService {
+	if scoped, ok := orders.(TenantOrders); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return orders
+}
+
+// OrdersFromContext returns the orders of the tenant of the request, the default one when none was resolved
+func OrdersFromContext(ctx context.Context, orders OrderService) OrderService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return OrdersFor(orders, tenant.ID)
+	}
+	return orders
+}
+
+// Concrete implementation of OrderService
+type OrderServiceImpl struct {
+	DB db.Querier
+	// Tenant is the shop the orders are placed with, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantOrders = (*OrderServiceImpl)(nil)
+
+// ForTenant returns the orders of the tenant on the same database
+func (o *OrderServiceImpl) ForTenant(tenantID string) OrderService {
+	return &OrderServiceImpl{DB: o.DB, Tenant: tenantID}
+}
+
+func (o *OrderServiceImpl) tenant() string {
+	if o.Tenant != "" {
+		return o.Tenant
+	}
+	return DefaultTenant
+}
+
+// CalculateTotals fills in the line total of every item and returns the order total.
+func CalculateTotals(items []OrderItem) float32 {
+	var total float32
+	for i := range items {
+		items[i].LineTotal = items[i].UnitPrice * float32(items[i].Quantity)
+		total += items[i].LineTotal
+	}
+	return total
+}
+
+func (o *OrderServiceImpl) GetAllOrders() ([]*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT o.id, o.customer_name, o.customer_email, COALESCE(o.cart_id::text, ''), o.status, o.total, o.created_at, o.updated_at,
+		o.paid_at, o.roasting_at, o.packed_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.refunded_at,
+		i.id, i.coffee_id, i.name, i.quantity, i.unit_price, i.line_total, i.created_at
+		FROM orders o LEFT JOIN order_items i ON i.order_id = o.id
+		WHERE o.tenant_id = $1
+		ORDER BY o.created_at, i.created_at`
+
+	rows, err := o.DB.QueryContext(ctx, query, o.tenant())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+func (o *OrderServiceImpl) GetOrderById(id string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return getOrderById(ctx, o.DB, id, o.tenant())
+}
+
+func (o *OrderServiceImpl) CreateOrder(order Order) (*Order, error) {
+	if len(order.Items) == 0 {
+		return nil, ErrOrderEmpty
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Snapshot the name and price of every coffee so later catalog changes don't alter the order
+	items := make([]OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		if item.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		row := tx.QueryRowContext(ctx, `SELECT name, price FROM coffees WHERE id = $1 AND tenant_id = $2`, item.CoffeeID, o.tenant())
+		err := row.Scan(&items[i].Name, &items[i].UnitPrice)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCoffeeNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		items[i].CoffeeID = item.CoffeeID
+		items[i].Quantity = item.Quantity
+	}
+
+	if order.CartID != "" {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM carts WHERE id::text = $1 AND tenant_id = $2)`, order.CartID, o.tenant()).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrCartNotFound
+		}
+	}
+
+	now := time.Now()
+	created := Order{
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+		CartID:        order.CartID,
+		Status:        OrderStatusPending,
+		Total:         CalculateTotals(items),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	query := `INSERT INTO orders(customer_name, customer_email, status, total, created_at, updated_at, tenant_id, cart_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) returning id`
+
+	err = tx.QueryRowContext(ctx, query, created.CustomerName, created.CustomerEmail, created.Status, created.Total, now, now, o.tenant(), nullString(created.CartID)).Scan(&created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTransition(ctx, tx, created.ID, "", OrderStatusPending, now)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO order_items(order_id, coffee_id, name, quantity, unit_price, line_total, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) returning id`
+
+	for i := range items {
+		items[i].OrderID = created.ID
+		items[i].CreatedAt = now
+		err := tx.QueryRowContext(ctx, query, created.ID, items[i].CoffeeID, items[i].Name, items[i].Quantity, items[i].UnitPrice, items[i].LineTotal, now).Scan(&items[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	created.Items = items
+	return &created, nil
+}
+
+func (o *OrderServiceImpl) CancelOrder(id string) (*Order, error) {
+	return o.TransitionOrder(id, OrderStatusCancelled)
+}
+
+func (o *OrderServiceImpl) TransitionOrder(id string, status string) (*Order, error) {
+	if !IsValidOrderStatus(status) {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the order so concurrent transitions are applied one after the other
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 AND tenant_id = $2 FOR UPDATE`, id, o.tenant()).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckTransition(current, status); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query := fmt.Sprintf(`UPDATE orders SET status = $1, %s = $2, updated_at = $2 WHERE id = $3`, orderStatusColumns[status])
+
+	_, err = tx.ExecContext(ctx, query, status, now, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTransition(ctx, tx, id, current, status, now)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := getOrderById(ctx, tx, id, o.tenant())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (o *OrderServiceImpl) GetOrderHistory(id string) ([]*OrderTransition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var exists bool
+	err := o.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM orders WHERE id = $1 AND tenant_id = $2)`, id, o.tenant()).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+
+	query := `SELECT id, order_id, from_status, to_status, created_at FROM order_transitions WHERE order_id = $1 ORDER BY created_at`
+
+	rows, err := o.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []*OrderTransition{}
+	for rows.Next() {
+		var transition OrderTransition
+		err := rows.Scan(
+			&transition.ID,
+			&transition.OrderID,
+			&transition.FromStatus,
+			&transition.ToStatus,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, &transition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func insertTransition(ctx context.Context, tx db.Executor, orderId, from, to string, at time.Time) error {
+	query := `INSERT INTO order_transitions(order_id, from_status, to_status, created_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := tx.ExecContext(ctx, query, orderId, from, to, at)
+	return err
+}
+
+// getOrderById loads the order of the tenant with its items
+func getOrderById(ctx context.Context, db db.Executor, id string, tenantID string) (*Order, error) {
+	query := `SELECT o.id, o.customer_name, o.customer_email, COALESCE(o.cart_id::text, ''), o.status, o.total, o.created_at, o.updated_at,
+		o.paid_at, o.roasting_at, o.packed_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.refunded_at,
+		i.id, i.coffee_id, i.name, i.quantity, i.unit_price, i.line_total, i.created_at
+		FROM orders o LEFT JOIN order_items i ON i.order_id = o.id
+		WHERE o.id = $1 AND o.tenant_id = $2
+		ORDER BY i.created_at`
+
+	rows, err := db.QueryContext(ctx, query, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+// scanOrders folds the joined order/order_items rows back into orders with their items.
+func scanOrders(rows db.Rows) ([]*Order, error) {
+	var orders []*Order
+	byId := map[string]*Order{}
+
+	for rows.Next() {
+		var order Order
+		var itemId, coffeeId, name sql.NullString
+		var quantity sql.NullInt64
+		var unitPrice, lineTotal sql.NullFloat64
+		var itemCreatedAt sql.NullTime
+
+		err := rows.Scan(
+			&order.ID,
+			&order.CustomerName,
+			&order.CustomerEmail,
+			&order.CartID,
+			&order.Status,
+			&order.Total,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+			&order.PaidAt,
+			&order.RoastingAt,
+			&order.PackedAt,
+			&order.ShippedAt,
+			&order.DeliveredAt,
+			&order.CancelledAt,
+			&order.RefundedAt,
+			&itemId,
+			&coffeeId,
+			&name,
+			&quantity,
+			&unitPrice,
+			&lineTotal,
+			&itemCreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		current, ok := byId[order.ID]
+		if !ok {
+			order.Items = []OrderItem{}
+			current = &order
+			byId[order.ID] = current
+			orders = append(orders, current)
+		}
+
+		if itemId.Valid {
+			current.Items = append(current.Items, OrderItem{
+				ID:        itemId.String,
+				OrderID:   current.ID,
+				CoffeeID:  coffeeId.String,
+				Name:      name.String,
+				Quantity:  int(quantity.Int64),
+				UnitPrice: float32(unitPrice.Float64),
+				LineTotal: float32(lineTotal.Float64),
+				CreatedAt: itemCreatedAt.Time,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
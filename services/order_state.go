@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusRoasting  = "roasting"
+	OrderStatusPacked    = "packed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// orderTransitions lists for every status the statuses an order may move to next
+var orderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusRoasting, OrderStatusRefunded},
+	OrderStatusRoasting:  {OrderStatusPacked, OrderStatusRefunded},
+	OrderStatusPacked:    {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// orderStatusColumns maps a status to the column holding the moment the order entered it
+var orderStatusColumns = map[string]string{
+	OrderStatusPaid:      "paid_at",
+	OrderStatusRoasting:  "roasting_at",
+	OrderStatusPacked:    "packed_at",
+	OrderStatusShipped:   "shipped_at",
+	OrderStatusDelivered: "delivered_at",
+	OrderStatusCancelled: "cancelled_at",
+	OrderStatusRefunded:  "refunded_at",
+}
+
+type OrderTransition struct {
+	ID         string    `json:"id,omitempty"`
+	OrderID    string    `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StatusTimestamps holds the moment an order entered each state, nil while it has not
+type StatusTimestamps struct {
+	PaidAt      *time.Time `json:"paid_at"`
+	RoastingAt  *time.Time `json:"roasting_at"`
+	PackedAt    *time.Time `json:"packed_at"`
+	ShippedAt   *time.Time `json:"shipped_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	RefundedAt  *time.Time `json:"refunded_at"`
+}
+
+// TransitionError is returned when an order is asked to move to a status it can't reach from its current one
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order cannot transition from %s to %s", e.From, e.To)
+}
+
+// ErrorData exposes the rejected transition in the error response
+func (e *TransitionError) ErrorData() interface{} {
+	return map[string]interface{}{
+		"from":    e.From,
+		"to":      e.To,
+		"allowed": e.Allowed,
+	}
+}
+
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+func AllowedTransitions(from string) []string {
+	return orderTransitions[from]
+}
+
+// CheckTransition returns a *TransitionError when an order in status from may not move to status to
+func CheckTransition(from, to string) error {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	allowed := orderTransitions[from]
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return &TransitionError{From: from, To: to, Allowed: allowed}
+}
@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"coffee/coffee-server/services"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Order totals", Label("unit"), func() {
+	It("should compute line totals and the order total", func() {
+		items := []services.OrderItem{
+			{Quantity: 2, UnitPrice: 10.0},
+			{Quantity: 1, UnitPrice: 12.5},
+		}
+
+		total := services.CalculateTotals(items)
+
+		Expect(items[0].LineTotal).To(Equal(float32(20.0)))
+		Expect(items[1].LineTotal).To(Equal(float32(12.5)))
+		Expect(total).To(Equal(float32(32.5)))
+	})
+
+	It("should return zero for no items", func() {
+		Expect(services.CalculateTotals(nil)).To(Equal(float32(0)))
+	})
+})
+
+var _ = Describe("Order state machine", Label("unit"), func() {
+	It("should allow the happy path from pending to delivered", func() {
+		path := []string{
+			services.OrderStatusPending,
+			services.OrderStatusPaid,
+			services.OrderStatusRoasting,
+			services.OrderStatusPacked,
+			services.OrderStatusShipped,
+			services.OrderStatusDelivered,
+		}
+		for i := 1; i < len(path); i++ {
+			Expect(services.CheckTransition(path[i-1], path[i])).To(Succeed())
+		}
+	})
+
+	It("should allow cancelling only while pending", func() {
+		Expect(services.CheckTransition(services.OrderStatusPending, services.OrderStatusCancelled)).To(Succeed())
+		Expect(services.CheckTransition(services.OrderStatusPaid, services.OrderStatusCancelled)).NotTo(Succeed())
+	})
+
+	It("should reject skipping states with a TransitionError", func() {
+		err := services.CheckTransition(services.OrderStatusPaid, services.OrderStatusShipped)
+
+		var transitionErr *services.TransitionError
+		Expect(errors.As(err, &transitionErr)).To(BeTrue())
+		Expect(transitionErr.From).To(Equal(services.OrderStatusPaid))
+		Expect(transitionErr.To).To(Equal(services.OrderStatusShipped))
+		Expect(transitionErr.Allowed).To(ConsistOf(services.OrderStatusRoasting, services.OrderStatusRefunded))
+	})
+
+	It("should not allow leaving a final state", func() {
+		Expect(services.AllowedTransitions(services.OrderStatusCancelled)).To(BeEmpty())
+		Expect(services.CheckTransition(services.OrderStatusRefunded, services.OrderStatusPaid)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Order Service", Label("integration"), func() {
+	var orderService services.OrderService
+
+	BeforeEach(func() {
+		orderService = services.New(conn).Order
+
+		_, err := db.Exec("DELETE FROM orders")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000', 'Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		_, err := db.Exec("DELETE FROM orders")
+		Expect(err).To(BeNil())
+	})
+
+	Describe("CreateOrder", func() {
+		It("should not order a coffee of another shop", func() {
+			_, err := services.OrdersFor(orderService, "00000000-0000-0000-0000-000000000002").CreateOrder(services.Order{
+				Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 1}},
+			})
+			Expect(err).To(MatchError(services.ErrCoffeeNotFound))
+		})
+
+		It("should snapshot the coffee price and compute the total", func() {
+			order, err := orderService.CreateOrder(services.Order{
+				CustomerName:  "Jan",
+				CustomerEmail: "jan@example.com",
+				Items:         []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 3}},
+			})
+			Expect(err).To(BeNil())
+			Expect(order.ID).NotTo(BeEmpty())
+			Expect(order.Status).To(Equal(services.OrderStatusPending))
+			Expect(order.Total).To(Equal(float32(30.0)))
+			Expect(order.Items[0].Name).To(Equal("Espresso"))
+
+			// Changing the catalog price must not alter the existing order
+			_, err = db.Exec("UPDATE coffees SET price = 99 WHERE id = '550e8400-e29b-41d4-a716-446655440000'")
+			Expect(err).To(BeNil())
+
+			found, err := orderService.GetOrderById(order.ID)
+			Expect(err).To(BeNil())
+			Expect(found.Items).To(HaveLen(1))
+			Expect(found.Items[0].UnitPrice).To(Equal(float32(10.0)))
+			Expect(found.Total).To(Equal(float32(30.0)))
+		})
+
+		It("should reject an order for an unknown coffee", func() {
+			_, err := orderService.CreateOrder(services.Order{
+				Items: []services.OrderItem{{CoffeeID: "650e8400-e29b-41d4-a716-446655440000", Quantity: 1}},
+			})
+			Expect(err).To(MatchError(services.ErrCoffeeNotFound))
+
+			orders, err := orderService.GetAllOrders()
+			Expect(err).To(BeNil())
+			Expect(orders).To(BeEmpty())
+		})
+	})
+
+	Describe("TransitionOrder", func() {
+		It("should record a timestamp and history entry for every transition", func() {
+			order, err := orderService.CreateOrder(services.Order{
+				Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 1}},
+			})
+			Expect(err).To(BeNil())
+
+			paid, err := orderService.TransitionOrder(order.ID, services.OrderStatusPaid)
+			Expect(err).To(BeNil())
+			Expect(paid.Status).To(Equal(services.OrderStatusPaid))
+			Expect(paid.PaidAt).NotTo(BeNil())
+			Expect(paid.ShippedAt).To(BeNil())
+
+			_, err = orderService.TransitionOrder(order.ID, services.OrderStatusShipped)
+			Expect(err).To(HaveOccurred())
+
+			history, err := orderService.GetOrderHistory(order.ID)
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(2))
+			Expect(history[0].ToStatus).To(Equal(services.OrderStatusPending))
+			Expect(history[1].FromStatus).To(Equal(services.OrderStatusPending))
+			Expect(history[1].ToStatus).To(Equal(services.OrderStatusPaid))
+		})
+	})
+
+	Describe("CancelOrder", func() {
+		It("should cancel a pending order only once", func() {
+			order, err := orderService.CreateOrder(services.Order{
+				Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 1}},
+			})
+			Expect(err).To(BeNil())
+
+			cancelled, err := orderService.CancelOrder(order.ID)
+			Expect(err).To(BeNil())
+			Expect(cancelled.Status).To(Equal(services.OrderStatusCancelled))
+
+			_, err = orderService.CancelOrder(order.ID)
+			var transitionErr *services.TransitionError
+			Expect(errors.As(err, &transitionErr)).To(BeTrue())
+		})
+
+		It("should return not found for an unknown order", func() {
+			_, err := orderService.CancelOrder("650e8400-e29b-41d4-a716-446655440000")
+			Expect(err).To(MatchError(services.ErrOrderNotFound))
+		})
+	})
+})
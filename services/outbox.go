@@ -0,0 +1,162 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/events"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AggregateCoffee = "coffee"
+
+	// outboxLockKey is the advisory lock held while relaying, so a single relay publishes at a time
+	// and events of the same aggregate go out in the order they were written
+	outboxLockKey = 7311
+)
+
+type OutboxService interface {
+	PublishPending(limit int) (int, error)
+}
+
+// Concrete implementation of OutboxService relaying the outbox to the sinks
+type OutboxServiceImpl struct {
+	DB    db.Querier
+	Sinks []events.Sink
+}
+
+func NewOutboxService(dbPool db.Querier, sinks ...events.Sink) *OutboxServiceImpl {
+	return &OutboxServiceImpl{DB: dbPool, Sinks: sinks}
+}
+
+// insertOutboxEvent records an event in the same transaction as the change it describes
+func insertOutboxEvent(ctx context.Context, tx db.Executor, aggregateType string, aggregateId string, eventType string, data interface{}) error {
+	query, err := outboxEventQuery(aggregateType, aggregateId, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query.SQL, query.Args...)
+	return err
+}
+
+// outboxEventQuery returns the insert of an event, to record many events in a batch
+func outboxEventQuery(aggregateType string, aggregateId string, eventType string, data interface{}) (db.Query, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return db.Query{}, err
+	}
+
+	return db.Query{
+		SQL:  `INSERT INTO outbox_events(aggregate_type, aggregate_id, event_type, payload, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		Args: []interface{}{aggregateType, aggregateId, eventType, string(payload), time.Now()},
+	}, nil
+}
+
+// PublishPending publishes up to limit unpublished events, oldest first, to every sink and returns how many went out.
+// An event is only marked as published once all sinks accepted it, so a failure means it is sent again later.
+// When an event fails, the later events of the same aggregate wait for it.
+func (o *OutboxServiceImpl) PublishPending(limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		// Another relay is running
+		return 0, nil
+	}
+
+	query := `SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at FROM outbox_events
+		WHERE published_at IS NULL ORDER BY id LIMIT $1`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []events.Event
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.Type, &payload, &event.OccurredAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		event.Payload = json.RawMessage(payload)
+		pending = append(pending, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := map[string]bool{}
+	for _, event := range pending {
+		aggregate := event.AggregateType + ":" + event.AggregateID
+		if blocked[aggregate] {
+			continue
+		}
+
+		if err := o.publish(ctx, event); err != nil {
+			blocked[aggregate] = true
+			_, err := tx.ExecContext(ctx, `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = $2`, err.Error(), event.ID)
+			if err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		// Stamped by the database after the lock, so OutboxFeed follows the batches in the order they were committed
+		_, err := tx.ExecContext(ctx, `UPDATE outbox_events SET attempts = attempts + 1, last_error = '', published_at = statement_timestamp() WHERE id = $1`, event.ID)
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+func (o *OutboxServiceImpl) publish(ctx context.Context, event events.Event) error {
+	for _, sink := range o.Sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s sink: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// WebhookSink queues a webhook delivery for every catalog event, for the subscriptions of the tenant
+// named by the event. An event naming no tenant goes to the default one.
+type WebhookSink struct {
+	Webhooks WebhookService
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event events.Event) error {
+	var scope struct {
+		TenantID string `json:"tenant_id"`
+	}
+	// A payload that isn't an object names no tenant
+	_ = json.Unmarshal(event.Payload, &scope)
+
+	return WebhooksFor(s.Webhooks, scope.TenantID).Enqueue(event.Type, event.Payload)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/events"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// OutboxFeed publishes the events relayed from the outbox to the bus of this instance, whichever instance
+// relayed them: the relay runs on one instance at a time, every instance follows what it published. The
+// events are followed in the order they were published, the relay stamps them with the time of the
+// statement after taking its lock, so a batch is always stamped after the ones committed before it.
+type OutboxFeed struct {
+	DB  db.Querier
+	Bus *events.MemoryBus
+
+	mu      sync.Mutex
+	started bool
+	after   time.Time
+	afterId int64
+}
+
+func NewOutboxFeed(dbPool db.Querier, bus *events.MemoryBus) *OutboxFeed {
+	return &OutboxFeed{DB: dbPool, Bus: bus}
+}
+
+// Poll publishes up to limit events relayed since the last poll to the bus, oldest first, and returns
+// how many went out. The first poll starts after the events relayed so far, the clients of the stream
+// catch up on those with Replay.
+func (f *OutboxFeed) Poll(limit int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if !f.started {
+		query := `SELECT published_at, id FROM outbox_events WHERE published_at IS NOT NULL ORDER BY published_at DESC, id DESC LIMIT 1`
+		err := f.DB.QueryRowContext(ctx, query).Scan(&f.after, &f.afterId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+		f.started = true
+		return 0, nil
+	}
+
+	query := `SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at FROM outbox_events
+		WHERE published_at IS NOT NULL AND (published_at, id) > ($1, $2) ORDER BY published_at, id LIMIT $3`
+
+	rows, err := f.DB.QueryContext(ctx, query, f.after, f.afterId, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	published := 0
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		var publishedAt time.Time
+		if err := rows.Scan(&event.ID, &event.AggregateType, &event.AggregateID, &event.Type, &payload, &event.OccurredAt, &publishedAt); err != nil {
+			return published, err
+		}
+		event.Payload = json.RawMessage(payload)
+		if err := f.Bus.Publish(ctx, event); err != nil {
+			return published, err
+		}
+		f.after, f.afterId = publishedAt, event.ID
+		published++
+	}
+	return published, rows.Err()
+}
@@ -0,0 +1,181 @@
+package services_test
+
+import (
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"context"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingSink struct {
+	published []events.Event
+	fail      map[string]bool
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event events.Event) error {
+	if s.fail[event.AggregateID] {
+		return errors.New("unavailable")
+	}
+	s.published = append(s.published, event)
+	return nil
+}
+
+var _ = Describe("Webhook sink", Label("unit"), func() {
+	It("should queue a webhook delivery for the event", func() {
+		hooks := new(mocks.WebhookService)
+		payload := json.RawMessage(`{"id":"c1"}`)
+		hooks.On("Enqueue", services.EventCoffeeDeleted, payload).Return(nil)
+
+		sink := &services.WebhookSink{Webhooks: hooks}
+		err := sink.Publish(context.Background(), events.Event{Type: services.EventCoffeeDeleted, Payload: payload})
+		Expect(err).To(BeNil())
+		hooks.AssertExpectations(GinkgoT())
+	})
+})
+
+var _ = Describe("Outbox Service", Label("integration"), func() {
+	var (
+		sink   *recordingSink
+		outbox services.OutboxService
+	)
+
+	BeforeEach(func() {
+		sink = &recordingSink{fail: map[string]bool{}}
+		outbox = services.NewOutboxService(conn, sink)
+
+		_, err := db.Exec("DELETE FROM outbox_events")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+	})
+
+	It("should publish the coffee changes in order, once", func() {
+		created, err := coffeeService.CreateCoffee(services.Coffee{Name: "Mocha", Roast: "Medium", Region: "Ethiopia", Price: 15})
+		Expect(err).To(BeNil())
+		_, err = coffeeService.UpdateCoffee(created.ID, services.Coffee{Name: "Mocha", Roast: "Dark", Region: "Ethiopia", Price: 16})
+		Expect(err).To(BeNil())
+		Expect(coffeeService.DeleteCoffee(created.ID)).To(Succeed())
+
+		published, err := outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(3))
+		Expect(sink.published).To(HaveLen(3))
+		Expect(sink.published[0].Type).To(Equal(services.EventCoffeeCreated))
+		Expect(sink.published[1].Type).To(Equal(services.EventCoffeeUpdated))
+		Expect(sink.published[2].Type).To(Equal(services.EventCoffeeDeleted))
+		Expect(sink.published[0].AggregateID).To(Equal(created.ID))
+
+		published, err = outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(0))
+	})
+
+	It("should not write an event when nothing changed", func() {
+		Expect(coffeeService.DeleteCoffee("550e8400-e29b-41d4-a716-446655440000")).To(Succeed())
+
+		published, err := outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(0))
+	})
+
+	It("should hold back the later events of an aggregate until the failed one goes out", func() {
+		first, err := coffeeService.CreateCoffee(services.Coffee{Name: "Mocha", Roast: "Medium", Region: "Ethiopia", Price: 15})
+		Expect(err).To(BeNil())
+		second, err := coffeeService.CreateCoffee(services.Coffee{Name: "Latte", Roast: "Light", Region: "Colombia", Price: 12})
+		Expect(err).To(BeNil())
+		_, err = coffeeService.UpdateCoffee(first.ID, services.Coffee{Name: "Mocha", Roast: "Dark", Region: "Ethiopia", Price: 16})
+		Expect(err).To(BeNil())
+
+		sink.fail[first.ID] = true
+		published, err := outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(1))
+		Expect(sink.published[0].AggregateID).To(Equal(second.ID))
+
+		sink.fail[first.ID] = false
+		published, err = outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(2))
+		Expect(sink.published[1].Type).To(Equal(services.EventCoffeeCreated))
+		Expect(sink.published[2].Type).To(Equal(services.EventCoffeeUpdated))
+	})
+})
+
+var _ = Describe("Coffee Stream", Label("integration"), func() {
+	BeforeEach(func() {
+		_, err := db.Exec("DELETE FROM outbox_events")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+	})
+
+	It("should replay the published events after the given id", func() {
+		models := services.New(conn)
+		stream := models.CoffeeStream
+
+		created, err := coffeeService.CreateCoffee(services.Coffee{Name: "Mocha", Roast: "Medium", Region: "Ethiopia", Price: 15})
+		Expect(err).To(BeNil())
+
+		// Not published yet, so not replayed either
+		missed, err := stream.Replay(0)
+		Expect(err).To(BeNil())
+		Expect(missed).To(BeEmpty())
+
+		_, err = models.Outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+		_, err = coffeeService.UpdateCoffee(created.ID, services.Coffee{Name: "Mocha", Roast: "Dark", Region: "Ethiopia", Price: 16})
+		Expect(err).To(BeNil())
+		_, err = models.Outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+
+		missed, err = stream.Replay(0)
+		Expect(err).To(BeNil())
+		Expect(missed).To(HaveLen(2))
+
+		missed, err = stream.Replay(missed[0].ID)
+		Expect(err).To(BeNil())
+		Expect(missed).To(HaveLen(1))
+		Expect(missed[0].Type).To(Equal(services.EventCoffeeUpdated))
+	})
+
+	It("should hand the relayed events to the bus of every instance", func() {
+		relay := services.New(conn)
+		buses := []*events.MemoryBus{events.NewMemoryBus(8), events.NewMemoryBus(8)}
+		var received []<-chan events.Event
+		var feeds []*services.OutboxFeed
+		for _, bus := range buses {
+			ch, unsubscribe := bus.Subscribe()
+			DeferCleanup(unsubscribe)
+			received = append(received, ch)
+			feed := services.NewOutboxFeed(conn, bus)
+			_, err := feed.Poll(10)
+			Expect(err).To(BeNil())
+			feeds = append(feeds, feed)
+		}
+
+		created, err := coffeeService.CreateCoffee(services.Coffee{Name: "Mocha", Roast: "Medium", Region: "Ethiopia", Price: 15})
+		Expect(err).To(BeNil())
+		_, err = relay.Outbox.PublishPending(10)
+		Expect(err).To(BeNil())
+
+		for i, feed := range feeds {
+			followed, err := feed.Poll(10)
+			Expect(err).To(BeNil())
+			Expect(followed).To(Equal(1))
+			Expect(<-received[i]).To(HaveField("AggregateID", created.ID))
+
+			followed, err = feed.Poll(10)
+			Expect(err).To(BeNil())
+			Expect(followed).To(BeZero())
+		}
+	})
+})
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/payments"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// providerTimeout bounds every call to the provider, each one gets its own
+	providerTimeout = 10 * time.Second
+	// StaleClaimAge is how long a checkout may leave its payment pending or authorized. It is well
+	// beyond the provider calls of a checkout, a claim this old was left by a checkout that stopped.
+	StaleClaimAge = 15 * time.Minute
+	// staleClaimReason is the failure reason of the expired claims
+	staleClaimReason = "checkout abandoned"
+)
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentDeclined      = errors.New("payment was declined")
+	ErrPaymentFailed        = errors.New("payment provider failed")
+	ErrPaymentNotRefundable = errors.New("only captured payments can be refunded")
+	ErrPaymentInProgress    = errors.New("the order already has a payment in progress or captured")
+	ErrPaymentTokenRequired = errors.New("payment_token is required")
+	ErrInvalidWebhookEvent  = errors.New("webhook event has an unknown payment status")
+)
+
+type Payment struct {
+	ID                string    `json:"id,omitempty"`
+	OrderID           string    `json:"order_id"`
+	Provider          string    `json:"provider"`
+	ProviderReference string    `json:"provider_reference"`
+	Amount            float32   `json:"amount"`
+	Status            string    `json:"status"`
+	FailureReason     string    `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// tenantID is the shop the payment was taken for, the webhooks of the provider don't name it
+	tenantID string
+}
+
+const paymentColumns = `id, order_id, provider, COALESCE(provider_reference, ''), amount, status, failure_reason, created_at, updated_at, tenant_id`
+
+// paymentTransitions lists for every status the statuses a payment may move to next. A payment never
+// moves back, and a failed or refunded one is done.
+var paymentTransitions = map[string][]string{
+	payments.StatusPending:    {payments.StatusAuthorized, payments.StatusCaptured, payments.StatusFailed},
+	payments.StatusAuthorized: {payments.StatusCaptured, payments.StatusFailed},
+	payments.StatusCaptured:   {payments.StatusRefunded},
+	payments.StatusFailed:     {},
+	payments.StatusRefunded:   {},
+}
+
+// paymentMayMove tells whether a payment in status from may move to status to
+func paymentMayMove(from, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type PaymentService interface {
+	Checkout(orderId string, token string) (*Payment, error)
+	GetPaymentsByOrder(orderId string) ([]*Payment, error)
+	RefundPayment(id string) (*Payment, error)
+	HandleWebhook(payload []byte, signature string) (*Payment, error)
+	ExpireStaleClaims(olderThan time.Duration) (int64, error)
+}
+
+// TenantPayments is implemented by the payments taken per tenant
+type TenantPayments interface {
+	ForTenant(tenantID string) PaymentService
+}
+
+// PaymentsFor returns the payments of the tenant
+func PaymentsFor(payments PaymentService, tenantID string) PaymentService {
+	if scoped, ok := payments.(TenantPayments); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return payments
+}
+
+// PaymentsFromContext returns the payments of the tenant of the request, the default one when none was resolved
+func PaymentsFromContext(ctx context.Context, payments PaymentService) PaymentService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return PaymentsFor(payments, tenant.ID)
+	}
+	return payments
+}
+
+// Concrete implementation of PaymentService
+type PaymentServiceImpl struct {
+	DB       db.Querier
+	Provider payments.Provider
+	Orders   OrderService
+	// Tenant is the shop the payments are taken for, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantPayments = (*PaymentServiceImpl)(nil)
+
+func NewPaymentService(dbPool db.Querier, provider payments.Provider, orders OrderService) *PaymentServiceImpl {
+	return &PaymentServiceImpl{DB: dbPool, Provider: provider, Orders: orders}
+}
+
+// ForTenant returns the payments of the tenant, paying its orders
+func (p *PaymentServiceImpl) ForTenant(tenantID string) PaymentService {
+	return &PaymentServiceImpl{DB: p.DB, Provider: p.Provider, Orders: OrdersFor(p.Orders, tenantID), Tenant: tenantID}
+}
+
+func (p *PaymentServiceImpl) tenant() string {
+	if p.Tenant != "" {
+		return p.Tenant
+	}
+	return DefaultTenant
+}
+
+// Checkout authorizes and captures the order total and marks the order as paid.
+// Every attempt is recorded, including the declined ones. The attempt is recorded as pending before the
+// provider is called, and only one payment of an order can be pending, authorized or captured at once,
+// so two checkouts of the order can't both capture. The capture is refunded when the order can't be
+// marked as paid anymore, e.g. it was cancelled meanwhile.
+//
+// Every provider call has its own timeout and every outcome is written with a fresh context, so a slow
+// provider doesn't leave a captured payment pending. A checkout that stops anyway leaves a claim that
+// ExpireStaleClaims fails after StaleClaimAge.
+func (p *PaymentServiceImpl) Checkout(orderId string, token string) (*Payment, error) {
+	if token == "" {
+		return nil, ErrPaymentTokenRequired
+	}
+
+	order, err := p.Orders.GetOrderById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckTransition(order.Status, OrderStatusPaid); err != nil {
+		return nil, err
+	}
+
+	payment := &Payment{
+		OrderID:  order.ID,
+		Provider: p.Provider.Name(),
+		Amount:   order.Total,
+		Status:   payments.StatusPending,
+	}
+	err = p.insertPayment(payment)
+	if db.SQLState(err) == db.UniqueViolation {
+		return nil, ErrPaymentInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	authCtx, cancelAuth := context.WithTimeout(context.Background(), providerTimeout)
+	result, authErr := p.Provider.Authorize(authCtx, payments.AuthorizeRequest{
+		OrderID: order.ID,
+		Amount:  order.Total,
+		Token:   token,
+	})
+	cancelAuth()
+	if authErr != nil {
+		payment.Status = payments.StatusFailed
+		payment.FailureReason = authErr.Error()
+		if result != nil {
+			payment.ProviderReference = result.Reference
+			payment.FailureReason = result.FailureReason
+		}
+		if err := p.updatePayment(payment); err != nil {
+			return nil, err
+		}
+		if errors.Is(authErr, payments.ErrDeclined) {
+			return payment, fmt.Errorf("%w: %s", ErrPaymentDeclined, payment.FailureReason)
+		}
+		return payment, fmt.Errorf("%w: %s", ErrPaymentFailed, authErr)
+	}
+
+	payment.ProviderReference = result.Reference
+	payment.Status = result.Status
+	if err := p.updatePayment(payment); err != nil {
+		return nil, err
+	}
+
+	captureCtx, cancelCapture := context.WithTimeout(context.Background(), providerTimeout)
+	result, err = p.Provider.Capture(captureCtx, payment.ProviderReference, payment.Amount)
+	cancelCapture()
+	if err != nil {
+		payment.FailureReason = err.Error()
+		payment.Status = payments.StatusFailed
+		if err := p.updatePayment(payment); err != nil {
+			return nil, err
+		}
+		return payment, fmt.Errorf("%w: %s", ErrPaymentFailed, err)
+	}
+
+	payment.Status = result.Status
+	if err := p.updatePayment(payment); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.Orders.TransitionOrder(order.ID, OrderStatusPaid); err != nil {
+		return payment, p.refundCapture(payment, err)
+	}
+	return payment, nil
+}
+
+// refundCapture gives back the payment captured for an order that couldn't be marked as paid, it
+// returns the error of the transition unless the refund failed too
+func (p *PaymentServiceImpl) refundCapture(payment *Payment, transitionErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	result, err := p.Provider.Refund(ctx, payment.ProviderReference, payment.Amount)
+	if err != nil {
+		return fmt.Errorf("%w: refunding the capture of an order that can't be paid (%s): %s", ErrPaymentFailed, transitionErr, err)
+	}
+	payment.Status = result.Status
+	payment.FailureReason = transitionErr.Error()
+	if err := p.updatePayment(payment); err != nil {
+		return err
+	}
+	return transitionErr
+}
+
+func (p *PaymentServiceImpl) GetPaymentsByOrder(orderId string) ([]*Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE order_id = $1 AND tenant_id = $2 ORDER BY created_at`
+
+	rows, err := p.DB.QueryContext(ctx, query, orderId, p.tenant())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := []*Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// RefundPayment refunds a captured payment in full and marks its order as refunded. The payment is
+// locked until the refund is recorded, so two refunds of a payment can't both reach the provider.
+func (p *PaymentServiceImpl) RefundPayment(id string) (*Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*dbTimeout+providerTimeout)
+	defer cancel()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	payment, err := lockPayment(ctx, tx, `id = $1 AND tenant_id = $2`, id, p.tenant())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != payments.StatusCaptured {
+		return nil, ErrPaymentNotRefundable
+	}
+
+	order, err := p.Orders.GetOrderById(payment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckTransition(order.Status, OrderStatusRefunded); err != nil {
+		return nil, err
+	}
+
+	refundCtx, cancelRefund := context.WithTimeout(context.Background(), providerTimeout)
+	result, err := p.Provider.Refund(refundCtx, payment.ProviderReference, payment.Amount)
+	cancelRefund()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, err)
+	}
+
+	payment.Status = result.Status
+	if err := writePayment(ctx, tx, payment); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.Orders.TransitionOrder(order.ID, OrderStatusRefunded); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// HandleWebhook applies a status update pushed by the provider to the matching payment. A payment only
+// moves forward: an event arriving late, e.g. authorized after the capture, is acknowledged and ignored,
+// it would otherwise undo the capture and free the order for another one. The payment is matched by its
+// provider reference whatever the tenant, the provider doesn't name the shop.
+func (p *PaymentServiceImpl) HandleWebhook(payload []byte, signature string) (*Payment, error) {
+	event, err := p.Provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event.Status {
+	case payments.StatusAuthorized, payments.StatusCaptured, payments.StatusFailed, payments.StatusRefunded:
+	default:
+		return nil, ErrInvalidWebhookEvent
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	payment, err := lockPayment(ctx, tx, `provider = $1 AND provider_reference = $2`, p.Provider.Name(), event.Reference)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !paymentMayMove(payment.Status, event.Status) {
+		return payment, nil
+	}
+
+	payment.Status = event.Status
+	if err := writePayment(ctx, tx, payment); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// A refund issued from the provider dashboard also refunds the order
+	if event.Status == payments.StatusRefunded {
+		orders := OrdersFor(p.Orders, payment.tenantID)
+		order, err := orders.GetOrderById(payment.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if CheckTransition(order.Status, OrderStatusRefunded) == nil {
+			if _, err := orders.TransitionOrder(order.ID, OrderStatusRefunded); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return payment, nil
+}
+
+// ExpireStaleClaims fails the payments left pending or authorized for longer than olderThan, so their
+// orders can be checked out again, and returns how many it failed
+func (p *PaymentServiceImpl) ExpireStaleClaims(olderThan time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `UPDATE payments SET status = $1, failure_reason = $2, updated_at = NOW()
+		WHERE status IN ($3, $4) AND updated_at < $5`
+
+	res, err := p.DB.ExecContext(ctx, query, payments.StatusFailed, staleClaimReason, payments.StatusPending,
+		payments.StatusAuthorized, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *PaymentServiceImpl) insertPayment(payment *Payment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	now := time.Now()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	payment.tenantID = p.tenant()
+
+	query := `INSERT INTO payments(order_id, provider, provider_reference, amount, status, failure_reason, created_at, updated_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id`
+
+	return p.DB.QueryRowContext(ctx, query, payment.OrderID, payment.Provider, nullString(payment.ProviderReference), payment.Amount,
+		payment.Status, payment.FailureReason, now, now, payment.tenantID).Scan(&payment.ID)
+}
+
+// updatePayment writes the payment with a context of its own, the outcome of a provider call is written
+// however long the call took
+func (p *PaymentServiceImpl) updatePayment(payment *Payment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return writePayment(ctx, p.DB, payment)
+}
+
+func writePayment(ctx context.Context, q db.Executor, payment *Payment) error {
+	payment.UpdatedAt = time.Now()
+
+	query := `UPDATE payments SET provider_reference = $1, status = $2, failure_reason = $3, updated_at = $4 WHERE id = $5`
+
+	_, err := q.ExecContext(ctx, query, nullString(payment.ProviderReference), payment.Status, payment.FailureReason,
+		payment.UpdatedAt, payment.ID)
+	return err
+}
+
+// lockPayment reads the payment matching the condition and locks it until the end of the transaction
+func lockPayment(ctx context.Context, tx db.Tx, condition string, args ...interface{}) (*Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE ` + condition + ` FOR UPDATE`
+
+	return scanPayment(tx.QueryRowContext(ctx, query, args...))
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	var payment Payment
+	err := row.Scan(
+		&payment.ID,
+		&payment.OrderID,
+		&payment.Provider,
+		&payment.ProviderReference,
+		&payment.Amount,
+		&payment.Status,
+		&payment.FailureReason,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+		&payment.tenantID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
@@ -0,0 +1,187 @@
+package services_test
+
+import (
+	"coffee/coffee-server/payments"
+	"coffee/coffee-server/services"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Payment Service", Label("integration"), func() {
+	var (
+		provider       *payments.FakeProvider
+		orderService   services.OrderService
+		paymentService services.PaymentService
+		order          *services.Order
+	)
+
+	BeforeEach(func() {
+		provider = payments.NewFakeProvider("secret")
+		models := services.New(conn).WithPaymentProvider(conn, provider)
+		orderService = models.Order
+		paymentService = models.Payment
+
+		_, err := db.Exec("DELETE FROM orders")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000', 'Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		Expect(err).To(BeNil())
+
+		order, err = orderService.CreateOrder(services.Order{
+			Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 2}},
+		})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		_, err := db.Exec("DELETE FROM orders")
+		Expect(err).To(BeNil())
+	})
+
+	It("should capture the order total and mark the order as paid", func() {
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+		Expect(payment.Status).To(Equal(payments.StatusCaptured))
+		Expect(payment.Amount).To(Equal(float32(20.0)))
+
+		paid, err := orderService.GetOrderById(order.ID)
+		Expect(err).To(BeNil())
+		Expect(paid.Status).To(Equal(services.OrderStatusPaid))
+	})
+
+	It("should record declined attempts and keep the order pending", func() {
+		_, err := paymentService.Checkout(order.ID, payments.FakeTokenDeclined)
+		Expect(err).To(MatchError(ContainSubstring(services.ErrPaymentDeclined.Error())))
+
+		attempts, err := paymentService.GetPaymentsByOrder(order.ID)
+		Expect(err).To(BeNil())
+		Expect(attempts).To(HaveLen(1))
+		Expect(attempts[0].Status).To(Equal(payments.StatusFailed))
+		Expect(attempts[0].FailureReason).To(Equal("card_declined"))
+
+		pending, err := orderService.GetOrderById(order.ID)
+		Expect(err).To(BeNil())
+		Expect(pending.Status).To(Equal(services.OrderStatusPending))
+	})
+
+	It("should let a single one of concurrent checkouts capture", func() {
+		errs := make(chan error, 4)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				defer GinkgoRecover()
+				_, err := paymentService.Checkout(order.ID, "tok_visa")
+				errs <- err
+			}()
+		}
+
+		captured := 0
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err == nil {
+				captured++
+			}
+		}
+		Expect(captured).To(Equal(1))
+
+		attempts, err := paymentService.GetPaymentsByOrder(order.ID)
+		Expect(err).To(BeNil())
+		Expect(attempts).To(ContainElement(HaveField("Status", payments.StatusCaptured)))
+
+		_, err = paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not check out an order claimed by another payment", func() {
+		_, err := db.Exec("INSERT INTO payments (order_id, provider, amount, status) VALUES ($1, 'fake', 20.0, 'pending')", order.ID)
+		Expect(err).To(BeNil())
+
+		_, err = paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(MatchError(services.ErrPaymentInProgress))
+	})
+
+	It("should expire a stale claim so the order can be checked out again", func() {
+		_, err := db.Exec(`INSERT INTO payments (order_id, provider, amount, status, updated_at) VALUES ($1, 'fake', 20.0, 'pending', NOW() - INTERVAL '1 hour')`, order.ID)
+		Expect(err).To(BeNil())
+		_, err = paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(MatchError(services.ErrPaymentInProgress))
+
+		expired, err := paymentService.ExpireStaleClaims(services.StaleClaimAge)
+		Expect(err).To(BeNil())
+		Expect(expired).To(Equal(int64(1)))
+
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+		Expect(payment.Status).To(Equal(payments.StatusCaptured))
+	})
+
+	It("should refund a captured payment and the order", func() {
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+
+		refunded, err := paymentService.RefundPayment(payment.ID)
+		Expect(err).To(BeNil())
+		Expect(refunded.Status).To(Equal(payments.StatusRefunded))
+
+		_, err = paymentService.RefundPayment(payment.ID)
+		Expect(err).To(MatchError(services.ErrPaymentNotRefundable))
+
+		found, err := orderService.GetOrderById(order.ID)
+		Expect(err).To(BeNil())
+		Expect(found.Status).To(Equal(services.OrderStatusRefunded))
+	})
+
+	It("should apply signed webhook updates", func() {
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+
+		payload := []byte(`{"type": "payment.updated", "reference": "` + payment.ProviderReference + `", "status": "refunded"}`)
+
+		_, err = paymentService.HandleWebhook(payload, "bad")
+		Expect(err).To(MatchError(payments.ErrInvalidSignature))
+
+		updated, err := paymentService.HandleWebhook(payload, provider.Sign(payload))
+		Expect(err).To(BeNil())
+		Expect(updated.Status).To(Equal(payments.StatusRefunded))
+	})
+
+	It("should ignore a webhook update arriving after the payment moved on", func() {
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+
+		for _, status := range []string{payments.StatusAuthorized, payments.StatusFailed} {
+			payload := []byte(`{"type": "payment.updated", "reference": "` + payment.ProviderReference + `", "status": "` + status + `"}`)
+			updated, err := paymentService.HandleWebhook(payload, provider.Sign(payload))
+			Expect(err).To(BeNil())
+			Expect(updated.Status).To(Equal(payments.StatusCaptured))
+		}
+
+		stored, err := paymentService.GetPaymentsByOrder(order.ID)
+		Expect(err).To(BeNil())
+		Expect(stored[0].Status).To(Equal(payments.StatusCaptured))
+	})
+
+	It("should let a single one of concurrent refunds reach the provider", func() {
+		payment, err := paymentService.Checkout(order.ID, "tok_visa")
+		Expect(err).To(BeNil())
+
+		errs := make(chan error, 4)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				defer GinkgoRecover()
+				_, err := paymentService.RefundPayment(payment.ID)
+				errs <- err
+			}()
+		}
+
+		refunded := 0
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err == nil {
+				refunded++
+			} else {
+				Expect(err).To(MatchError(services.ErrPaymentNotRefundable))
+			}
+		}
+		Expect(refunded).To(Equal(1))
+	})
+})
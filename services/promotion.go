@@ -0,0 +1,398 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	ErrOrderRequired     = errors.New("an order_id is required to redeem promotions")
+	ErrOrderNotOfCart    = errors.New("the order wasn't placed from the cart")
+)
+
+type Promotion struct {
+	ID                 string     `json:"id,omitempty"`
+	Code               string     `json:"code,omitempty"`
+	Name               string     `json:"name"`
+	Type               string     `json:"type"`
+	Value              float32    `json:"value"`
+	BuyQuantity        int        `json:"buy_quantity,omitempty"`
+	GetQuantity        int        `json:"get_quantity,omitempty"`
+	Roasts             []string   `json:"roasts"`
+	Regions            []string   `json:"regions"`
+	StartsAt           *time.Time `json:"starts_at"`
+	EndsAt             *time.Time `json:"ends_at"`
+	MaxUses            int        `json:"max_uses"`
+	MaxUsesPerCustomer int        `json:"max_uses_per_customer"`
+	Stackable          bool       `json:"stackable"`
+	Priority           int        `json:"priority"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// PromotionRequest asks which promotions apply to a cart. Promotions without a code always take part,
+// the ones with a code only when it is passed along.
+type PromotionRequest struct {
+	CartID     string   `json:"cart_id"`
+	CustomerID string   `json:"customer_id"`
+	Codes      []string `json:"codes"`
+}
+
+type PromotionService interface {
+	GetAllPromotions() ([]*Promotion, error)
+	CreatePromotion(promotion Promotion) (*Promotion, error)
+	GetPromotionById(id string) (*Promotion, error)
+	DeletePromotion(id string) error
+	EvaluateCart(req PromotionRequest) (*PromotionEvaluation, error)
+	RedeemPromotions(req PromotionRequest, orderId string) (*PromotionEvaluation, error)
+}
+
+// TenantPromotions is implemented by the promotions run per tenant
+type TenantPromotions interface {
+	ForTenant(tenantID string) PromotionService
+}
+
+// PromotionsFor returns the promotions of the tenant
+func PromotionsFor(promotions PromotionService, tenantID string) PromotionService {
+	if scoped, ok := promotions.(TenantPromotions); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return promotions
+}
+
+// PromotionsFromContext returns the promotions of the tenant of the request, the default one when none was resolved
+func PromotionsFromContext(ctx context.Context, promotions PromotionService) PromotionService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return PromotionsFor(promotions, tenant.ID)
+	}
+	return promotions
+}
+
+// Concrete implementation of PromotionService
+type PromotionServiceImpl struct {
+	DB    db.Querier
+	Carts CartService
+	// Tenant is the shop running the promotions, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantPromotions = (*PromotionServiceImpl)(nil)
+
+// ForTenant returns the promotions of the tenant, applied to its carts
+func (s *PromotionServiceImpl) ForTenant(tenantID string) PromotionService {
+	return &PromotionServiceImpl{DB: s.DB, Carts: CartsFor(s.Carts, tenantID), Tenant: tenantID}
+}
+
+func (s *PromotionServiceImpl) tenant() string {
+	if s.Tenant != "" {
+		return s.Tenant
+	}
+	return DefaultTenant
+}
+
+const promotionColumns = `id, COALESCE(code, ''), name, type, value, buy_quantity, get_quantity, roasts::text, regions::text,
+	starts_at, ends_at, max_uses, max_uses_per_customer, stackable, priority, created_at, updated_at`
+
+// Validate checks the promotion can be evaluated and normalizes its code
+func (p *Promotion) Validate() error {
+	p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percentage must be between 0 and 100", ErrInvalidPromotion)
+		}
+	case PromotionFixedAmount:
+		if p.Value <= 0 {
+			return fmt.Errorf("%w: amount must be greater than zero", ErrInvalidPromotion)
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be greater than zero", ErrInvalidPromotion)
+		}
+	case PromotionFreeShipping:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidPromotion, p.Type)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	if p.MaxUses < 0 || p.MaxUsesPerCustomer < 0 {
+		return fmt.Errorf("%w: usage limits can't be negative", ErrInvalidPromotion)
+	}
+	if p.Roasts == nil {
+		p.Roasts = []string{}
+	}
+	if p.Regions == nil {
+		p.Regions = []string{}
+	}
+	return nil
+}
+
+func (s *PromotionServiceImpl) GetAllPromotions() ([]*Promotion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE tenant_id = $1 ORDER BY priority DESC, created_at`
+
+	return queryPromotions(ctx, s.DB, query, s.tenant())
+}
+
+func (s *PromotionServiceImpl) CreatePromotion(promotion Promotion) (*Promotion, error) {
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	roasts, _ := json.Marshal(promotion.Roasts)
+	regions, _ := json.Marshal(promotion.Regions)
+
+	now := time.Now()
+	promotion.CreatedAt = now
+	promotion.UpdatedAt = now
+
+	query := `INSERT INTO promotions(code, name, type, value, buy_quantity, get_quantity, roasts, regions, starts_at, ends_at,
+		max_uses, max_uses_per_customer, stackable, priority, created_at, updated_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb, $9, $10, $11, $12, $13, $14, $15, $16, $17) returning id`
+
+	err := s.DB.QueryRowContext(ctx, query, nullString(promotion.Code), promotion.Name, promotion.Type, promotion.Value,
+		promotion.BuyQuantity, promotion.GetQuantity, string(roasts), string(regions), promotion.StartsAt, promotion.EndsAt,
+		promotion.MaxUses, promotion.MaxUsesPerCustomer, promotion.Stackable, promotion.Priority, now, now, s.tenant()).Scan(&promotion.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func (s *PromotionServiceImpl) GetPromotionById(id string) (*Promotion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1 AND tenant_id = $2`
+
+	found, err := queryPromotions(ctx, s.DB, query, id, s.tenant())
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, ErrPromotionNotFound
+	}
+	return found[0], nil
+}
+
+func (s *PromotionServiceImpl) DeletePromotion(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM promotions WHERE id = $1 AND tenant_id = $2`, id, s.tenant())
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+func (s *PromotionServiceImpl) EvaluateCart(req PromotionRequest) (*PromotionEvaluation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	cart, err := s.Carts.GetCartById(req.CartID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.evaluate(ctx, s.DB, cart, req, false)
+}
+
+// RedeemPromotions evaluates the cart and records a redemption for every applied promotion,
+// which counts towards their usage limits. The redemptions are made for an order placed from the cart.
+// The promotions are locked while their uses are counted and recorded, so concurrent redemptions can't
+// go over the limits together.
+func (s *PromotionServiceImpl) RedeemPromotions(req PromotionRequest, orderId string) (*PromotionEvaluation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	if strings.TrimSpace(orderId) == "" {
+		return nil, ErrOrderRequired
+	}
+
+	cart, err := s.Carts.GetCartById(req.CartID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var cartId string
+	query := `SELECT COALESCE(cart_id::text, '') FROM orders WHERE id::text = $1 AND tenant_id = $2`
+	err = tx.QueryRowContext(ctx, query, orderId, s.tenant()).Scan(&cartId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cartId != cart.ID {
+		return nil, ErrOrderNotOfCart
+	}
+
+	evaluation, err := s.evaluate(ctx, tx, cart, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO promotion_redemptions(promotion_id, customer_id, order_id, discount, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	for _, applied := range evaluation.Applied {
+		_, err := tx.ExecContext(ctx, query, applied.PromotionID, strings.TrimSpace(req.CustomerID), orderId, applied.Discount, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return evaluation, nil
+}
+
+// evaluate applies the promotions to the cart, lock holds the candidate promotions until the transaction
+// of q ends before their uses are counted
+func (s *PromotionServiceImpl) evaluate(ctx context.Context, q db.Executor, cart *Cart, req PromotionRequest, lock bool) (*PromotionEvaluation, error) {
+	codes := make([]string, 0, len(req.Codes))
+	seen := map[string]bool{}
+	for _, code := range req.Codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	// Automatic promotions plus the ones matching the requested codes
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code IS NULL AND tenant_id = $1`
+	candidates, err := queryPromotions(ctx, q, query, s.tenant())
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, code := range codes {
+		found, err := queryPromotions(ctx, q, `SELECT `+promotionColumns+` FROM promotions WHERE code = $1 AND tenant_id = $2`, code, s.tenant())
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			unknown = append(unknown, code)
+			continue
+		}
+		candidates = append(candidates, found[0])
+	}
+
+	if lock && len(candidates) > 0 {
+		if err := lockPromotions(ctx, q, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	// A blank customer is no customer, the promotions limited per customer don't apply without one
+	customerId := strings.TrimSpace(req.CustomerID)
+	usage := map[string]PromotionUsage{}
+	for _, promotion := range candidates {
+		current := PromotionUsage{HasCustomer: customerId != ""}
+
+		query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE customer_id = $2 AND $2 <> '') FROM promotion_redemptions WHERE promotion_id = $1`
+
+		err := q.QueryRowContext(ctx, query, promotion.ID, customerId).Scan(&current.Total, &current.ByCustomer)
+		if err != nil {
+			return nil, err
+		}
+		usage[promotion.ID] = current
+	}
+
+	evaluation := EvaluatePromotions(cart, candidates, usage, time.Now())
+	for _, code := range unknown {
+		evaluation.Rejected = append(evaluation.Rejected, RejectedPromotion{Code: code, Reason: "unknown promotion code"})
+	}
+	return evaluation, nil
+}
+
+// lockPromotions locks the rows of the promotions in the order of their ids, so two redemptions sharing
+// promotions can't wait on each other
+func lockPromotions(ctx context.Context, q db.Executor, promotions []*Promotion) error {
+	ids := make([]string, len(promotions))
+	for i, promotion := range promotions {
+		ids[i] = promotion.ID
+	}
+	_, err := q.ExecContext(ctx, `SELECT id FROM promotions WHERE id::text = ANY($1) ORDER BY id FOR UPDATE`, ids)
+	return err
+}
+
+func queryPromotions(ctx context.Context, q db.Executor, query string, args ...interface{}) ([]*Promotion, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []*Promotion{}
+	for rows.Next() {
+		var promotion Promotion
+		var roasts, regions string
+
+		err := rows.Scan(
+			&promotion.ID,
+			&promotion.Code,
+			&promotion.Name,
+			&promotion.Type,
+			&promotion.Value,
+			&promotion.BuyQuantity,
+			&promotion.GetQuantity,
+			&roasts,
+			&regions,
+			&promotion.StartsAt,
+			&promotion.EndsAt,
+			&promotion.MaxUses,
+			&promotion.MaxUsesPerCustomer,
+			&promotion.Stackable,
+			&promotion.Priority,
+			&promotion.CreatedAt,
+			&promotion.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(roasts), &promotion.Roasts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(regions), &promotion.Regions); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, &promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
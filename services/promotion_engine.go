@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PromotionPercentage   = "percentage"
+	PromotionFixedAmount  = "fixed_amount"
+	PromotionBuyXGetY     = "buy_x_get_y"
+	PromotionFreeShipping = "free_shipping"
+
+	// ShippingFee is the flat shipping cost charged on every cart
+	ShippingFee float32 = 4.95
+)
+
+type AppliedPromotion struct {
+	PromotionID string  `json:"promotion_id"`
+	Code        string  `json:"code,omitempty"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Discount    float32 `json:"discount"`
+	Reason      string  `json:"reason"`
+}
+
+type RejectedPromotion struct {
+	PromotionID string `json:"promotion_id,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+type PromotionEvaluation struct {
+	CartID   string              `json:"cart_id"`
+	Subtotal float32             `json:"subtotal"`
+	Shipping float32             `json:"shipping"`
+	Discount float32             `json:"discount"`
+	Total    float32             `json:"total"`
+	Applied  []AppliedPromotion  `json:"applied"`
+	Rejected []RejectedPromotion `json:"rejected"`
+}
+
+// PromotionUsage is how often a promotion was redeemed in total and by the current customer
+type PromotionUsage struct {
+	Total       int
+	ByCustomer  int
+	HasCustomer bool
+}
+
+// EvaluatePromotions works out which of the candidate promotions apply to the cart and why the others don't.
+// Candidates are tried by descending priority; a promotion that is not stackable can't be combined with any other.
+func EvaluatePromotions(cart *Cart, candidates []*Promotion, usage map[string]PromotionUsage, now time.Time) *PromotionEvaluation {
+	evaluation := &PromotionEvaluation{
+		CartID:   cart.ID,
+		Subtotal: cart.CalculateTotal(),
+		Shipping: ShippingFee,
+		Applied:  []AppliedPromotion{},
+		Rejected: []RejectedPromotion{},
+	}
+	if len(cart.Items) == 0 {
+		evaluation.Shipping = 0
+	}
+
+	sorted := make([]*Promotion, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	remaining := evaluation.Subtotal
+	shippingWaived := false
+	var exclusive *Promotion
+
+	for _, promotion := range sorted {
+		reject := func(reason string) {
+			evaluation.Rejected = append(evaluation.Rejected, RejectedPromotion{
+				PromotionID: promotion.ID,
+				Code:        promotion.Code,
+				Name:        promotion.Name,
+				Reason:      reason,
+			})
+		}
+
+		if reason, ok := promotion.isActive(now, usage[promotion.ID]); !ok {
+			reject(reason)
+			continue
+		}
+		if exclusive != nil {
+			reject(fmt.Sprintf("cannot be combined with %s", exclusive.Name))
+			continue
+		}
+		if !promotion.Stackable && len(evaluation.Applied) > 0 {
+			reject(fmt.Sprintf("cannot be combined with %s", evaluation.Applied[0].Name))
+			continue
+		}
+
+		eligible := promotion.eligibleItems(cart.Items)
+		if len(eligible) == 0 {
+			reject("no items in the cart match " + promotion.scopeDescription())
+			continue
+		}
+
+		var discount float32
+		var reason string
+
+		switch promotion.Type {
+		case PromotionPercentage:
+			discount = itemsSubtotal(eligible) * promotion.Value / 100
+			reason = fmt.Sprintf("%.0f%% off %s", promotion.Value, promotion.scopeDescription())
+		case PromotionFixedAmount:
+			discount = min(promotion.Value, itemsSubtotal(eligible))
+			reason = fmt.Sprintf("%.2f off %s", promotion.Value, promotion.scopeDescription())
+		case PromotionBuyXGetY:
+			discount = buyXGetYDiscount(eligible, promotion.BuyQuantity, promotion.GetQuantity)
+			if discount == 0 {
+				reject(fmt.Sprintf("needs at least %d items of %s", promotion.BuyQuantity+promotion.GetQuantity, promotion.scopeDescription()))
+				continue
+			}
+			reason = fmt.Sprintf("buy %d get %d free on %s", promotion.BuyQuantity, promotion.GetQuantity, promotion.scopeDescription())
+		case PromotionFreeShipping:
+			if shippingWaived || evaluation.Shipping == 0 {
+				reject("shipping is already free")
+				continue
+			}
+			shippingWaived = true
+			discount = evaluation.Shipping
+			reason = "free shipping for " + promotion.scopeDescription()
+		default:
+			reject("unknown promotion type")
+			continue
+		}
+
+		// Item discounts never take the subtotal below zero
+		if promotion.Type != PromotionFreeShipping {
+			discount = min(discount, remaining)
+			remaining -= discount
+		}
+
+		evaluation.Discount += discount
+		evaluation.Applied = append(evaluation.Applied, AppliedPromotion{
+			PromotionID: promotion.ID,
+			Code:        promotion.Code,
+			Name:        promotion.Name,
+			Type:        promotion.Type,
+			Discount:    discount,
+			Reason:      reason,
+		})
+		if !promotion.Stackable {
+			exclusive = promotion
+		}
+	}
+
+	evaluation.Total = evaluation.Subtotal + evaluation.Shipping - evaluation.Discount
+	return evaluation
+}
+
+func (p *Promotion) isActive(now time.Time, usage PromotionUsage) (string, bool) {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return "not valid until " + p.StartsAt.Format(time.RFC3339), false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return "expired on " + p.EndsAt.Format(time.RFC3339), false
+	}
+	if p.MaxUses > 0 && usage.Total >= p.MaxUses {
+		return "usage limit reached", false
+	}
+	if p.MaxUsesPerCustomer > 0 {
+		if !usage.HasCustomer {
+			return "limited per customer, a customer_id is required", false
+		}
+		if usage.ByCustomer >= p.MaxUsesPerCustomer {
+			return "usage limit per customer reached", false
+		}
+	}
+	return "", true
+}
+
+func (p *Promotion) eligibleItems(items []CartItem) []CartItem {
+	var eligible []CartItem
+	for _, item := range items {
+		if matchesScope(p.Roasts, item.Roast) && matchesScope(p.Regions, item.Region) {
+			eligible = append(eligible, item)
+		}
+	}
+	return eligible
+}
+
+func (p *Promotion) scopeDescription() string {
+	var parts []string
+	if len(p.Roasts) > 0 {
+		parts = append(parts, strings.Join(p.Roasts, "/")+" roasts")
+	}
+	if len(p.Regions) > 0 {
+		parts = append(parts, "coffees from "+strings.Join(p.Regions, "/"))
+	}
+	if len(parts) == 0 {
+		return "the whole cart"
+	}
+	return strings.Join(parts, " and ")
+}
+
+func matchesScope(scope []string, value string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, s := range scope {
+		if strings.EqualFold(s, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func itemsSubtotal(items []CartItem) float32 {
+	var subtotal float32
+	for _, item := range items {
+		subtotal += item.UnitPrice * float32(item.Quantity)
+	}
+	return subtotal
+}
+
+// buyXGetYDiscount makes the cheapest units free, get of them for every buy+get units
+func buyXGetYDiscount(items []CartItem, buy, get int) float32 {
+	var prices []float32
+	for _, item := range items {
+		for i := 0; i < item.Quantity; i++ {
+			prices = append(prices, item.UnitPrice)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i] < prices[j] })
+
+	free := len(prices) / (buy + get) * get
+
+	var discount float32
+	for _, price := range prices[:free] {
+		discount += price
+	}
+	return discount
+}
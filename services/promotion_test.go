@@ -0,0 +1,273 @@
+package services_test
+
+import (
+	"coffee/coffee-server/services"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Promotions engine", Label("unit"), func() {
+	var (
+		cart *services.Cart
+		now  time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2024, 10, 23, 12, 0, 0, 0, time.UTC)
+		cart = &services.Cart{
+			ID: "cart-1",
+			Items: []services.CartItem{
+				{CoffeeID: "a", Roast: "Dark", Region: "Brazil", UnitPrice: 10, Quantity: 2},
+				{CoffeeID: "b", Roast: "Light", Region: "Ethiopia", UnitPrice: 15, Quantity: 1},
+			},
+		}
+	})
+
+	It("should charge shipping without promotions", func() {
+		evaluation := services.EvaluatePromotions(cart, nil, nil, now)
+
+		Expect(evaluation.Subtotal).To(Equal(float32(35)))
+		Expect(evaluation.Shipping).To(Equal(services.ShippingFee))
+		Expect(evaluation.Total).To(Equal(35 + services.ShippingFee))
+		Expect(evaluation.Applied).To(BeEmpty())
+	})
+
+	It("should apply a percentage only to the roasts in scope", func() {
+		promotion := &services.Promotion{ID: "p1", Name: "Dark days", Type: services.PromotionPercentage, Value: 10, Roasts: []string{"dark"}}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{promotion}, nil, now)
+
+		Expect(evaluation.Applied).To(HaveLen(1))
+		Expect(evaluation.Applied[0].Discount).To(BeNumerically("~", 2.0, 0.001))
+		Expect(evaluation.Applied[0].Reason).To(ContainSubstring("dark roasts"))
+	})
+
+	It("should cap a fixed amount at the eligible subtotal", func() {
+		promotion := &services.Promotion{ID: "p1", Name: "Ethiopia treat", Type: services.PromotionFixedAmount, Value: 50, Regions: []string{"Ethiopia"}}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{promotion}, nil, now)
+
+		Expect(evaluation.Discount).To(Equal(float32(15)))
+	})
+
+	It("should make the cheapest units free for buy x get y", func() {
+		promotion := &services.Promotion{ID: "p1", Name: "3 for 2", Type: services.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{promotion}, nil, now)
+
+		Expect(evaluation.Discount).To(Equal(float32(10)))
+	})
+
+	It("should explain why buy x get y does not apply to too few items", func() {
+		promotion := &services.Promotion{ID: "p1", Name: "3 for 2 light", Type: services.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Roasts: []string{"Light"}}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{promotion}, nil, now)
+
+		Expect(evaluation.Applied).To(BeEmpty())
+		Expect(evaluation.Rejected[0].Reason).To(ContainSubstring("needs at least 3 items"))
+	})
+
+	It("should waive shipping once", func() {
+		first := &services.Promotion{ID: "p1", Name: "Free shipping", Type: services.PromotionFreeShipping, Stackable: true}
+		second := &services.Promotion{ID: "p2", Name: "Free shipping Brazil", Type: services.PromotionFreeShipping, Stackable: true, Regions: []string{"Brazil"}}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{first, second}, nil, now)
+
+		Expect(evaluation.Applied).To(HaveLen(1))
+		Expect(evaluation.Total).To(Equal(float32(35)))
+		Expect(evaluation.Rejected[0].Reason).To(Equal("shipping is already free"))
+	})
+
+	It("should reject promotions outside their validity window", func() {
+		future := now.Add(time.Hour)
+		past := now.Add(-time.Hour)
+		upcoming := &services.Promotion{ID: "p1", Name: "Upcoming", Type: services.PromotionPercentage, Value: 10, StartsAt: &future}
+		expired := &services.Promotion{ID: "p2", Name: "Expired", Type: services.PromotionPercentage, Value: 10, EndsAt: &past}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{upcoming, expired}, nil, now)
+
+		Expect(evaluation.Applied).To(BeEmpty())
+		Expect(evaluation.Rejected[0].Reason).To(HavePrefix("not valid until"))
+		Expect(evaluation.Rejected[1].Reason).To(HavePrefix("expired on"))
+	})
+
+	It("should enforce usage limits in total and per customer", func() {
+		limited := &services.Promotion{ID: "p1", Name: "Limited", Type: services.PromotionPercentage, Value: 10, MaxUses: 5, Stackable: true}
+		once := &services.Promotion{ID: "p2", Name: "Once", Type: services.PromotionPercentage, Value: 10, MaxUsesPerCustomer: 1, Stackable: true}
+		usage := map[string]services.PromotionUsage{
+			"p1": {Total: 5, HasCustomer: true},
+			"p2": {Total: 3, ByCustomer: 1, HasCustomer: true},
+		}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{limited, once}, usage, now)
+
+		Expect(evaluation.Applied).To(BeEmpty())
+		Expect(evaluation.Rejected[0].Reason).To(Equal("usage limit reached"))
+		Expect(evaluation.Rejected[1].Reason).To(Equal("usage limit per customer reached"))
+	})
+
+	It("should not combine an exclusive promotion with others", func() {
+		exclusive := &services.Promotion{ID: "p1", Name: "Big sale", Type: services.PromotionPercentage, Value: 20, Priority: 10}
+		stackable := &services.Promotion{ID: "p2", Name: "Free shipping", Type: services.PromotionFreeShipping, Stackable: true}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{stackable, exclusive}, nil, now)
+
+		Expect(evaluation.Applied).To(HaveLen(1))
+		Expect(evaluation.Applied[0].Name).To(Equal("Big sale"))
+		Expect(evaluation.Rejected[0].Reason).To(Equal("cannot be combined with Big sale"))
+	})
+
+	It("should stack stackable promotions", func() {
+		percentage := &services.Promotion{ID: "p1", Name: "10% off", Type: services.PromotionPercentage, Value: 10, Stackable: true}
+		shipping := &services.Promotion{ID: "p2", Name: "Free shipping", Type: services.PromotionFreeShipping, Stackable: true}
+
+		evaluation := services.EvaluatePromotions(cart, []*services.Promotion{percentage, shipping}, nil, now)
+
+		Expect(evaluation.Applied).To(HaveLen(2))
+		Expect(evaluation.Total).To(BeNumerically("~", 31.5, 0.001))
+	})
+
+	Describe("Validate", func() {
+		It("should normalize the code", func() {
+			promotion := services.Promotion{Code: " summer10 ", Name: "Summer", Type: services.PromotionPercentage, Value: 10}
+			Expect(promotion.Validate()).To(Succeed())
+			Expect(promotion.Code).To(Equal("SUMMER10"))
+		})
+
+		It("should reject impossible promotions", func() {
+			invalid := []services.Promotion{
+				{Name: "Too much", Type: services.PromotionPercentage, Value: 120},
+				{Name: "Nothing", Type: services.PromotionFixedAmount},
+				{Name: "Buy none", Type: services.PromotionBuyXGetY, BuyQuantity: 0, GetQuantity: 1},
+				{Name: "Unknown", Type: "bogo"},
+				{Type: services.PromotionFreeShipping},
+			}
+			for _, promotion := range invalid {
+				Expect(errors.Is(promotion.Validate(), services.ErrInvalidPromotion)).To(BeTrue(), promotion.Name)
+			}
+		})
+	})
+})
+
+var _ = Describe("Promotion Service", Label("integration"), func() {
+	var (
+		models services.Models
+		cart   *services.Cart
+		order  *services.Order
+	)
+
+	BeforeEach(func() {
+		models = services.New(conn)
+
+		_, err := db.Exec("DELETE FROM promotions")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM carts")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM coffees")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("INSERT INTO coffees (id, name, roast, image, region, price, grind_unit) VALUES ('550e8400-e29b-41d4-a716-446655440000', 'Espresso', 'Dark', 'image1.png', 'Brazil', 10.0, 1)")
+		Expect(err).To(BeNil())
+
+		cart, err = models.Cart.CreateCart("")
+		Expect(err).To(BeNil())
+		cart, err = models.Cart.AddCartItem(cart.ID, services.CartItem{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Grind: "espresso", Quantity: 2})
+		Expect(err).To(BeNil())
+		order, err = models.Order.CreateOrder(services.Order{CartID: cart.ID, Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 2}}})
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		_, err := db.Exec("DELETE FROM promotions")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM orders")
+		Expect(err).To(BeNil())
+		_, err = db.Exec("DELETE FROM carts")
+		Expect(err).To(BeNil())
+	})
+
+	It("should only apply coded promotions when the code is given", func() {
+		_, err := models.Promotion.CreatePromotion(services.Promotion{Code: "dark10", Name: "Dark 10", Type: services.PromotionPercentage, Value: 10, Roasts: []string{"Dark"}})
+		Expect(err).To(BeNil())
+
+		evaluation, err := models.Promotion.EvaluateCart(services.PromotionRequest{CartID: cart.ID})
+		Expect(err).To(BeNil())
+		Expect(evaluation.Applied).To(BeEmpty())
+
+		evaluation, err = models.Promotion.EvaluateCart(services.PromotionRequest{CartID: cart.ID, Codes: []string{"DARK10", "NOPE"}})
+		Expect(err).To(BeNil())
+		Expect(evaluation.Applied).To(HaveLen(1))
+		Expect(evaluation.Discount).To(Equal(float32(2)))
+		Expect(evaluation.Rejected).To(ContainElement(services.RejectedPromotion{Code: "NOPE", Reason: "unknown promotion code"}))
+	})
+
+	It("should count redemptions towards the per customer limit", func() {
+		_, err := models.Promotion.CreatePromotion(services.Promotion{Code: "WELCOME", Name: "Welcome", Type: services.PromotionFixedAmount, Value: 5, MaxUsesPerCustomer: 1})
+		Expect(err).To(BeNil())
+
+		req := services.PromotionRequest{CartID: cart.ID, CustomerID: "customer-1", Codes: []string{"welcome"}}
+
+		redeemed, err := models.Promotion.RedeemPromotions(req, order.ID)
+		Expect(err).To(BeNil())
+		Expect(redeemed.Applied).To(HaveLen(1))
+
+		evaluation, err := models.Promotion.EvaluateCart(req)
+		Expect(err).To(BeNil())
+		Expect(evaluation.Applied).To(BeEmpty())
+		Expect(evaluation.Rejected[0].Reason).To(Equal("usage limit per customer reached"))
+
+		req.CustomerID = "customer-2"
+		evaluation, err = models.Promotion.EvaluateCart(req)
+		Expect(err).To(BeNil())
+		Expect(evaluation.Applied).To(HaveLen(1))
+	})
+
+	It("should only redeem for an order placed from the cart", func() {
+		req := services.PromotionRequest{CartID: cart.ID}
+
+		_, err := models.Promotion.RedeemPromotions(req, "")
+		Expect(err).To(MatchError(services.ErrOrderRequired))
+		_, err = models.Promotion.RedeemPromotions(req, "00000000-0000-0000-0000-000000000000")
+		Expect(err).To(MatchError(services.ErrOrderNotFound))
+
+		other, err := models.Order.CreateOrder(services.Order{Items: []services.OrderItem{{CoffeeID: "550e8400-e29b-41d4-a716-446655440000", Quantity: 1}}})
+		Expect(err).To(BeNil())
+		_, err = models.Promotion.RedeemPromotions(req, other.ID)
+		Expect(err).To(MatchError(services.ErrOrderNotOfCart))
+	})
+
+	It("should not apply a promotion limited per customer without one", func() {
+		_, err := models.Promotion.CreatePromotion(services.Promotion{Code: "WELCOME", Name: "Welcome", Type: services.PromotionFixedAmount, Value: 5, MaxUsesPerCustomer: 1})
+		Expect(err).To(BeNil())
+
+		redeemed, err := models.Promotion.RedeemPromotions(services.PromotionRequest{CartID: cart.ID, CustomerID: "  ", Codes: []string{"welcome"}}, order.ID)
+		Expect(err).To(BeNil())
+		Expect(redeemed.Applied).To(BeEmpty())
+		Expect(redeemed.Rejected[0].Reason).To(Equal("limited per customer, a customer_id is required"))
+	})
+
+	It("should not go over the total limit with concurrent redemptions", func() {
+		_, err := models.Promotion.CreatePromotion(services.Promotion{Code: "FIRST", Name: "First", Type: services.PromotionFixedAmount, Value: 5, MaxUses: 1})
+		Expect(err).To(BeNil())
+
+		applied := make(chan int, 4)
+		for i := 0; i < cap(applied); i++ {
+			go func(i int) {
+				defer GinkgoRecover()
+				req := services.PromotionRequest{CartID: cart.ID, CustomerID: fmt.Sprintf("customer-%d", i), Codes: []string{"first"}}
+				redeemed, err := models.Promotion.RedeemPromotions(req, order.ID)
+				Expect(err).To(BeNil())
+				applied <- len(redeemed.Applied)
+			}(i)
+		}
+
+		total := 0
+		for i := 0; i < cap(applied); i++ {
+			total += <-applied
+		}
+		Expect(total).To(Equal(1))
+	})
+})
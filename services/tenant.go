@@ -0,0 +1,231 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultTenant is the shop of the catalog from before the tenants, it serves the requests naming no tenant
+const (
+	DefaultTenant     = "00000000-0000-0000-0000-000000000001"
+	DefaultTenantSlug = "default"
+)
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrInvalidTenant  = errors.New("invalid tenant")
+)
+
+var (
+	// tenantSlug is what a slug can be, it names the tenant in its subdomain
+	tenantSlug   = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Tenant is a shop with its own catalog
+type Tenant struct {
+	ID        string       `json:"id,omitempty"`
+	Slug      string       `json:"slug"`
+	Name      string       `json:"name"`
+	Config    TenantConfig `json:"config"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// TenantConfig is the configuration of a shop, what is left out falls back on the defaults of the server
+type TenantConfig struct {
+	// Currency of the prices, an ISO 4217 code
+	Currency string `json:"currency,omitempty"`
+	// Locale the catalog is written in, a BCP 47 tag
+	Locale string `json:"locale,omitempty"`
+	// Timezone of the shop, an IANA name
+	Timezone string `json:"timezone,omitempty"`
+}
+
+func (c TenantConfig) validate() error {
+	if c.Currency != "" && !currencyCode.MatchString(c.Currency) {
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidTenant)
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidTenant, c.Timezone)
+		}
+	}
+	return nil
+}
+
+type TenantService interface {
+	GetAllTenants() ([]*Tenant, error)
+	GetTenantById(id string) (*Tenant, error)
+	GetTenantBySlug(slug string) (*Tenant, error)
+	CreateTenant(tenant Tenant) (*Tenant, error)
+	UpdateTenantConfig(id string, config TenantConfig) (*Tenant, error)
+}
+
+// Concrete implementation of TenantService
+type TenantServiceImpl struct {
+	DB db.Querier
+}
+
+const tenantColumns = `id, slug, name, config::text, created_at, updated_at`
+
+func (s *TenantServiceImpl) GetAllTenants() ([]*Tenant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	rows, err := s.DB.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := []*Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+func (s *TenantServiceImpl) GetTenantById(id string) (*Tenant, error) {
+	return s.getTenant(`id = $1`, id)
+}
+
+func (s *TenantServiceImpl) GetTenantBySlug(slug string) (*Tenant, error) {
+	return s.getTenant(`slug = $1`, slug)
+}
+
+func (s *TenantServiceImpl) getTenant(condition string, arg string) (*Tenant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	tenant, err := scanTenant(s.DB.QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE `+condition, arg))
+	// An id that isn't a UUID can't name a tenant
+	if errors.Is(err, sql.ErrNoRows) || db.SQLState(err) == db.InvalidText {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+func (s *TenantServiceImpl) CreateTenant(tenant Tenant) (*Tenant, error) {
+	if !tenantSlug.MatchString(tenant.Slug) {
+		return nil, fmt.Errorf("%w: slug must be lowercase letters, digits and dashes", ErrInvalidTenant)
+	}
+	if tenant.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTenant)
+	}
+	if err := tenant.Config.validate(); err != nil {
+		return nil, err
+	}
+	config, err := json.Marshal(tenant.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	now := time.Now()
+	tenant.CreatedAt, tenant.UpdatedAt = now, now
+
+	query := `INSERT INTO tenants(slug, name, config, created_at, updated_at) VALUES ($1, $2, $3::jsonb, $4, $5) returning id`
+
+	err = s.DB.QueryRowContext(ctx, query, tenant.Slug, tenant.Name, string(config), now, now).Scan(&tenant.ID)
+	if err != nil {
+		if db.SQLState(err) == db.UniqueViolation {
+			return nil, fmt.Errorf("%w: slug %q is taken", ErrInvalidTenant, tenant.Slug)
+		}
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (s *TenantServiceImpl) UpdateTenantConfig(id string, config TenantConfig) (*Tenant, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `UPDATE tenants SET config = $1::jsonb, updated_at = $2 WHERE id = $3 RETURNING ` + tenantColumns
+
+	tenant, err := scanTenant(s.DB.QueryRowContext(ctx, query, string(data), time.Now(), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+func scanTenant(row db.Row) (*Tenant, error) {
+	var tenant Tenant
+	var config string
+	err := row.Scan(&tenant.ID, &tenant.Slug, &tenant.Name, &config, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(config), &tenant.Config); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant returns the context of a request made to the tenant
+func ContextWithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of the request, false when it was never resolved
+func TenantFromContext(ctx context.Context) (*Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(*Tenant)
+	return tenant, ok && tenant != nil
+}
+
+// TenantCatalog is implemented by the catalogs keeping a catalog per tenant
+type TenantCatalog interface {
+	ForTenant(tenantID string) CoffeeService
+}
+
+// CatalogFor returns the catalog of the tenant. A catalog that isn't split by tenant, e.g. the one kept
+// in memory, is the catalog of every tenant.
+func CatalogFor(coffees CoffeeService, tenantID string) CoffeeService {
+	if catalog, ok := coffees.(TenantCatalog); ok && tenantID != "" {
+		return catalog.ForTenant(tenantID)
+	}
+	return coffees
+}
+
+// CatalogFromContext returns the catalog of the tenant of the request, the default one when none was resolved.
+// It reads from the primary for a client reading its own writes.
+func CatalogFromContext(ctx context.Context, coffees CoffeeService) CoffeeService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		coffees = CatalogFor(coffees, tenant.ID)
+	}
+	if primaryReads(ctx) {
+		coffees = onPrimary(coffees)
+	}
+	return coffees
+}
+
+// onPrimary returns the catalog reading from the primary, a catalog without replicas is the same
+func onPrimary(coffees CoffeeService) CoffeeService {
+	if catalog, ok := coffees.(PrimaryCatalog); ok {
+		return catalog.OnPrimary()
+	}
+	return coffees
+}
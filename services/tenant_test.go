@@ -0,0 +1,168 @@
+package services_test
+
+import (
+	"coffee/coffee-server/cache"
+	"coffee/coffee-server/events"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgconn"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Tenants", Label("unit"), func() {
+	var (
+		conn    *mocks.DBInterface
+		tenants *services.TenantServiceImpl
+	)
+
+	BeforeEach(func() {
+		conn = &mocks.DBInterface{}
+		tenants = &services.TenantServiceImpl{DB: conn}
+	})
+
+	It("should refuse an invalid tenant before writing it", func() {
+		_, err := tenants.CreateTenant(services.Tenant{Slug: "The Roastery", Name: "The Roastery"})
+		Expect(err).To(MatchError(services.ErrInvalidTenant))
+		_, err = tenants.CreateTenant(services.Tenant{Slug: "roastery"})
+		Expect(err).To(MatchError(services.ErrInvalidTenant))
+		_, err = tenants.UpdateTenantConfig("t1", services.TenantConfig{Timezone: "Mars/Olympus"})
+		Expect(err).To(MatchError(services.ErrInvalidTenant))
+		conn.AssertNotCalled(GinkgoT(), "QueryRowContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("should report a taken slug as an invalid tenant", func() {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(&pgconn.PgError{Code: "23505"})
+		conn.On("QueryRowContext", mock.Anything, mock.Anything, "roastery", "The Roastery", `{"currency":"EUR"}`, mock.Anything, mock.Anything).Return(row)
+
+		_, err := tenants.CreateTenant(services.Tenant{Slug: "roastery", Name: "The Roastery", Config: services.TenantConfig{Currency: "EUR"}})
+		Expect(err).To(MatchError(ContainSubstring(`slug "roastery" is taken`)))
+	})
+
+	It("should not find a tenant by an id that isn't a UUID", func() {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&pgconn.PgError{Code: "22P02"})
+		conn.On("QueryRowContext", mock.Anything, mock.Anything, "nope").Return(row)
+
+		_, err := tenants.GetTenantById("nope")
+		Expect(err).To(MatchError(services.ErrTenantNotFound))
+	})
+
+	Describe("Catalogs", func() {
+		It("should limit the queries to the tenant", func() {
+			catalog := services.CatalogFor(&services.CoffeeServiceImpl{DB: conn}, "t1")
+			conn.On("QueryContext", mock.Anything, mock.Anything, "t1").Return(nil, sql.ErrConnDone).Once()
+			row := &mocks.Row{}
+			row.On("Scan", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+			// By slug and then by former slug
+			conn.On("QueryRowContext", mock.Anything, mock.Anything, "42", "t1").Return(row).Twice()
+
+			_, err := catalog.GetAllCoffees()
+			Expect(err).To(MatchError(sql.ErrConnDone))
+			_, err = catalog.GetCoffeesById("42")
+			Expect(err).To(MatchError(sql.ErrNoRows))
+			conn.AssertExpectations(GinkgoT())
+		})
+
+		It("should pick the catalog of the tenant of the request through the cache", func() {
+			first, second := new(mocks.CoffeeService), new(mocks.CoffeeService)
+			scoped := &scopedCatalog{CoffeeService: new(mocks.CoffeeService), tenants: map[string]services.CoffeeService{"t1": first, "t2": second}}
+			cached := services.NewCachedCoffeeService(scoped, cache.NewLRU(100), time.Minute)
+			first.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c1"}}, nil).Once()
+			second.On("GetAllCoffees").Return([]*services.Coffee{{ID: "c2"}}, nil).Once()
+
+			for range 2 {
+				ctx := services.ContextWithTenant(context.Background(), &services.Tenant{ID: "t1"})
+				coffees, err := services.CatalogFromContext(ctx, cached).GetAllCoffees()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(coffees[0].ID).To(Equal("c1"))
+
+				ctx = services.ContextWithTenant(context.Background(), &services.Tenant{ID: "t2"})
+				coffees, err = services.CatalogFromContext(ctx, cached).GetAllCoffees()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(coffees[0].ID).To(Equal("c2"))
+			}
+			first.AssertExpectations(GinkgoT())
+			second.AssertExpectations(GinkgoT())
+		})
+	})
+
+	Describe("Orders, promotions and webhooks", func() {
+		It("should limit the queries to the tenant", func() {
+			conn.On("QueryContext", mock.Anything, mock.Anything, "t1").Return(nil, sql.ErrConnDone).Twice()
+			conn.On("QueryContext", mock.Anything, mock.Anything, "t2").Return(nil, sql.ErrConnDone).Once()
+
+			_, err := services.OrdersFor(&services.OrderServiceImpl{DB: conn}, "t1").GetAllOrders()
+			Expect(err).To(MatchError(sql.ErrConnDone))
+			_, err = services.PromotionsFor(&services.PromotionServiceImpl{DB: conn}, "t1").GetAllPromotions()
+			Expect(err).To(MatchError(sql.ErrConnDone))
+			_, err = services.WebhooksFor(&services.WebhookServiceImpl{DB: conn}, "t2").GetAllSubscriptions()
+			Expect(err).To(MatchError(sql.ErrConnDone))
+			conn.AssertExpectations(GinkgoT())
+		})
+
+		It("should only queue an event for the subscriptions of its tenant", func() {
+			sink := &services.WebhookSink{Webhooks: &services.WebhookServiceImpl{DB: conn}}
+			conn.On("ExecContext", mock.Anything, mock.Anything, services.EventCoffeeUpdated, mock.Anything, mock.Anything, mock.Anything, "t2").
+				Return(driver.RowsAffected(1), nil).Once()
+			conn.On("ExecContext", mock.Anything, mock.Anything, services.EventCoffeeUpdated, mock.Anything, mock.Anything, mock.Anything, services.DefaultTenant).
+				Return(driver.RowsAffected(1), nil).Once()
+
+			payload, _ := json.Marshal(map[string]string{"id": "c1", "tenant_id": "t2"})
+			Expect(sink.Publish(context.Background(), events.Event{Type: services.EventCoffeeUpdated, Payload: payload})).To(Succeed())
+			// The events from before the tenants name none
+			Expect(sink.Publish(context.Background(), events.Event{Type: services.EventCoffeeUpdated, Payload: json.RawMessage(`{"id":"c1"}`)})).To(Succeed())
+			conn.AssertExpectations(GinkgoT())
+		})
+	})
+
+	Describe("Streams", func() {
+		event := func(id int64, tenantID string) events.Event {
+			payload, _ := json.Marshal(map[string]string{"id": "c1", "tenant_id": tenantID})
+			return events.Event{ID: id, AggregateType: services.AggregateCoffee, Type: services.EventCoffeeUpdated, Payload: payload}
+		}
+
+		It("should only stream the changes of the tenant", func() {
+			bus := events.NewMemoryBus(8)
+			stream := services.StreamFor(&services.CoffeeStreamImpl{Bus: bus}, "t1")
+			changes, unsubscribe := stream.Subscribe()
+			defer unsubscribe()
+
+			Expect(bus.Publish(context.Background(), event(1, "t2"))).To(Succeed())
+			Expect(bus.Publish(context.Background(), event(2, "t1"))).To(Succeed())
+			Eventually(changes).Should(Receive(HaveField("ID", int64(2))))
+			Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should give the changes from before the tenants to the default tenant", func() {
+			memory := services.NewMemoryCoffeeService(events.NewMemoryBus(8))
+			_, err := memory.CreateCoffee(services.Coffee{Name: "Espresso"})
+			Expect(err).NotTo(HaveOccurred())
+
+			missed, err := services.StreamFor(memory, "").Replay(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed).To(HaveLen(1))
+			missed, err = services.StreamFor(memory, "t1").Replay(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missed).To(BeEmpty())
+		})
+	})
+})
+
+// scopedCatalog hands out the catalog of each tenant like CoffeeServiceImpl does
+type scopedCatalog struct {
+	services.CoffeeService
+	tenants map[string]services.CoffeeService
+}
+
+func (s *scopedCatalog) ForTenant(tenantID string) services.CoffeeService {
+	return s.tenants[tenantID]
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/events"
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultTxRetries   = 3
+	defaultTxRetryWait = 20 * time.Millisecond
+)
+
+// TxManager runs units of work: a function using the services in a single transaction, so what it
+// changes through several services is committed, or rolled back, as a whole. The transactions the
+// services begin themselves become savepoints of the unit of work.
+//
+// A unit of work that fails with a serialization failure or a deadlock is run again, up to Retries
+// times, so the function must not have effects outside of the transaction it can't repeat.
+type TxManager struct {
+	DB        db.Querier
+	Isolation sql.IsolationLevel
+	Retries   int
+	RetryWait time.Duration
+
+	// scope returns the services running their queries on the transaction
+	scope func(tx db.Querier) Models
+}
+
+func NewTxManager(dbPool db.Querier, scope func(tx db.Querier) Models) *TxManager {
+	return &TxManager{
+		DB:        dbPool,
+		Isolation: sql.LevelDefault,
+		Retries:   defaultTxRetries,
+		RetryWait: defaultTxRetryWait,
+		scope:     scope,
+	}
+}
+
+// Do runs fn in a transaction at the isolation level of the manager, it is committed when fn returns nil
+func (t *TxManager) Do(ctx context.Context, fn func(tx Models) error) error {
+	return t.DoWithIsolation(ctx, t.Isolation, fn)
+}
+
+// DoWithIsolation runs fn in a transaction at the given isolation level
+func (t *TxManager) DoWithIsolation(ctx context.Context, isolation sql.IsolationLevel, fn func(tx Models) error) error {
+	for attempt := 0; ; attempt++ {
+		err := t.attempt(ctx, isolation, fn)
+		if err == nil || !db.IsSerializationFailure(err) || attempt >= t.Retries {
+			return err
+		}
+
+		// The conflicting transactions back off by different times so they don't collide again
+		wait := t.RetryWait << attempt
+		wait += time.Duration(rand.Int63n(int64(wait) + 1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *TxManager) attempt(ctx context.Context, isolation sql.IsolationLevel, fn func(tx Models) error) error {
+	tx, err := t.DB.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		return err
+	}
+
+	committed := false
+	defer func() {
+		// Also ends the transaction when fn panics
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(t.scope(db.InTx(tx))); err != nil {
+		return err
+	}
+	committed = true
+	return tx.Commit()
+}
+
+// txModels returns the services of a unit of work. The stream and the outbox relay aren't part of it,
+// the events the services write to the outbox are only relayed once the unit of work is committed.
+// Neither are the payments nor the webhooks: they call out to the provider and the subscribers, which
+// a unit of work run again after a serialization failure would do twice.
+func txModels(tx db.Querier, bus *events.MemoryBus) Models {
+	orders := &OrderServiceImpl{DB: tx}
+	carts := &CartServiceImpl{DB: tx}
+
+	return Models{
+		Coffee:       &CoffeeServiceImpl{DB: tx},
+		Order:        orders,
+		Cart:         carts,
+		Promotion:    &PromotionServiceImpl{DB: tx, Carts: carts},
+		Tenant:       &TenantServiceImpl{DB: tx},
+		Translation:  &TranslationServiceImpl{DB: tx},
+		Events:       bus,
+		JsonResponse: JsonResponse{},
+	}
+}
+
+// TxOrderService places and moves the orders in units of work, so an order conflicting with another one at
+// the isolation level of the manager is written again instead of failing. The reads go to Next.
+type TxOrderService struct {
+	OrderService
+	Tx *TxManager
+	// Tenant is the shop whose coffees are ordered, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantOrders = (*TxOrderService)(nil)
+
+func NewTxOrderService(next OrderService, tx *TxManager) *TxOrderService {
+	return &TxOrderService{OrderService: next, Tx: tx}
+}
+
+// ForTenant returns the orders of the tenant placed in units of work of the same manager
+func (s *TxOrderService) ForTenant(tenantID string) OrderService {
+	return &TxOrderService{OrderService: OrdersFor(s.OrderService, tenantID), Tx: s.Tx, Tenant: tenantID}
+}
+
+func (s *TxOrderService) CreateOrder(order Order) (*Order, error) {
+	return s.inTx(func(orders OrderService) (*Order, error) {
+		return orders.CreateOrder(order)
+	})
+}
+
+func (s *TxOrderService) CancelOrder(id string) (*Order, error) {
+	return s.inTx(func(orders OrderService) (*Order, error) {
+		return orders.CancelOrder(id)
+	})
+}
+
+func (s *TxOrderService) TransitionOrder(id string, status string) (*Order, error) {
+	return s.inTx(func(orders OrderService) (*Order, error) {
+		return orders.TransitionOrder(id, status)
+	})
+}
+
+// inTx runs the write on the orders of the tenant in a unit of work, again when it conflicted
+func (s *TxOrderService) inTx(write func(orders OrderService) (*Order, error)) (*Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var written *Order
+	err := s.Tx.Do(ctx, func(tx Models) error {
+		var err error
+		written, err = write(OrdersFor(tx.Order, s.Tenant))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
@@ -0,0 +1,197 @@
+package services_test
+
+import (
+	database "coffee/coffee-server/db"
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/payments"
+	"coffee/coffee-server/services"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Units of work", Label("unit"), func() {
+	var (
+		conn    *mocks.DBInterface
+		tx      *mocks.Tx
+		manager *services.TxManager
+	)
+
+	serializable := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	conflict := &pgconn.PgError{Code: database.SerializationFailure}
+
+	BeforeEach(func() {
+		conn = &mocks.DBInterface{}
+		tx = &mocks.Tx{}
+		manager = services.New(conn).Tx
+		manager.RetryWait = time.Millisecond
+	})
+
+	AfterEach(func() {
+		conn.AssertExpectations(GinkgoT())
+		tx.AssertExpectations(GinkgoT())
+	})
+
+	It("commits what the services did in the transaction", func() {
+		conn.On("BeginTx", mock.Anything, &sql.TxOptions{Isolation: sql.LevelDefault}).Return(tx, nil).Once()
+		tx.On("ExecContext", mock.Anything, "SAVEPOINT sp_1").Return(nil, nil).Once()
+		tx.On("ExecContext", mock.Anything, `DELETE FROM coffees WHERE id = $1 AND tenant_id = $2`, "42", services.DefaultTenant).Return(driver.RowsAffected(0), nil).Once()
+		tx.On("ExecContext", mock.Anything, "RELEASE SAVEPOINT sp_1").Return(nil, nil).Once()
+		tx.On("Commit").Return(nil).Once()
+
+		err := manager.Do(context.Background(), func(m services.Models) error {
+			Expect(m.Tx).To(BeNil())
+			return m.Coffee.DeleteCoffee("42")
+		})
+		Expect(err).NotTo(HaveOccurred())
+		tx.AssertNotCalled(GinkgoT(), "Rollback")
+	})
+
+	It("rolls back the transaction when the unit of work fails", func() {
+		conn.On("BeginTx", mock.Anything, serializable).Return(tx, nil).Once()
+		tx.On("Rollback").Return(nil).Once()
+
+		err := manager.DoWithIsolation(context.Background(), sql.LevelSerializable, func(m services.Models) error {
+			return services.ErrOrderNotFound
+		})
+		Expect(err).To(MatchError(services.ErrOrderNotFound))
+		tx.AssertNotCalled(GinkgoT(), "Commit")
+	})
+
+	It("rolls back the transaction when the unit of work panics", func() {
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil).Once()
+		tx.On("Rollback").Return(nil).Once()
+
+		Expect(func() {
+			manager.Do(context.Background(), func(m services.Models) error {
+				panic("out of beans")
+			})
+		}).To(PanicWith("out of beans"))
+	})
+
+	It("runs the unit of work again after a serialization failure", func() {
+		manager.Isolation = sql.LevelSerializable
+		conn.On("BeginTx", mock.Anything, serializable).Return(tx, nil).Times(2)
+		tx.On("Commit").Return(conflict).Once()
+		tx.On("Commit").Return(nil).Once()
+
+		runs := 0
+		err := manager.Do(context.Background(), func(m services.Models) error {
+			runs++
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs).To(Equal(2))
+	})
+
+	It("gives up after the retries", func() {
+		manager.Retries = 2
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil).Times(3)
+		tx.On("Rollback").Return(nil).Times(3)
+
+		runs := 0
+		err := manager.Do(context.Background(), func(m services.Models) error {
+			runs++
+			return conflict
+		})
+		Expect(database.IsSerializationFailure(err)).To(BeTrue())
+		Expect(runs).To(Equal(3))
+	})
+
+	It("leaves the payments and the webhooks out of the unit of work", func() {
+		manager = services.New(conn).WithPaymentProvider(conn, payments.NewFakeProvider("secret")).Tx
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil).Once()
+		tx.On("Commit").Return(nil).Once()
+
+		err := manager.Do(context.Background(), func(m services.Models) error {
+			Expect(m.Payment).To(BeNil())
+			Expect(m.Webhook).To(BeNil())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("places the orders in a unit of work", func() {
+		orders := services.New(conn).WithOrdersInTx().Order
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil).Once()
+		tx.On("Rollback").Return(nil).Once()
+
+		_, err := services.OrdersFor(orders, "tenant-2").CreateOrder(services.Order{})
+		Expect(err).To(MatchError(services.ErrOrderEmpty))
+	})
+
+	It("moves the orders in a unit of work", func() {
+		orders := services.New(conn).WithOrdersInTx().Order
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(tx, nil).Twice()
+		tx.On("ExecContext", mock.Anything, "SAVEPOINT sp_1").Return(nil, nil).Twice()
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(sql.ErrNoRows)
+		tx.On("QueryRowContext", mock.Anything, mock.Anything, "o1", "tenant-2").Return(row).Twice()
+		tx.On("ExecContext", mock.Anything, "ROLLBACK TO SAVEPOINT sp_1").Return(nil, nil).Twice()
+		tx.On("Rollback").Return(nil).Twice()
+
+		_, err := services.OrdersFor(orders, "tenant-2").CancelOrder("o1")
+		Expect(err).To(MatchError(services.ErrOrderNotFound))
+		_, err = services.OrdersFor(orders, "tenant-2").TransitionOrder("o1", services.OrderStatusPaid)
+		Expect(err).To(MatchError(services.ErrOrderNotFound))
+	})
+
+	It("doesn't run the unit of work again after other errors", func() {
+		conn.On("BeginTx", mock.Anything, mock.Anything).Return(nil, errors.New("too many connections")).Once()
+
+		err := manager.Do(context.Background(), func(m services.Models) error {
+			Fail("the unit of work ran without a transaction")
+			return nil
+		})
+		Expect(err).To(MatchError("too many connections"))
+	})
+})
+
+var _ = Describe("Units of work on Postgres", Label("integration"), func() {
+	BeforeEach(func() {
+		_, err := db.Exec("DELETE FROM coffees")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rolls back the changes of every service together", func() {
+		models := services.New(conn)
+
+		err := models.Tx.Do(context.Background(), func(m services.Models) error {
+			if _, err := m.Coffee.CreateCoffee(services.Coffee{Name: "Espresso", Roast: "Dark", Region: "Brazil", Price: 10, GrindUnit: 1}); err != nil {
+				return err
+			}
+			_, err := m.Order.TransitionOrder("00000000-0000-0000-0000-000000000000", services.OrderStatusPaid)
+			return err
+		})
+		Expect(err).To(HaveOccurred())
+
+		coffees, err := models.Coffee.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffees).To(BeEmpty())
+	})
+
+	It("keeps a transaction going after a service rolled back its own part", func() {
+		models := services.New(conn)
+
+		err := models.Tx.Do(context.Background(), func(m services.Models) error {
+			if _, err := m.Coffee.CreateCoffee(services.Coffee{Name: "Espresso", Roast: "Dark", Region: "Brazil", Price: 10, GrindUnit: 1}); err != nil {
+				return err
+			}
+			_, err := m.Order.TransitionOrder("00000000-0000-0000-0000-000000000000", services.OrderStatusPaid)
+			Expect(err).To(HaveOccurred())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		coffees, err := models.Coffee.GetAllCoffees()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(coffees).To(HaveLen(1))
+	})
+})
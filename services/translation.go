@@ -0,0 +1,486 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTranslationNotFound = errors.New("translation not found")
+	ErrInvalidTranslation  = errors.New("invalid translation")
+)
+
+// SourceLanguage is the language the catalog is written in, the last one of every language chain
+const SourceLanguage = "en"
+
+// DefaultLanguages are the languages the catalog is served in unless WithLanguages sets others
+var DefaultLanguages = []string{SourceLanguage, "nl"}
+
+// The kinds of labels, the values of a coffee translated through a shared table
+const (
+	LabelRoast  = "roast"
+	LabelRegion = "region"
+)
+
+// languageTag is a BCP 47 tag once normalized, e.g. nl or nl-be
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLanguage returns the tag in the form the translations are kept in, nl-be for nl_BE, "" when
+// it isn't a language tag
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if !languageTag.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// LanguageChain returns the languages to look the translations up in, most preferred first: each of the
+// requested languages followed by its base language, then the fallbacks the same way. nl-BE, en gives
+// nl-be, nl, en. The tags that aren't languages are left out.
+func LanguageChain(requested []string, fallbacks ...string) []string {
+	var chain []string
+	add := func(tag string) {
+		if !slices.Contains(chain, tag) {
+			chain = append(chain, tag)
+		}
+	}
+	for _, tag := range slices.Concat(requested, fallbacks) {
+		tag = NormalizeLanguage(tag)
+		if tag == "" {
+			continue
+		}
+		add(tag)
+		if base, _, ok := strings.Cut(tag, "-"); ok {
+			add(base)
+		}
+	}
+	return chain
+}
+
+// Translation is a coffee in another language, an empty field falls back on the next language of the chain
+type Translation struct {
+	CoffeeID    string    `json:"coffee_id"`
+	Lang        string    `json:"lang"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Label is a roast or a region in another language, shared by the coffees of the tenant with that value
+type Label struct {
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Lang      string    `json:"lang"`
+	Label     string    `json:"label"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MissingTranslation is a coffee or a label the catalog can't be served with in a language yet
+type MissingTranslation struct {
+	Lang string `json:"lang"`
+	// Kind is "coffee", or the kind of the label
+	Kind string `json:"kind"`
+	// Key is the id of the coffee, or the value of the label
+	Key string `json:"key"`
+	// Name is the name of the coffee in the source language
+	Name string `json:"name,omitempty"`
+	// Fields are the fields of the coffee left to translate
+	Fields []string `json:"fields,omitempty"`
+}
+
+type TranslationService interface {
+	// Localize translates the coffees in place into the first language of the chain they are translated
+	// in, and returns the language of the response
+	Localize(coffees []*Coffee, langs []string) (string, error)
+	GetTranslations(coffeeId string) ([]*Translation, error)
+	PutTranslation(translation Translation) (*Translation, error)
+	DeleteTranslation(coffeeId string, lang string) error
+	GetLabels(lang string) ([]*Label, error)
+	PutLabel(label Label) (*Label, error)
+	DeleteLabel(kind string, value string, lang string) error
+	// MissingTranslations reports what is left to translate in the languages, all the served ones but
+	// the source language when none are given
+	MissingTranslations(langs []string) ([]*MissingTranslation, error)
+}
+
+// TenantTranslations is implemented by the translations kept per tenant
+type TenantTranslations interface {
+	ForTenant(tenantID string) TranslationService
+}
+
+// TranslationsFor returns the translations of the tenant
+func TranslationsFor(translations TranslationService, tenantID string) TranslationService {
+	if scoped, ok := translations.(TenantTranslations); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return translations
+}
+
+// TranslationsFromContext returns the translations of the tenant of the request, the default one when none was resolved
+func TranslationsFromContext(ctx context.Context, translations TranslationService) TranslationService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return TranslationsFor(translations, tenant.ID)
+	}
+	return translations
+}
+
+// Concrete implementation of TranslationService
+type TranslationServiceImpl struct {
+	DB db.Querier
+	// Languages are the languages the catalog is served in, DefaultLanguages when empty. The source
+	// language is always served.
+	Languages []string
+	// Tenant is the shop of the translations, DefaultTenant when empty
+	Tenant string
+}
+
+var _ TenantTranslations = (*TranslationServiceImpl)(nil)
+
+// ForTenant returns the translations of the tenant on the same database
+func (s *TranslationServiceImpl) ForTenant(tenantID string) TranslationService {
+	return &TranslationServiceImpl{DB: s.DB, Languages: s.Languages, Tenant: tenantID}
+}
+
+func (s *TranslationServiceImpl) tenant() string {
+	if s.Tenant != "" {
+		return s.Tenant
+	}
+	return DefaultTenant
+}
+
+func (s *TranslationServiceImpl) served(lang string) bool {
+	languages := s.Languages
+	if len(languages) == 0 {
+		languages = DefaultLanguages
+	}
+	return lang == SourceLanguage || slices.Contains(languages, lang)
+}
+
+// chain keeps the served languages of langs, up to the source language which ends it
+func (s *TranslationServiceImpl) chain(langs []string) []string {
+	var chain []string
+	for _, lang := range LanguageChain(langs, SourceLanguage) {
+		if s.served(lang) {
+			chain = append(chain, lang)
+		}
+		if lang == SourceLanguage {
+			break
+		}
+	}
+	return chain
+}
+
+func (s *TranslationServiceImpl) language(lang string) (string, error) {
+	normalized := NormalizeLanguage(lang)
+	if normalized == "" || !s.served(normalized) {
+		return "", fmt.Errorf("%w: language %q isn't served", ErrInvalidTranslation, lang)
+	}
+	return normalized, nil
+}
+
+func (s *TranslationServiceImpl) Localize(coffees []*Coffee, langs []string) (string, error) {
+	chain := s.chain(langs)
+	if len(coffees) == 0 {
+		return chain[0], nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	ids := make([]string, len(coffees))
+	for i, coffee := range coffees {
+		ids[i] = coffee.ID
+	}
+
+	// translations[lang][coffee id], labels[lang][kind + value]
+	translations := map[string]map[string]Translation{}
+	rows, err := s.DB.QueryContext(ctx, `SELECT coffee_id, lang, name, description FROM coffee_translations WHERE coffee_id::text = ANY($1) AND lang = ANY($2)`, ids, chain)
+	if err != nil {
+		return SourceLanguage, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t Translation
+		if err := rows.Scan(&t.CoffeeID, &t.Lang, &t.Name, &t.Description); err != nil {
+			return SourceLanguage, err
+		}
+		if translations[t.Lang] == nil {
+			translations[t.Lang] = map[string]Translation{}
+		}
+		translations[t.Lang][t.CoffeeID] = t
+	}
+	if err := rows.Err(); err != nil {
+		return SourceLanguage, err
+	}
+
+	labels := map[string]map[string]string{}
+	labelRows, err := s.DB.QueryContext(ctx, `SELECT kind, value, lang, label FROM label_translations WHERE tenant_id = $1 AND lang = ANY($2)`, s.tenant(), chain)
+	if err != nil {
+		return SourceLanguage, err
+	}
+	defer labelRows.Close()
+	for labelRows.Next() {
+		var l Label
+		if err := labelRows.Scan(&l.Kind, &l.Value, &l.Lang, &l.Label); err != nil {
+			return SourceLanguage, err
+		}
+		if labels[l.Lang] == nil {
+			labels[l.Lang] = map[string]string{}
+		}
+		labels[l.Lang][l.Kind+":"+l.Value] = l.Label
+	}
+	if err := labelRows.Err(); err != nil {
+		return SourceLanguage, err
+	}
+
+	// The first language with a translation wins, field by field
+	pick := func(fallback string, translated func(lang string) string) string {
+		for _, lang := range chain {
+			if value := translated(lang); value != "" {
+				return value
+			}
+		}
+		return fallback
+	}
+	for _, coffee := range coffees {
+		id := coffee.ID
+		coffee.Name = pick(coffee.Name, func(lang string) string { return translations[lang][id].Name })
+		coffee.Description = pick(coffee.Description, func(lang string) string { return translations[lang][id].Description })
+		roast, region := LabelRoast+":"+coffee.Roast, LabelRegion+":"+coffee.Region
+		coffee.Roast = pick(coffee.Roast, func(lang string) string { return labels[lang][roast] })
+		coffee.Region = pick(coffee.Region, func(lang string) string { return labels[lang][region] })
+	}
+	return chain[0], nil
+}
+
+func (s *TranslationServiceImpl) GetTranslations(coffeeId string) ([]*Translation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT t.coffee_id, t.lang, t.name, t.description, t.updated_at FROM coffee_translations t
+		JOIN coffees c ON c.id = t.coffee_id
+		WHERE t.coffee_id = $1 AND c.tenant_id = $2 ORDER BY t.lang`
+
+	rows, err := s.DB.QueryContext(ctx, query, coffeeId, s.tenant())
+	if err != nil {
+		// An id that isn't a UUID can't name a coffee
+		if db.SQLState(err) == db.InvalidText {
+			return nil, ErrCoffeeNotFound
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*Translation{}
+	for rows.Next() {
+		var t Translation
+		if err := rows.Scan(&t.CoffeeID, &t.Lang, &t.Name, &t.Description, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		translations = append(translations, &t)
+	}
+	return translations, rows.Err()
+}
+
+func (s *TranslationServiceImpl) PutTranslation(translation Translation) (*Translation, error) {
+	lang, err := s.language(translation.Lang)
+	if err != nil {
+		return nil, err
+	}
+	if translation.Name == "" && translation.Description == "" {
+		return nil, fmt.Errorf("%w: name or description is required", ErrInvalidTranslation)
+	}
+	translation.Lang = lang
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	translation.UpdatedAt = time.Now()
+
+	// Selecting the coffee keeps the translations to the coffees of the tenant
+	query := `INSERT INTO coffee_translations(coffee_id, lang, name, description, created_at, updated_at)
+		SELECT id, $2, $3, $4, $5, $5 FROM coffees WHERE id = $1 AND tenant_id = $6
+		ON CONFLICT (coffee_id, lang) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = EXCLUDED.updated_at
+		RETURNING coffee_id`
+
+	err = s.DB.QueryRowContext(ctx, query, translation.CoffeeID, translation.Lang, translation.Name, translation.Description, translation.UpdatedAt, s.tenant()).Scan(&translation.CoffeeID)
+	if errors.Is(err, sql.ErrNoRows) || db.SQLState(err) == db.InvalidText {
+		return nil, ErrCoffeeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (s *TranslationServiceImpl) DeleteTranslation(coffeeId string, lang string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `DELETE FROM coffee_translations t USING coffees c WHERE c.id = t.coffee_id AND t.coffee_id = $1 AND t.lang = $2 AND c.tenant_id = $3`
+
+	res, err := s.DB.ExecContext(ctx, query, coffeeId, NormalizeLanguage(lang), s.tenant())
+	if db.SQLState(err) == db.InvalidText {
+		return ErrTranslationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+func (s *TranslationServiceImpl) GetLabels(lang string) ([]*Label, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT kind, value, lang, label, updated_at FROM label_translations
+		WHERE tenant_id = $1 AND ($2 = '' OR lang = $2) ORDER BY kind, value, lang`
+
+	rows, err := s.DB.QueryContext(ctx, query, s.tenant(), NormalizeLanguage(lang))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []*Label{}
+	for rows.Next() {
+		var l Label
+		if err := rows.Scan(&l.Kind, &l.Value, &l.Lang, &l.Label, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		labels = append(labels, &l)
+	}
+	return labels, rows.Err()
+}
+
+func (s *TranslationServiceImpl) PutLabel(label Label) (*Label, error) {
+	if label.Kind != LabelRoast && label.Kind != LabelRegion {
+		return nil, fmt.Errorf("%w: kind must be %q or %q", ErrInvalidTranslation, LabelRoast, LabelRegion)
+	}
+	if label.Value == "" || label.Label == "" {
+		return nil, fmt.Errorf("%w: value and label are required", ErrInvalidTranslation)
+	}
+	lang, err := s.language(label.Lang)
+	if err != nil {
+		return nil, err
+	}
+	label.Lang = lang
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	label.UpdatedAt = time.Now()
+
+	query := `INSERT INTO label_translations(tenant_id, kind, value, lang, label, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (tenant_id, kind, value, lang) DO UPDATE SET label = EXCLUDED.label, updated_at = EXCLUDED.updated_at`
+
+	if _, err := s.DB.ExecContext(ctx, query, s.tenant(), label.Kind, label.Value, label.Lang, label.Label, label.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (s *TranslationServiceImpl) DeleteLabel(kind string, value string, lang string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `DELETE FROM label_translations WHERE tenant_id = $1 AND kind = $2 AND value = $3 AND lang = $4`
+
+	res, err := s.DB.ExecContext(ctx, query, s.tenant(), kind, value, NormalizeLanguage(lang))
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+func (s *TranslationServiceImpl) MissingTranslations(langs []string) ([]*MissingTranslation, error) {
+	if len(langs) == 0 {
+		langs = s.Languages
+		if len(langs) == 0 {
+			langs = DefaultLanguages
+		}
+		langs = slices.DeleteFunc(slices.Clone(langs), func(lang string) bool { return lang == SourceLanguage })
+	}
+	normalized := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		lang, err := s.language(lang)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, lang)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	coffees := `SELECT c.id, c.name, l.lang, COALESCE(t.name, ''), COALESCE(t.description, '')
+		FROM coffees c CROSS JOIN unnest($2::text[]) AS l(lang)
+		LEFT JOIN coffee_translations t ON t.coffee_id = c.id AND t.lang = l.lang
+		WHERE c.tenant_id = $1 AND (t.coffee_id IS NULL OR t.name = '' OR t.description = '')
+		ORDER BY l.lang, c.name`
+
+	rows, err := s.DB.QueryContext(ctx, coffees, s.tenant(), normalized)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missing := []*MissingTranslation{}
+	for rows.Next() {
+		m := MissingTranslation{Kind: "coffee"}
+		var name, description string
+		if err := rows.Scan(&m.Key, &m.Name, &m.Lang, &name, &description); err != nil {
+			return nil, err
+		}
+		if name == "" {
+			m.Fields = append(m.Fields, "name")
+		}
+		if description == "" {
+			m.Fields = append(m.Fields, "description")
+		}
+		missing = append(missing, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	labels := `SELECT v.kind, v.value, l.lang FROM (
+			SELECT DISTINCT 'roast' AS kind, roast AS value FROM coffees WHERE tenant_id = $1 AND roast <> ''
+			UNION SELECT DISTINCT 'region', region FROM coffees WHERE tenant_id = $1 AND region <> ''
+		) v CROSS JOIN unnest($2::text[]) AS l(lang)
+		WHERE NOT EXISTS (SELECT 1 FROM label_translations t WHERE t.tenant_id = $1 AND t.kind = v.kind AND t.value = v.value AND t.lang = l.lang)
+		ORDER BY l.lang, v.kind, v.value`
+
+	labelRows, err := s.DB.QueryContext(ctx, labels, s.tenant(), normalized)
+	if err != nil {
+		return nil, err
+	}
+	defer labelRows.Close()
+
+	for labelRows.Next() {
+		var m MissingTranslation
+		if err := labelRows.Scan(&m.Kind, &m.Key, &m.Lang); err != nil {
+			return nil, err
+		}
+		missing = append(missing, &m)
+	}
+	return missing, labelRows.Err()
+}
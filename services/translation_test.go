@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"database/sql"
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+// rowsOf returns rows scanning the given values, one slice per row
+type rowsOf [][]interface{}
+
+func (r *rowsOf) Next() bool {
+	return len(*r) > 0
+}
+
+func (r *rowsOf) Scan(dest ...interface{}) error {
+	for i, value := range (*r)[0] {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	*r = (*r)[1:]
+	return nil
+}
+
+func (r *rowsOf) Err() error { return nil }
+func (r *rowsOf) Close()     {}
+
+var _ = Describe("Translations", Label("unit"), func() {
+	var (
+		conn         *mocks.DBInterface
+		translations *services.TranslationServiceImpl
+	)
+
+	BeforeEach(func() {
+		conn = &mocks.DBInterface{}
+		translations = &services.TranslationServiceImpl{DB: conn}
+	})
+
+	It("should follow each requested language with its base language, then the fallbacks", func() {
+		Expect(services.LanguageChain([]string{"nl_BE", "not a tag", "en-GB"}, "nl", "")).To(Equal([]string{"nl-be", "nl", "en-gb", "en"}))
+		Expect(services.LanguageChain(nil, "de-AT")).To(Equal([]string{"de-at", "de"}))
+	})
+
+	It("should translate field by field from the first served language with a translation", func() {
+		coffees := []*services.Coffee{
+			{ID: "c1", Name: "Morning", Roast: "Dark", Region: "Brazil"},
+			{ID: "c2", Name: "Evening", Roast: "Light", Region: "Kenya"},
+		}
+		chain := []string{"nl", "en"}
+		conn.On("QueryContext", mock.Anything, mock.Anything, []string{"c1", "c2"}, chain).Return(&rowsOf{
+			{"c1", "nl", "Ochtend", ""},
+			{"c1", "en", "", "Dark and sweet"},
+		}, nil).Once()
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DefaultTenant, chain).Return(&rowsOf{
+			{"roast", "Dark", "nl", "Donker"},
+		}, nil).Once()
+
+		// German isn't served, it is left out of the chain
+		lang, err := translations.Localize(coffees, []string{"de", "nl-BE"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lang).To(Equal("nl"))
+		Expect(coffees[0]).To(And(HaveField("Name", "Ochtend"), HaveField("Description", "Dark and sweet"), HaveField("Roast", "Donker"), HaveField("Region", "Brazil")))
+		Expect(coffees[1]).To(And(HaveField("Name", "Evening"), HaveField("Description", ""), HaveField("Roast", "Light")))
+		conn.AssertExpectations(GinkgoT())
+	})
+
+	It("should serve the source language when nothing served is asked for", func() {
+		lang, err := translations.Localize(nil, []string{"fr"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lang).To(Equal(services.SourceLanguage))
+		conn.AssertNotCalled(GinkgoT(), "QueryContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("should refuse a translation it can't serve before writing it", func() {
+		_, err := translations.PutTranslation(services.Translation{CoffeeID: "c1", Lang: "fr", Name: "Matin"})
+		Expect(err).To(MatchError(services.ErrInvalidTranslation))
+		_, err = translations.PutTranslation(services.Translation{CoffeeID: "c1", Lang: "nl"})
+		Expect(err).To(MatchError(services.ErrInvalidTranslation))
+		_, err = translations.PutLabel(services.Label{Kind: "grind", Value: "Fine", Lang: "nl", Label: "Fijn"})
+		Expect(err).To(MatchError(services.ErrInvalidTranslation))
+		conn.AssertNotCalled(GinkgoT(), "QueryRowContext", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("should not translate a coffee of another tenant", func() {
+		row := &mocks.Row{}
+		row.On("Scan", mock.Anything).Return(sql.ErrNoRows)
+		conn.On("QueryRowContext", mock.Anything, mock.Anything, "c1", "nl", "Ochtend", "", mock.Anything, "t2").Return(row)
+
+		_, err := translations.ForTenant("t2").PutTranslation(services.Translation{CoffeeID: "c1", Lang: "NL", Name: "Ochtend"})
+		Expect(err).To(MatchError(services.ErrCoffeeNotFound))
+	})
+
+	It("should report the coffees and labels left to translate in the served languages", func() {
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DefaultTenant, []string{"nl"}).Return(&rowsOf{
+			{"c1", "Morning", "nl", "Ochtend", ""},
+		}, nil).Once()
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DefaultTenant, []string{"nl"}).Return(&rowsOf{
+			{"region", "Brazil", "nl"},
+		}, nil).Once()
+
+		missing, err := translations.MissingTranslations(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(missing).To(Equal([]*services.MissingTranslation{
+			{Lang: "nl", Kind: "coffee", Key: "c1", Name: "Morning", Fields: []string{"description"}},
+			{Lang: "nl", Kind: "region", Key: "Brazil"},
+		}))
+	})
+})
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"coffee/coffee-server/db"
+	"coffee/coffee-server/webhooks"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	EventCoffeeCreated = "coffee.created"
+	EventCoffeeUpdated = "coffee.updated"
+	EventCoffeeDeleted = "coffee.deleted"
+	// EventAll subscribes to every event type
+	EventAll = "*"
+
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+
+	// maxDeliveryAttempts is how often a delivery is tried before it moves to the dead-letter list
+	maxDeliveryAttempts = 8
+	// deliveryLease is how long a claimed delivery is hidden from other dispatchers
+	deliveryLease = time.Minute
+)
+
+var CatalogEvents = []string{EventCoffeeCreated, EventCoffeeUpdated, EventCoffeeDeleted}
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+)
+
+type WebhookSubscription struct {
+	ID         string    `json:"id,omitempty"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookEvent is the body posted to subscribers
+type WebhookEvent struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+type WebhookService interface {
+	CreateSubscription(subscription WebhookSubscription) (*WebhookSubscription, error)
+	GetAllSubscriptions() ([]*WebhookSubscription, error)
+	DeleteSubscription(id string) error
+	Enqueue(eventType string, data interface{}) error
+	GetDeliveries(subscriptionId string) ([]*WebhookDelivery, error)
+	GetDeadDeliveries() ([]*WebhookDelivery, error)
+	Redeliver(deliveryId string) (*WebhookDelivery, error)
+	DispatchDue(limit int) (int, error)
+}
+
+// TenantWebhooks is implemented by the webhooks subscribed per tenant
+type TenantWebhooks interface {
+	ForTenant(tenantID string) WebhookService
+}
+
+// WebhooksFor returns the webhooks of the tenant
+func WebhooksFor(webhooks WebhookService, tenantID string) WebhookService {
+	if scoped, ok := webhooks.(TenantWebhooks); ok && tenantID != "" {
+		return scoped.ForTenant(tenantID)
+	}
+	return webhooks
+}
+
+// WebhooksFromContext returns the webhooks of the tenant of the request, the default one when none was resolved
+func WebhooksFromContext(ctx context.Context, webhooks WebhookService) WebhookService {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return WebhooksFor(webhooks, tenant.ID)
+	}
+	return webhooks
+}
+
+// Concrete implementation of WebhookService
+type WebhookServiceImpl struct {
+	DB     db.Querier
+	Sender *webhooks.Sender
+	// Tenant is the shop of the subscriptions, they only get its events. It is DefaultTenant when empty.
+	Tenant string
+}
+
+var _ TenantWebhooks = (*WebhookServiceImpl)(nil)
+
+// ForTenant returns the webhooks of the tenant, sent by the same sender
+func (s *WebhookServiceImpl) ForTenant(tenantID string) WebhookService {
+	return &WebhookServiceImpl{DB: s.DB, Sender: s.Sender, Tenant: tenantID}
+}
+
+func (s *WebhookServiceImpl) tenant() string {
+	if s.Tenant != "" {
+		return s.Tenant
+	}
+	return DefaultTenant
+}
+
+const deliveryColumns = `id, subscription_id, event_type, payload, status, attempts, last_error, response_status,
+	next_attempt_at, delivered_at, created_at, updated_at`
+
+func (s *WebhookServiceImpl) CreateSubscription(subscription WebhookSubscription) (*WebhookSubscription, error) {
+	parsed, err := url.Parse(subscription.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidSubscription)
+	}
+	if len(subscription.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidSubscription)
+	}
+	for _, eventType := range subscription.EventTypes {
+		if eventType != EventAll && !contains(CatalogEvents, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, eventType)
+		}
+	}
+	if subscription.Secret == "" {
+		subscription.Secret, err = webhooks.NewSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	eventTypes, _ := json.Marshal(subscription.EventTypes)
+	now := time.Now()
+	subscription.Active = true
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	query := `INSERT INTO webhook_subscriptions(url, event_types, secret, active, created_at, updated_at, tenant_id) VALUES ($1, $2::jsonb, $3, $4, $5, $6, $7) returning id`
+
+	err = s.DB.QueryRowContext(ctx, query, subscription.URL, string(eventTypes), subscription.Secret, subscription.Active, now, now, s.tenant()).Scan(&subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret is only handed out once, when the subscription is created
+	return &subscription, nil
+}
+
+func (s *WebhookServiceImpl) GetAllSubscriptions() ([]*WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT id, url, event_types::text, active, created_at, updated_at FROM webhook_subscriptions WHERE tenant_id = $1 ORDER BY created_at`
+
+	rows, err := s.DB.QueryContext(ctx, query, s.tenant())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []*WebhookSubscription{}
+	for rows.Next() {
+		var subscription WebhookSubscription
+		var eventTypes string
+
+		err := rows.Scan(
+			&subscription.ID,
+			&subscription.URL,
+			&eventTypes,
+			&subscription.Active,
+			&subscription.CreatedAt,
+			&subscription.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookServiceImpl) DeleteSubscription(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $2`, id, s.tenant())
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// Enqueue queues a delivery of the event for every active subscription of the tenant listening to it
+func (s *WebhookServiceImpl) Enqueue(eventType string, data interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(WebhookEvent{Event: eventType, OccurredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO webhook_deliveries(subscription_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT id, $1, $2, $3, NOW(), NOW(), NOW() FROM webhook_subscriptions
+		WHERE active AND tenant_id = $5 AND (event_types @> jsonb_build_array($1::text) OR event_types @> jsonb_build_array($4::text))`
+
+	_, err = s.DB.ExecContext(ctx, query, eventType, string(payload), DeliveryStatusPending, EventAll, s.tenant())
+	return err
+}
+
+func (s *WebhookServiceImpl) GetDeliveries(subscriptionId string) ([]*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	var exists bool
+	err := s.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $2)`, subscriptionId, s.tenant()).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC`
+
+	return s.queryDeliveries(ctx, query, subscriptionId)
+}
+
+func (s *WebhookServiceImpl) GetDeadDeliveries() ([]*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE status = $1
+		AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE tenant_id = $2) ORDER BY updated_at DESC`
+
+	return s.queryDeliveries(ctx, query, DeliveryStatusDead, s.tenant())
+}
+
+// Redeliver resets the attempts of a delivery and tries it right away
+func (s *WebhookServiceImpl) Redeliver(deliveryId string) (*WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = $2, updated_at = $2
+		WHERE id = $3 AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE tenant_id = $4)`
+
+	res, err := s.DB.ExecContext(ctx, query, DeliveryStatusPending, time.Now(), deliveryId, s.tenant())
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, ErrDeliveryNotFound
+	}
+
+	claimed, err := s.claim(ctx, `d.id = $3`, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range claimed {
+		if err := s.attempt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	found, err := s.queryDeliveries(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, ErrDeliveryNotFound
+	}
+	return found[0], nil
+}
+
+// DispatchDue sends up to limit deliveries that are due and returns how many were delivered. They are
+// claimed in batches the lease covers: every delivery of a batch is sent before its lease runs out, even
+// when each of them takes the whole client timeout, so no other dispatcher claims it again meanwhile.
+func (s *WebhookServiceImpl) DispatchDue(limit int) (int, error) {
+	delivered := 0
+	for limit > 0 {
+		claimed, err := s.claimDue(min(limit, s.leasedBatch()))
+		if err != nil {
+			return delivered, err
+		}
+		if len(claimed) == 0 {
+			return delivered, nil
+		}
+		limit -= len(claimed)
+
+		for _, c := range claimed {
+			if err := s.attempt(c); err != nil {
+				return delivered, err
+			}
+			if c.delivered {
+				delivered++
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// leasedBatch is how many deliveries can be sent one after the other within their lease
+func (s *WebhookServiceImpl) leasedBatch() int {
+	perDelivery := s.Sender.Client.Timeout + dbTimeout
+	return max(int(deliveryLease/perDelivery), 1)
+}
+
+func (s *WebhookServiceImpl) claimDue(limit int) ([]*claimedDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	return s.claim(ctx, `d.id IN (SELECT id FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED)`, limit)
+}
+
+type claimedDelivery struct {
+	id        string
+	eventType string
+	payload   string
+	attempts  int
+	url       string
+	secret    string
+	delivered bool
+}
+
+// claim leases the matching pending deliveries so concurrent dispatchers don't send them twice
+func (s *WebhookServiceImpl) claim(ctx context.Context, condition string, arg interface{}) ([]*claimedDelivery, error) {
+	query := `UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM webhook_subscriptions s
+		WHERE d.subscription_id = s.id AND d.status = $1 AND ` + condition + `
+		RETURNING d.id, d.event_type, d.payload, d.attempts, s.url, s.secret`
+
+	rows, err := s.DB.QueryContext(ctx, query, DeliveryStatusPending, time.Now().Add(deliveryLease), arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []*claimedDelivery
+	for rows.Next() {
+		var c claimedDelivery
+		if err := rows.Scan(&c.id, &c.eventType, &c.payload, &c.attempts, &c.url, &c.secret); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, &c)
+	}
+	return claimed, rows.Err()
+}
+
+// attempt sends a claimed delivery and records the outcome, scheduling a retry with backoff on failure
+func (s *WebhookServiceImpl) attempt(c *claimedDelivery) error {
+	sendCtx, cancelSend := context.WithTimeout(context.Background(), s.Sender.Client.Timeout)
+	defer cancelSend()
+
+	status, sendErr := s.Sender.Send(sendCtx, c.url, c.secret, webhooks.Message{
+		DeliveryID: c.id,
+		Event:      c.eventType,
+		Payload:    []byte(c.payload),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	now := time.Now()
+	attempts := c.attempts + 1
+
+	if sendErr == nil {
+		c.delivered = true
+		query := `UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = $3, last_error = '', delivered_at = $4, updated_at = $4 WHERE id = $5`
+		_, err := s.DB.ExecContext(ctx, query, DeliveryStatusDelivered, attempts, status, now, c.id)
+		return err
+	}
+
+	next := DeliveryStatusPending
+	if attempts >= maxDeliveryAttempts {
+		next = DeliveryStatusDead
+	}
+
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = $3, last_error = $4, next_attempt_at = $5, updated_at = $6 WHERE id = $7`
+	_, err := s.DB.ExecContext(ctx, query, next, attempts, status, sendErr.Error(), now.Add(webhooks.Backoff(attempts)), now, c.id)
+	return err
+}
+
+func (s *WebhookServiceImpl) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.LastError,
+			&delivery.ResponseStatus,
+			&delivery.NextAttemptAt,
+			&delivery.DeliveredAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package services_test
+
+import (
+	"coffee/coffee-server/mocks"
+	"coffee/coffee-server/services"
+	"coffee/coffee-server/webhooks"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Webhook dispatch", Label("unit"), func() {
+	It("should only claim as many deliveries as can be sent within their lease", func() {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer receiver.Close()
+
+		due := func(n int) *rowsOf {
+			rows := rowsOf{}
+			for range n {
+				rows = append(rows, []interface{}{"d1", services.EventCoffeeCreated, `{}`, 0, receiver.URL, "secret"})
+			}
+			return &rows
+		}
+		conn := &mocks.DBInterface{}
+		// A minute of lease covers four deliveries of ten seconds and their updates
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DeliveryStatusPending, mock.Anything, 4).Return(due(4), nil).Once()
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DeliveryStatusPending, mock.Anything, 4).Return(due(4), nil).Once()
+		conn.On("QueryContext", mock.Anything, mock.Anything, services.DeliveryStatusPending, mock.Anything, 2).Return(due(0), nil).Once()
+		conn.On("ExecContext", mock.Anything, mock.Anything, services.DeliveryStatusDelivered, 1, http.StatusOK, mock.Anything, "d1").Return(driver.RowsAffected(1), nil)
+
+		delivered, err := (&services.WebhookServiceImpl{DB: conn, Sender: webhooks.NewSender()}).DispatchDue(10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delivered).To(Equal(8))
+		conn.AssertExpectations(GinkgoT())
+	})
+})
+
+var _ = Describe("Webhook Service", Label("integration"), func() {
+	var (
+		webhookService services.WebhookService
+		receiver       *httptest.Server
+		mu             sync.Mutex
+		status         int
+		received       []*http.Request
+		bodies         [][]byte
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		received = nil
+		bodies = nil
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := io.ReadAll(r.Body)
+			received = append(received, r)
+			bodies = append(bodies, body)
+			w.WriteHeader(status)
+		}))
+
+		webhookService = services.New(conn).Webhook
+
+		_, err := db.Exec("DELETE FROM webhook_subscriptions")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		receiver.Close()
+		_, err := db.Exec("DELETE FROM webhook_subscriptions")
+		Expect(err).To(BeNil())
+	})
+
+	It("should deliver a signed event to the subscribers of its type", func() {
+		subscription, err := webhookService.CreateSubscription(services.WebhookSubscription{
+			URL:        receiver.URL,
+			EventTypes: []string{services.EventCoffeeCreated},
+		})
+		Expect(err).To(BeNil())
+		Expect(subscription.Secret).NotTo(BeEmpty())
+
+		Expect(webhookService.Enqueue(services.EventCoffeeDeleted, map[string]string{"id": "c1"})).To(Succeed())
+		Expect(webhookService.Enqueue(services.EventCoffeeCreated, services.Coffee{Name: "Espresso"})).To(Succeed())
+
+		delivered, err := webhookService.DispatchDue(10)
+		Expect(err).To(BeNil())
+		Expect(delivered).To(Equal(1))
+
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].Header.Get(webhooks.EventHeader)).To(Equal(services.EventCoffeeCreated))
+		Expect(webhooks.Verify(subscription.Secret, received[0].Header.Get(webhooks.TimestampHeader), bodies[0],
+			received[0].Header.Get(webhooks.SignatureHeader))).To(BeTrue())
+
+		deliveries, err := webhookService.GetDeliveries(subscription.ID)
+		Expect(err).To(BeNil())
+		Expect(deliveries).To(HaveLen(1))
+		Expect(deliveries[0].Status).To(Equal(services.DeliveryStatusDelivered))
+		Expect(deliveries[0].Attempts).To(Equal(1))
+	})
+
+	It("should schedule a retry when the receiver fails and redeliver on demand", func() {
+		status = http.StatusInternalServerError
+		subscription, err := webhookService.CreateSubscription(services.WebhookSubscription{
+			URL:        receiver.URL,
+			EventTypes: []string{services.EventAll},
+		})
+		Expect(err).To(BeNil())
+		Expect(webhookService.Enqueue(services.EventCoffeeUpdated, services.Coffee{ID: "c1"})).To(Succeed())
+
+		delivered, err := webhookService.DispatchDue(10)
+		Expect(err).To(BeNil())
+		Expect(delivered).To(Equal(0))
+
+		deliveries, err := webhookService.GetDeliveries(subscription.ID)
+		Expect(err).To(BeNil())
+		Expect(deliveries[0].Status).To(Equal(services.DeliveryStatusPending))
+		Expect(deliveries[0].Attempts).To(Equal(1))
+		Expect(deliveries[0].ResponseStatus).To(Equal(http.StatusInternalServerError))
+
+		// The retry is not due yet
+		delivered, err = webhookService.DispatchDue(10)
+		Expect(err).To(BeNil())
+		Expect(delivered).To(Equal(0))
+		Expect(received).To(HaveLen(1))
+
+		status = http.StatusOK
+		redelivered, err := webhookService.Redeliver(deliveries[0].ID)
+		Expect(err).To(BeNil())
+		Expect(redelivered.Status).To(Equal(services.DeliveryStatusDelivered))
+	})
+
+	It("should reject subscriptions to unknown events", func() {
+		_, err := webhookService.CreateSubscription(services.WebhookSubscription{URL: receiver.URL, EventTypes: []string{"order.created"}})
+		Expect(err).To(MatchError(services.ErrInvalidSubscription))
+	})
+})
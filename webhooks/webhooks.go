@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Coffee-Event"
+	DeliveryHeader  = "X-Coffee-Delivery"
+	TimestampHeader = "X-Coffee-Timestamp"
+	SignatureHeader = "X-Coffee-Signature"
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+type Message struct {
+	DeliveryID string
+	Event      string
+	Payload    []byte
+}
+
+// Sender posts signed webhook messages to subscribers
+type Sender struct {
+	Client *http.Client
+	Now    func() time.Time
+}
+
+func NewSender() *Sender {
+	return &Sender{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		Now:    time.Now,
+	}
+}
+
+// Send delivers the message and returns the status code of the receiver.
+// Anything but a 2xx response is returned as an error so the delivery is retried.
+func (s *Sender) Send(ctx context.Context, url string, secret string, msg Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(s.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, msg.Event)
+	req.Header.Set(DeliveryHeader, msg.DeliveryID)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, msg.Payload))
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded with %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.payload"; receivers recompute it to verify the delivery
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value as sent by Send
+func Verify(secret string, timestamp string, payload []byte, signature string) bool {
+	expected := "sha256=" + Sign(secret, timestamp, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Backoff is the wait before retry number attempt, doubling from 30 seconds up to an hour
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	wait := baseBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+// NewSecret generates a random signing secret for a new subscription
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,76 @@
+package webhooks_test
+
+import (
+	"coffee/coffee-server/webhooks"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhooks", Label("unit"), func() {
+	var (
+		sender   *webhooks.Sender
+		receiver *httptest.Server
+		received *http.Request
+		body     []byte
+		status   int
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(status)
+		}))
+
+		sender = webhooks.NewSender()
+		sender.Now = func() time.Time { return time.Unix(1700000000, 0) }
+	})
+
+	AfterEach(func() {
+		receiver.Close()
+	})
+
+	Describe("Send", func() {
+		It("should post a signed message the receiver can verify", func() {
+			msg := webhooks.Message{DeliveryID: "d1", Event: "coffee.created", Payload: []byte(`{"event":"coffee.created"}`)}
+
+			code, err := sender.Send(context.Background(), receiver.URL, "secret", msg)
+			Expect(err).To(BeNil())
+			Expect(code).To(Equal(http.StatusOK))
+
+			Expect(body).To(Equal(msg.Payload))
+			Expect(received.Header.Get(webhooks.EventHeader)).To(Equal("coffee.created"))
+			Expect(received.Header.Get(webhooks.DeliveryHeader)).To(Equal("d1"))
+			Expect(received.Header.Get(webhooks.TimestampHeader)).To(Equal("1700000000"))
+
+			signature := received.Header.Get(webhooks.SignatureHeader)
+			Expect(webhooks.Verify("secret", "1700000000", body, signature)).To(BeTrue())
+			Expect(webhooks.Verify("other", "1700000000", body, signature)).To(BeFalse())
+			Expect(webhooks.Verify("secret", "1700000001", body, signature)).To(BeFalse())
+		})
+
+		It("should fail when the receiver doesn't answer with a 2xx", func() {
+			status = http.StatusServiceUnavailable
+
+			code, err := sender.Send(context.Background(), receiver.URL, "secret", webhooks.Message{Payload: []byte(`{}`)})
+			Expect(err).To(HaveOccurred())
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Describe("Backoff", func() {
+		It("should double the wait up to an hour", func() {
+			Expect(webhooks.Backoff(1)).To(Equal(30 * time.Second))
+			Expect(webhooks.Backoff(2)).To(Equal(time.Minute))
+			Expect(webhooks.Backoff(4)).To(Equal(4 * time.Minute))
+			Expect(webhooks.Backoff(20)).To(Equal(time.Hour))
+		})
+	})
+})